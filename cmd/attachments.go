@@ -0,0 +1,444 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+const (
+	// defaultAttachmentTemplate names downloaded files after the attachment.
+	defaultAttachmentTemplate = "{filename}"
+	// maxTemplateValueLen caps each substituted value so names stay within
+	// filesystem limits.
+	maxTemplateValueLen = 80
+	// attachmentsChunkSize is how many messages are fetched at a time.
+	attachmentsChunkSize = 100
+	// attachmentTempPattern names the files attachments are downloaded to
+	// before they are saved under their final name.
+	attachmentTempPattern = ".gsuite-download-*"
+)
+
+// templatePlaceholder matches a {name} placeholder in a filename template.
+var templatePlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// attachmentTemplateFields are the placeholders a filename template may use.
+var attachmentTemplateFields = []string{"date", "from", "subject", "id", "filename"}
+
+var (
+	// attachmentsDownloadCmd flags
+	attachmentsDownloadQuery            string
+	attachmentsDownloadOut              string
+	attachmentsDownloadTemplate         string
+	attachmentsDownloadIncludeSpamTrash bool
+)
+
+// attachmentsCmd represents the attachments command group
+var attachmentsCmd = &cobra.Command{
+	Use:   "attachments",
+	Short: "Work with message attachments",
+	Long: `Commands for working with the attachments of many messages at once.
+
+Use 'messages get-attachment' to download a single attachment by ID.`,
+}
+
+// attachmentsDownloadCmd represents the attachments download command
+var attachmentsDownloadCmd = &cobra.Command{
+	Use:   "download",
+	Short: "Download the attachments of every message matching a query",
+	Long: `Download every attachment of every message matching a Gmail search query
+into the --out directory, fetching in parallel.
+
+Files are named with --template, which may use these placeholders:
+  {date}      the date the message was received (YYYY-MM-DD)
+  {from}      the sender's email address
+  {subject}   the message subject
+  {id}        the message ID
+  {filename}  the attachment's filename
+Characters that are not allowed in filenames are replaced with "_". The
+template may contain "/" to sort files into subdirectories.
+
+An attachment is skipped when a file of the same name and size already
+exists, so running the same download again only fetches what is new.
+Attachments whose content matches a file already in --out, or one saved
+earlier in the run, are not saved twice. To recognize them, every file in
+--out is read and hashed at the start of each run, which takes a while when
+--out already holds many large files. Different files that would get the
+same name are saved as "name (2).ext", "name (3).ext" and so on.`,
+	Example: `  # Download this month's invoices
+  gsuite attachments download -q "has:attachment from:billing@example.com newer_than:1m" --out ./invoices
+
+  # Name files after the date and sender
+  gsuite attachments download -q "has:attachment label:Receipts" --out ./receipts --template "{date}_{from}_{filename}"
+
+  # One directory per sender
+  gsuite attachments download -q "has:attachment filename:pdf" --out ./pdfs --template "{from}/{filename}"`,
+	Args: cobra.NoArgs,
+	RunE: runAttachmentsDownload,
+}
+
+func init() {
+	rootCmd.AddCommand(attachmentsCmd)
+	attachmentsCmd.AddCommand(attachmentsDownloadCmd)
+
+	// attachmentsDownloadCmd flags
+	attachmentsDownloadCmd.Flags().StringVarP(&attachmentsDownloadQuery, "query", "q", "", "Gmail search query selecting the messages (required)")
+	attachmentsDownloadCmd.Flags().StringVarP(&attachmentsDownloadOut, "out", "o", ".", "Directory to save the attachments in")
+	attachmentsDownloadCmd.Flags().StringVar(&attachmentsDownloadTemplate, "template", defaultAttachmentTemplate, "Filename template using {date}, {from}, {subject}, {id} and {filename}")
+	attachmentsDownloadCmd.Flags().BoolVar(&attachmentsDownloadIncludeSpamTrash, "include-spam-trash", false, "Include messages in Spam and Trash")
+	addConcurrencyFlag(attachmentsDownloadCmd)
+	attachmentsDownloadCmd.MarkFlagRequired("query")
+}
+
+// attachmentJob is one attachment to download.
+type attachmentJob struct {
+	messageID string
+	att       attachmentInfo
+	// path is where the attachment is saved, before de-duplication.
+	path string
+}
+
+// attachmentDownload is an attachment downloaded to a temporary file.
+type attachmentDownload struct {
+	tmpPath string
+	size    int64
+	hash    string
+}
+
+// savedAttachment describes a downloaded attachment.
+type savedAttachment struct {
+	MessageID string `json:"message_id"`
+	Filename  string `json:"filename"`
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+}
+
+// attachmentSaver writes downloaded attachments, skipping duplicate content.
+type attachmentSaver struct {
+	// hashes maps the SHA-256 of every file in the output directory, and of
+	// every file saved, to its path.
+	hashes map[string]string
+}
+
+func runAttachmentsDownload(cmd *cobra.Command, args []string) error {
+	if strings.TrimSpace(attachmentsDownloadQuery) == "" {
+		return fmt.Errorf("--query must not be empty")
+	}
+	if err := validateAttachmentTemplate(attachmentsDownloadTemplate); err != nil {
+		return err
+	}
+	if err := validateConcurrency(detailConcurrency); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	ids, err := listMatchingMessageIDs(ctx, service, attachmentsDownloadQuery, nil, attachmentsDownloadIncludeSpamTrash)
+	if err != nil {
+		return fmt.Errorf("Gmail API error: %w", err)
+	}
+	// Gmail lists newest first; going oldest first gives the original of a
+	// name its plain form and later files the numbered ones.
+	slices.Reverse(ids)
+
+	if err := os.MkdirAll(attachmentsDownloadOut, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	saver, err := newAttachmentSaver(attachmentsDownloadOut)
+	if err != nil {
+		return err
+	}
+
+	var saved []savedAttachment
+	var present, duplicates, failed int
+	for _, chunk := range chunkStrings(ids, attachmentsChunkSize) {
+		msgs, errs := fetchConcurrently(len(chunk), detailConcurrency, func(i int) (*gmail.Message, error) {
+			return service.Users.Messages.Get("me", chunk[i]).Format("full").Do()
+		})
+
+		var jobs []attachmentJob
+		for i, msg := range msgs {
+			if errs[i] != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to fetch message %s: %v\n", chunk[i], errs[i])
+				failed++
+				continue
+			}
+			if msg.Payload == nil {
+				continue
+			}
+			for _, att := range findAttachments(msg.Payload.Parts) {
+				name, err := renderAttachmentName(attachmentsDownloadTemplate, msg, att)
+				if err != nil {
+					return err
+				}
+				path := filepath.Join(attachmentsDownloadOut, name)
+				if info, err := os.Stat(path); err == nil && info.Size() == att.Size {
+					present++
+					continue
+				}
+				jobs = append(jobs, attachmentJob{messageID: msg.Id, att: att, path: path})
+			}
+		}
+
+		// Each worker writes its attachment to a temporary file, so only the
+		// attachments in flight are held in memory.
+		downloads, errs := fetchConcurrently(len(jobs), detailConcurrency, func(i int) (attachmentDownload, error) {
+			return downloadAttachmentToTemp(service, attachmentsDownloadOut, jobs[i])
+		})
+		for i, job := range jobs {
+			if errs[i] != nil {
+				fmt.Fprintf(os.Stderr, "Warning: message %s: %v\n", job.messageID, errs[i])
+				failed++
+				continue
+			}
+			result, dup, err := saver.save(job, downloads[i])
+			if err != nil {
+				for _, d := range downloads[i+1:] {
+					if d.tmpPath != "" {
+						os.Remove(d.tmpPath)
+					}
+				}
+				return err
+			}
+			if dup != "" {
+				duplicates++
+				if GetVerbose() {
+					fmt.Fprintf(os.Stderr, "Skipped %s from message %s: same content as %s\n", job.att.Filename, job.messageID, dup)
+				}
+				continue
+			}
+			saved = append(saved, result)
+			if GetOutputFormat() != "json" {
+				fmt.Printf("Saved %s (%d bytes)\n", result.Path, result.Size)
+			}
+		}
+	}
+
+	if GetOutputFormat() == "json" {
+		type downloadResult struct {
+			Messages   int               `json:"messages"`
+			Saved      []savedAttachment `json:"saved"`
+			Present    int               `json:"already_present"`
+			Duplicates int               `json:"duplicates"`
+			Failed     int               `json:"failed"`
+		}
+		if saved == nil {
+			saved = []savedAttachment{}
+		}
+		if err := outputJSON(downloadResult{Messages: len(ids), Saved: saved, Present: present, Duplicates: duplicates, Failed: failed}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Downloaded %d attachments from %d messages to %s (%d already present, %d duplicates)\n",
+			len(saved), len(ids), attachmentsDownloadOut, present, duplicates)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d messages or attachments failed to download; re-run to retry them", failed)
+	}
+	return nil
+}
+
+// validateAttachmentTemplate checks that template only uses known
+// placeholders and stays inside the output directory.
+func validateAttachmentTemplate(template string) error {
+	for _, m := range templatePlaceholder.FindAllStringSubmatch(template, -1) {
+		known := false
+		for _, field := range attachmentTemplateFields {
+			if m[1] == field {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown placeholder %s in --template (use {%s})", m[0], strings.Join(attachmentTemplateFields, "}, {"))
+		}
+	}
+	if !filepath.IsLocal(filepath.FromSlash(templatePlaceholder.ReplaceAllString(template, "x"))) {
+		return fmt.Errorf("--template must be a relative path inside --out: %s", template)
+	}
+	return nil
+}
+
+// renderAttachmentName fills in template for an attachment of msg. The
+// substituted values are made safe to use in a filename.
+func renderAttachmentName(template string, msg *gmail.Message, att attachmentInfo) (string, error) {
+	headers := msg.Payload.Headers
+	from := decodedHeaderValue(headers, "From")
+	if addr, err := mail.ParseAddress(from); err == nil {
+		from = addr.Address
+	}
+	values := map[string]string{
+		"date":     time.UnixMilli(msg.InternalDate).Format("2006-01-02"),
+		"from":     from,
+		"subject":  decodedHeaderValue(headers, "Subject"),
+		"id":       msg.Id,
+		"filename": decodeHeader(att.Filename),
+	}
+	name := templatePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return sanitizeFilename(values[placeholder[1:len(placeholder)-1]])
+	})
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("attachment name %q is not a relative path inside --out", name)
+	}
+	return name, nil
+}
+
+// sanitizeFilename makes value safe to use as part of a filename: path
+// separators, characters Windows rejects and control characters become "_",
+// leading and trailing dots and spaces are removed, and long values are cut.
+func sanitizeFilename(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, value)
+	if runes := []rune(value); len(runes) > maxTemplateValueLen {
+		// Keep the extension of long filenames.
+		ext := filepath.Ext(value)
+		if len([]rune(ext)) >= maxTemplateValueLen/2 {
+			ext = ""
+		}
+		value = string(runes[:maxTemplateValueLen-len([]rune(ext))]) + ext
+	}
+	value = strings.Trim(value, ". ")
+	if value == "" {
+		return "_"
+	}
+	return value
+}
+
+// downloadAttachmentToTemp downloads job's attachment into a temporary file
+// in dir, decoding and hashing it as it is written.
+func downloadAttachmentToTemp(service *gmail.Service, dir string, job attachmentJob) (attachmentDownload, error) {
+	data := job.att.Data
+	if job.att.AttachmentId != "" {
+		body, err := service.Users.Messages.Attachments.Get("me", job.messageID, job.att.AttachmentId).Do()
+		if err != nil {
+			return attachmentDownload{}, fmt.Errorf("failed to download attachment %s: %w", job.att.Filename, err)
+		}
+		data = body.Data
+	}
+
+	f, err := os.CreateTemp(dir, attachmentTempPattern)
+	if err != nil {
+		return attachmentDownload{}, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	h := sha256.New()
+	// Gmail sends base64url with or without padding; decode it unpadded.
+	decoder := base64.NewDecoder(base64.RawURLEncoding, strings.NewReader(strings.TrimRight(data, "=")))
+	size, err := io.Copy(io.MultiWriter(f, h), decoder)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return attachmentDownload{}, fmt.Errorf("failed to save attachment %s: %w", job.att.Filename, err)
+	}
+	return attachmentDownload{tmpPath: f.Name(), size: size, hash: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// newAttachmentSaver hashes the files already in dir, so attachments that
+// were downloaded before, under any name, are recognized. Temporary files
+// left behind by an interrupted run are removed.
+func newAttachmentSaver(dir string) (*attachmentSaver, error) {
+	s := &attachmentSaver{hashes: make(map[string]string)}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		if matched, _ := filepath.Match(attachmentTempPattern, d.Name()); matched {
+			return os.Remove(path)
+		}
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		if _, ok := s.hashes[sum]; !ok {
+			s.hashes[sum] = path
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output directory: %w", err)
+	}
+	return s, nil
+}
+
+// hashFile returns the hex SHA-256 of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// save moves a downloaded attachment to job's path, or to "name (n).ext" if
+// another file has that name. If a file with the same content exists, the
+// download is discarded and that file's path is returned as duplicate.
+func (s *attachmentSaver) save(job attachmentJob, download attachmentDownload) (saved savedAttachment, duplicate string, err error) {
+	// The temporary file is gone once it is linked to its name, or is not
+	// needed when anything else happens.
+	defer os.Remove(download.tmpPath)
+
+	if existing, ok := s.hashes[download.hash]; ok {
+		return savedAttachment{}, existing, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(job.path), 0755); err != nil {
+		return savedAttachment{}, "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	path := job.path
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	// Link rather than rename, so an existing file is never replaced.
+	for n := 2; ; n++ {
+		err := os.Link(download.tmpPath, path)
+		if os.IsExist(err) {
+			path = fmt.Sprintf("%s (%d)%s", base, n, ext)
+			continue
+		}
+		if err != nil {
+			return savedAttachment{}, "", fmt.Errorf("failed to write attachment file: %w", err)
+		}
+		break
+	}
+
+	s.hashes[download.hash] = path
+	return savedAttachment{
+		MessageID: job.messageID,
+		Filename:  job.att.Filename,
+		Path:      path,
+		Size:      download.size,
+		SHA256:    download.hash,
+	}, "", nil
+}
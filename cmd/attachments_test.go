@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestRenderAttachmentName(t *testing.T) {
+	t.Parallel()
+
+	received := time.Date(2026, 3, 4, 12, 0, 0, 0, time.Local)
+	msg := &gmail.Message{
+		Id:           "18c0ffee",
+		InternalDate: received.UnixMilli(),
+		Payload: &gmail.MessagePart{Headers: []*gmail.MessagePartHeader{
+			{Name: "From", Value: "=?UTF-8?Q?Billing_Caf=C3=A9?= <billing@example.com>"},
+			{Name: "Subject", Value: "Invoice 2026/03: paid?"},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		filename string
+		want     string
+	}{
+		{name: "should use the filename by default", template: defaultAttachmentTemplate, filename: "invoice.pdf", want: "invoice.pdf"},
+		{name: "should fill in date and sender address", template: "{date}_{from}_{filename}", filename: "invoice.pdf", want: "2026-03-04_billing@example.com_invoice.pdf"},
+		{name: "should replace unsafe characters in values", template: "{subject} {id}.pdf", filename: "x", want: "Invoice 2026_03_ paid_ 18c0ffee.pdf"},
+		{name: "should allow subdirectories in the template", template: "{from}/{filename}", filename: "a.pdf", want: filepath.Join("billing@example.com", "a.pdf")},
+		{name: "should not let a filename escape the directory", template: "{filename}", filename: "../../etc/passwd", want: "_.._etc_passwd"},
+		{name: "should decode an encoded filename", template: "{filename}", filename: "=?UTF-8?Q?r=C3=A9sum=C3=A9.pdf?=", want: "résumé.pdf"},
+		{name: "should replace an empty value", template: "{filename}", filename: "...", want: "_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := renderAttachmentName(tt.template, msg, attachmentInfo{Filename: tt.filename})
+			if err != nil {
+				t.Fatalf("renderAttachmentName() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("renderAttachmentName(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("a", 200) + ".pdf"
+	got := sanitizeFilename(long)
+	if len([]rune(got)) != maxTemplateValueLen || !strings.HasSuffix(got, ".pdf") {
+		t.Errorf("sanitizeFilename(long) = %q, want %d runes ending in .pdf", got, maxTemplateValueLen)
+	}
+	if got := sanitizeFilename("tab\there\x00"); got != "tab_here_" {
+		t.Errorf("sanitizeFilename() = %q, want control characters replaced", got)
+	}
+}
+
+func TestValidateAttachmentTemplate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		template string
+		wantErr  string
+	}{
+		{name: "should accept known placeholders", template: "{date}_{from}/{subject}_{id}_{filename}"},
+		{name: "should reject unknown placeholders", template: "{name}.pdf", wantErr: "unknown placeholder {name}"},
+		{name: "should reject absolute paths", template: "/tmp/{filename}", wantErr: "relative path"},
+		{name: "should reject parent directories", template: "../{filename}", wantErr: "relative path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateAttachmentTemplate(tt.template)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateAttachmentTemplate(%q) error = %v", tt.template, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateAttachmentTemplate(%q) error = %v, want %q", tt.template, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// e2eAttachmentMessage returns a message from sender with one attachment.
+func e2eAttachmentMessage(sender, subject, filename, content string) string {
+	return "From: " + sender + "\r\n" +
+		"To: me@example.com\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"b1\"\r\n" +
+		"\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"See attached.\r\n" +
+		"--b1\r\n" +
+		"Content-Type: application/pdf\r\n" +
+		"Content-Disposition: attachment; filename=\"" + filename + "\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		base64.StdEncoding.EncodeToString([]byte(content)) + "\r\n" +
+		"--b1--\r\n"
+}
+
+func TestE2EAttachmentsDownload(t *testing.T) {
+	srv := newE2EServer(t)
+	srv.AddMessage(e2eAttachmentMessage("billing@example.com", "January invoice", "invoice.pdf", "%PDF january"), "INBOX")
+	srv.AddMessage(e2eAttachmentMessage("billing@example.com", "January invoice (resent)", "invoice.pdf", "%PDF january"), "INBOX")
+	srv.AddMessage(e2eAttachmentMessage("billing@example.com", "February invoice", "invoice.pdf", "%PDF february 2026"), "INBOX")
+	srv.AddMessage(e2eAttachmentMessage("alice@example.com", "Photos", "photo.pdf", "%PDF photo"), "INBOX")
+	srv.AddMessage(e2eInboxMessage, "INBOX")
+	out := filepath.Join(t.TempDir(), "invoices")
+
+	got := mustRunCLI(t, "attachments", "download", "-q", "has:attachment from:billing@", "--out", out)
+	if !strings.Contains(got, "Downloaded 2 attachments from 3 messages") || !strings.Contains(got, "0 already present, 1 duplicates") {
+		t.Errorf("unexpected summary:\n%s", got)
+	}
+	want := map[string]string{
+		"invoice.pdf":     "%PDF january",
+		"invoice (2).pdf": "%PDF february 2026",
+	}
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != len(want) {
+		t.Errorf("got %d files, want %d", len(entries), len(want))
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Errorf("reading %s: %v", name, err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", name, data, content)
+		}
+	}
+
+	t.Run("should skip files it already has", func(t *testing.T) {
+		got := mustRunCLI(t, "attachments", "download", "-q", "has:attachment from:billing@", "--out", out)
+		if !strings.Contains(got, "Downloaded 0 attachments") || !strings.Contains(got, "2 already present, 1 duplicates") {
+			t.Errorf("unexpected summary on re-run:\n%s", got)
+		}
+		if entries, _ := os.ReadDir(out); len(entries) != len(want) {
+			t.Errorf("re-run wrote new files: got %d, want %d", len(entries), len(want))
+		}
+	})
+
+	t.Run("should remove temporary files left by an interrupted run", func(t *testing.T) {
+		dir := t.TempDir()
+		leftover := filepath.Join(dir, ".gsuite-download-123")
+		if err := os.WriteFile(leftover, []byte("%PDF photo"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got := mustRunCLI(t, "attachments", "download", "-q", "from:alice@", "--out", dir)
+		if !strings.Contains(got, "Downloaded 1 attachments") {
+			t.Errorf("unexpected summary:\n%s", got)
+		}
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("leftover temporary file still exists: %v", err)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 || entries[0].Name() != "photo.pdf" {
+			t.Errorf("files = %v, want only photo.pdf", entries)
+		}
+	})
+
+	t.Run("should name files from the template", func(t *testing.T) {
+		dir := t.TempDir()
+		mustRunCLI(t, "attachments", "download", "-q", "has:attachment", "--out", dir, "--template", "{from}/{filename}")
+		for _, name := range []string{"billing@example.com/invoice.pdf", "alice@example.com/photo.pdf"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				t.Errorf("missing %s: %v", name, err)
+			}
+		}
+	})
+
+	t.Run("should reject unknown placeholders", func(t *testing.T) {
+		_, err := runCLI(t, "attachments", "download", "-q", "has:attachment", "--template", "{sender}")
+		if err == nil || !strings.Contains(err.Error(), "unknown placeholder") {
+			t.Errorf("error = %v, want unknown placeholder", err)
+		}
+	})
+}
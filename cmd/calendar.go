@@ -249,10 +249,15 @@ func init() {
 }
 
 func resolveTimezone() (*time.Location, error) {
-	if calendarTimezone != "" {
-		loc, err := time.LoadLocation(calendarTimezone)
+	return loadTimezone(calendarTimezone)
+}
+
+// loadTimezone returns the named IANA location, or the local timezone if name is empty.
+func loadTimezone(name string) (*time.Location, error) {
+	if name != "" {
+		loc, err := time.LoadLocation(name)
 		if err != nil {
-			return nil, fmt.Errorf("invalid timezone %q: %w", calendarTimezone, err)
+			return nil, fmt.Errorf("invalid timezone %q: %w", name, err)
 		}
 		return loc, nil
 	}
@@ -24,13 +24,22 @@ var dayNames = map[string]time.Weekday{
 func parseDateTime(input string, loc *time.Location, now time.Time) (time.Time, error) {
 	input = strings.TrimSpace(input)
 	if input == "" {
-		return time.Time{}, fmt.Errorf("empty datetime input; accepted formats: RFC3339, 2006-01-02, 2006-01-02 15:04, 2006-01-02T15:04:05, 15:04, today, tomorrow, monday-sunday, +Nd")
+		return time.Time{}, fmt.Errorf("empty datetime input; accepted formats: RFC3339, 2006-01-02, 2006-01-02 15:04, 2006-01-02T15:04:05, 15:04, today, tomorrow, monday-sunday, +Nd, or one of those days with a time like \"tomorrow 09:00\"")
 	}
 
 	if t, ok := parseRelative(input, loc, now); ok {
 		return t, nil
 	}
 
+	// Relative day with a time: "tomorrow 09:00", "friday 14:30", "+2d 08:00"
+	if day, clock, found := strings.Cut(input, " "); found {
+		if d, ok := parseRelative(day, loc, now); ok {
+			if t, err := time.ParseInLocation("15:04", strings.TrimSpace(clock), loc); err == nil {
+				return time.Date(d.Year(), d.Month(), d.Day(), t.Hour(), t.Minute(), 0, 0, loc), nil
+			}
+		}
+	}
+
 	if t, err := time.Parse(time.RFC3339, input); err == nil {
 		return t, nil
 	}
@@ -51,7 +60,7 @@ func parseDateTime(input string, loc *time.Location, now time.Time) (time.Time,
 		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, loc), nil
 	}
 
-	return time.Time{}, fmt.Errorf("cannot parse %q; accepted formats: RFC3339, 2006-01-02, 2006-01-02 15:04, 2006-01-02T15:04:05, 15:04, today, tomorrow, monday-sunday, +Nd", input)
+	return time.Time{}, fmt.Errorf("cannot parse %q; accepted formats: RFC3339, 2006-01-02, 2006-01-02 15:04, 2006-01-02T15:04:05, 15:04, today, tomorrow, monday-sunday, +Nd, or one of those days with a time like \"tomorrow 09:00\"", input)
 }
 
 func parseRelative(input string, loc *time.Location, now time.Time) (time.Time, bool) {
@@ -69,6 +69,26 @@ func TestParseDateTime(t *testing.T) {
 			input: "friday",
 			want:  time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC),
 		},
+		{
+			name:  "tomorrow with a time",
+			input: "tomorrow 09:00",
+			want:  time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "weekday with a time",
+			input: "Friday 14:30",
+			want:  time.Date(2026, 3, 20, 14, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "relative days with a time",
+			input: "+2d 08:15",
+			want:  time.Date(2026, 3, 17, 8, 15, 0, 0, time.UTC),
+		},
+		{
+			name:    "relative day with a bad time",
+			input:   "tomorrow noon",
+			wantErr: true,
+		},
 		{
 			name:  "leap year date",
 			input: "2024-02-29",
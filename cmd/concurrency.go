@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	// defaultConcurrency is the default number of parallel detail requests.
+	defaultConcurrency = 10
+	// maxConcurrency bounds parallel detail requests. Gmail's per-user quota
+	// (250 units/s; a messages.get costs 5 units) allows about 50 gets a
+	// second, which a handful of workers can already exceed, so throttled
+	// requests are retried by fetchConcurrently rather than prevented here.
+	maxConcurrency = 20
+
+	// rateLimitRetries is how many times a throttled request is retried.
+	rateLimitRetries = 5
+	// rateLimitBackoff is the delay before the first retry; it doubles on
+	// each further attempt.
+	rateLimitBackoff = time.Second
+)
+
+// detailConcurrency is the --concurrency flag shared by the list commands.
+var detailConcurrency int
+
+// addConcurrencyFlag registers --concurrency on cmd.
+func addConcurrencyFlag(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&detailConcurrency, "concurrency", defaultConcurrency, fmt.Sprintf("Number of details to fetch in parallel (1-%d)", maxConcurrency))
+}
+
+// validateConcurrency checks that n is within the allowed worker range.
+func validateConcurrency(n int) error {
+	if n < 1 || n > maxConcurrency {
+		return fmt.Errorf("--concurrency must be between 1 and %d", maxConcurrency)
+	}
+	return nil
+}
+
+// fetchConcurrently calls fetch for every index in [0, n) using at most
+// workers goroutines. Results and errors are returned in index order, so
+// callers can print them in the same order as the original listing. Calls
+// rejected by Gmail's rate limit are retried with backoff.
+func fetchConcurrently[T any](n, workers int, fetch func(i int) (T, error)) ([]T, []error) {
+	results := make([]T, n)
+	errs := make([]error, n)
+	if n == 0 {
+		return results, errs
+	}
+
+	workers = max(1, min(workers, n))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = retryRateLimited(rateLimitBackoff, func() (T, error) {
+					return fetch(i)
+				})
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, errs
+}
+
+// retryRateLimited calls fetch until it succeeds, fails with an error other
+// than a rate limit, or rateLimitRetries retries are used up. The delay
+// starts at backoff and doubles each time, with jitter so that throttled
+// workers do not retry in lockstep.
+func retryRateLimited[T any](backoff time.Duration, fetch func() (T, error)) (T, error) {
+	result, err := fetch()
+	for attempt := 0; attempt < rateLimitRetries && isRateLimitError(err); attempt++ {
+		time.Sleep(backoff + rand.N(backoff/2+1))
+		backoff *= 2
+		result, err = fetch()
+	}
+	return result, err
+}
+
+// isRateLimitError reports whether err is Gmail rejecting a request for
+// exceeding a rate limit: a 429, or a 403 with a rateLimitExceeded or
+// userRateLimitExceeded reason.
+func isRateLimitError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	if apiErr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, e := range apiErr.Errors {
+		if e.Reason == "rateLimitExceeded" || e.Reason == "userRateLimitExceeded" {
+			return true
+		}
+	}
+	return false
+}
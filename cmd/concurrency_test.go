@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestFetchConcurrentlyPreservesOrder(t *testing.T) {
+	t.Parallel()
+	results, errs := fetchConcurrently(50, 8, func(i int) (int, error) {
+		// Finish later indexes first to shuffle completion order
+		time.Sleep(time.Duration(50-i) * 100 * time.Microsecond)
+		return i * i, nil
+	})
+
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatalf("errs[%d] = %v, want nil", i, errs[i])
+		}
+		if results[i] != i*i {
+			t.Errorf("results[%d] = %d, want %d", i, results[i], i*i)
+		}
+	}
+}
+
+func TestFetchConcurrentlyBoundsWorkers(t *testing.T) {
+	t.Parallel()
+	var inFlight, peak int32
+	fetchConcurrently(40, 4, func(i int) (struct{}, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return struct{}{}, nil
+	})
+
+	if peak > 4 {
+		t.Errorf("peak concurrency = %d, want <= 4", peak)
+	}
+}
+
+func TestFetchConcurrentlyReportsErrorsByIndex(t *testing.T) {
+	t.Parallel()
+	errBoom := errors.New("boom")
+	_, errs := fetchConcurrently(5, 2, func(i int) (string, error) {
+		if i == 3 {
+			return "", errBoom
+		}
+		return "ok", nil
+	})
+
+	for i, err := range errs {
+		if i == 3 {
+			if !errors.Is(err, errBoom) {
+				t.Errorf("errs[3] = %v, want %v", err, errBoom)
+			}
+		} else if err != nil {
+			t.Errorf("errs[%d] = %v, want nil", i, err)
+		}
+	}
+}
+
+func TestFetchConcurrentlyEmpty(t *testing.T) {
+	t.Parallel()
+	results, errs := fetchConcurrently(0, 10, func(i int) (int, error) {
+		t.Fatal("fetch should not be called")
+		return 0, nil
+	})
+	if len(results) != 0 || len(errs) != 0 {
+		t.Errorf("got %d results and %d errors, want none", len(results), len(errs))
+	}
+}
+
+func TestValidateConcurrency(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		n       int
+		wantErr bool
+	}{
+		{name: "should accept one", n: 1},
+		{name: "should accept maximum", n: maxConcurrency},
+		{name: "should reject zero", n: 0, wantErr: true},
+		{name: "should reject above maximum", n: maxConcurrency + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateConcurrency(tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateConcurrency(%d) error = %v, wantErr %v", tt.n, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryRateLimited(t *testing.T) {
+	t.Parallel()
+	throttled := &googleapi.Error{Code: http.StatusTooManyRequests}
+	notFound := &googleapi.Error{Code: http.StatusNotFound}
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "should not retry success", wantCalls: 1},
+		{name: "should retry until throttling stops", failures: 3, err: throttled, wantCalls: 4},
+		{name: "should give up after the retry limit", failures: 100, err: throttled, wantCalls: rateLimitRetries + 1, wantErr: true},
+		{name: "should not retry other errors", failures: 100, err: notFound, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			calls := 0
+			got, err := retryRateLimited(time.Microsecond, func() (int, error) {
+				calls++
+				if calls <= tt.failures {
+					return 0, tt.err
+				}
+				return 42, nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != 42 {
+				t.Errorf("result = %d, want 42", got)
+			}
+		})
+	}
+}
+
+func TestIsRateLimitError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "should match 429", err: &googleapi.Error{Code: http.StatusTooManyRequests}, want: true},
+		{name: "should match 403 rateLimitExceeded", err: &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}, want: true},
+		{name: "should match 403 userRateLimitExceeded", err: &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "userRateLimitExceeded"}}}, want: true},
+		{name: "should not match other 403s", err: &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "insufficientPermissions"}}}},
+		{name: "should not match 500", err: &googleapi.Error{Code: http.StatusInternalServerError}},
+		{name: "should not match plain errors", err: errors.New("boom")},
+		{name: "should not match nil", err: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := isRateLimitError(tt.err); got != tt.want {
+				t.Errorf("isRateLimitError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 var (
 	// draftsListCmd flags
 	draftsMaxResults int64
+	draftsListPages  pageOptions
 
 	// draftsCreateCmd flags
 	draftTo      string
@@ -20,6 +21,8 @@ var (
 	draftBody    string
 	draftCc      string
 	draftBcc     string
+	draftFrom    string
+	draftSig     bool
 )
 
 // draftsCmd represents the drafts command group
@@ -38,12 +41,19 @@ var draftsListCmd = &cobra.Command{
 	Short: "List drafts in the mailbox",
 	Long: `List drafts in the authenticated user's Gmail mailbox.
 
-Returns draft ID, message ID, subject, and snippet for each draft.`,
+Returns draft ID, message ID, subject, and snippet for each draft.
+
+JSON output is an array of drafts, with any next page token on stderr.
+Passing --page-token, --all or --limit makes it an object with "drafts" and
+"next_page_token"; --page-token "" does so for the first page.`,
 	Example: `  # List last 10 drafts
   gsuite drafts list
 
   # List up to 50 drafts
-  gsuite drafts list -n 50`,
+  gsuite drafts list -n 50
+
+  # List every draft
+  gsuite drafts list --all`,
 	RunE: runDraftsList,
 }
 
@@ -73,12 +83,17 @@ Required flags:
 
 Optional flags:
   --cc: CC recipients (comma-separated)
-  --bcc: BCC recipients (comma-separated)`,
+  --bcc: BCC recipients (comma-separated)
+  --from: Send from this verified send-as alias
+  --signature: Append the sender's signature (default from $GSUITE_SIGNATURE)`,
 	Example: `  # Create a simple draft
   gsuite drafts create --to "user@example.com" --subject "Hello" --body "Draft content"
 
   # Create a draft with CC and BCC
-  gsuite drafts create -t "user@example.com" -s "Meeting" -b "Let's meet" --cc "cc@example.com"`,
+  gsuite drafts create -t "user@example.com" -s "Meeting" -b "Let's meet" --cc "cc@example.com"
+
+  # Create a draft from an alias with its signature
+  gsuite drafts create -t "user@example.com" -s "Hello" -b "Hi there" --from "support@example.com" --signature`,
 	RunE: runDraftsCreate,
 }
 
@@ -150,7 +165,9 @@ func init() {
 	draftsCmd.AddCommand(draftsDeleteCmd)
 
 	// draftsListCmd flags
-	draftsListCmd.Flags().Int64VarP(&draftsMaxResults, "max-results", "n", 10, "Maximum number of drafts to return (max 500)")
+	draftsListCmd.Flags().Int64VarP(&draftsMaxResults, "max-results", "n", 10, "Maximum number of drafts per page (max 500)")
+	addPageFlags(draftsListCmd, &draftsListPages)
+	addConcurrencyFlag(draftsListCmd)
 
 	// draftsCreateCmd flags
 	draftsCreateCmd.Flags().StringVarP(&draftTo, "to", "t", "", "Recipient email address (required)")
@@ -158,6 +175,7 @@ func init() {
 	draftsCreateCmd.Flags().StringVarP(&draftBody, "body", "b", "", "Plain text body content (required)")
 	draftsCreateCmd.Flags().StringVar(&draftCc, "cc", "", "CC recipients (comma-separated)")
 	draftsCreateCmd.Flags().StringVar(&draftBcc, "bcc", "", "BCC recipients (comma-separated)")
+	addSenderFlags(draftsCreateCmd, &draftFrom, &draftSig)
 	draftsCreateCmd.MarkFlagRequired("to")
 	draftsCreateCmd.MarkFlagRequired("subject")
 	draftsCreateCmd.MarkFlagRequired("body")
@@ -171,6 +189,13 @@ func init() {
 }
 
 func runDraftsList(cmd *cobra.Command, args []string) error {
+	if err := draftsListPages.validate(); err != nil {
+		return err
+	}
+	if err := validateConcurrency(detailConcurrency); err != nil {
+		return err
+	}
+
 	ctx := context.Background()
 
 	service, err := auth.NewGmailService(ctx, GetAccountEmail())
@@ -178,29 +203,16 @@ func runDraftsList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
-	// Build the list request
-	listCall := service.Users.Drafts.List("me")
-
-	// Apply max results (cap at 500)
-	if draftsMaxResults > 500 {
-		draftsMaxResults = 500
-	}
-	listCall.MaxResults(draftsMaxResults)
-
-	// Execute the request
-	resp, err := listCall.Do()
+	// Execute the request, following pages if requested
+	drafts, nextPageToken, err := listDraftPages(ctx, service.Users.Drafts.List("me"), &draftsListPages, draftsMaxResults)
 	if err != nil {
 		return fmt.Errorf("Gmail API error: %w", err)
 	}
 
-	// Check if no drafts found
-	if len(resp.Drafts) == 0 {
-		if GetOutputFormat() == "json" {
-			return outputJSON([]struct{}{})
-		}
-		fmt.Println("No drafts found.")
-		return nil
-	}
+	// Fetch draft details in parallel, preserving list order
+	details, detailErrs := fetchConcurrently(len(drafts), detailConcurrency, func(i int) (*gmail.Draft, error) {
+		return service.Users.Drafts.Get("me", drafts[i].Id).Format("metadata").Do()
+	})
 
 	// JSON output mode
 	if GetOutputFormat() == "json" {
@@ -211,17 +223,17 @@ func runDraftsList(cmd *cobra.Command, args []string) error {
 			Snippet   string `json:"snippet"`
 		}
 		var results []draftListItem
-		for _, draft := range resp.Drafts {
-			detail, err := service.Users.Drafts.Get("me", draft.Id).Format("metadata").Do()
-			if err != nil {
+		for i, draft := range drafts {
+			if detailErrs[i] != nil {
 				results = append(results, draftListItem{DraftID: draft.Id})
 				continue
 			}
+			detail := details[i]
 			var subject, snippet, messageID string
 			if detail.Message != nil && detail.Message.Payload != nil {
 				for _, header := range detail.Message.Payload.Headers {
 					if header.Name == "Subject" {
-						subject = header.Value
+						subject = decodeHeader(header.Value)
 						break
 					}
 				}
@@ -237,25 +249,33 @@ func runDraftsList(cmd *cobra.Command, args []string) error {
 				Snippet:   snippet,
 			})
 		}
-		return outputJSON(results)
+		if results == nil {
+			results = []draftListItem{}
+		}
+		return outputListJSON(cmd, "drafts", results, nextPageToken)
+	}
+
+	// Check if no drafts found
+	if len(drafts) == 0 {
+		fmt.Println("No drafts found.")
+		return nil
 	}
 
 	// Print results (text mode)
-	fmt.Printf("Drafts (%d):\n\n", len(resp.Drafts))
-	for _, draft := range resp.Drafts {
-		// Get draft details
-		detail, err := service.Users.Drafts.Get("me", draft.Id).Format("metadata").Do()
-		if err != nil {
+	fmt.Printf("Drafts (%d):\n\n", len(drafts))
+	for i, draft := range drafts {
+		if detailErrs[i] != nil {
 			fmt.Printf("Draft ID: %s  (error fetching details)\n", draft.Id)
 			continue
 		}
+		detail := details[i]
 
 		// Extract subject from headers
 		var subject string
 		if detail.Message != nil && detail.Message.Payload != nil {
 			for _, header := range detail.Message.Payload.Headers {
 				if header.Name == "Subject" {
-					subject = header.Value
+					subject = decodeHeader(header.Value)
 					break
 				}
 			}
@@ -283,8 +303,8 @@ func runDraftsList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Indicate if more results are available
-	if resp.NextPageToken != "" {
-		fmt.Println("More results available (pagination token exists)")
+	if nextPageToken != "" {
+		fmt.Println(nextPageHint(nextPageToken))
 	}
 
 	return nil
@@ -315,9 +335,9 @@ func runDraftsGet(cmd *cobra.Command, args []string) error {
 	for _, header := range draft.Message.Payload.Headers {
 		switch header.Name {
 		case "To":
-			to = header.Value
+			to = decodeHeader(header.Value)
 		case "Subject":
-			subject = header.Value
+			subject = decodeHeader(header.Value)
 		case "Date":
 			date = header.Value
 		}
@@ -419,8 +439,13 @@ func runDraftsCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
+	extra, signature, err := resolveSender(service, draftFrom, useSignature(cmd, draftSig))
+	if err != nil {
+		return err
+	}
+
 	// Build RFC 2822 formatted message
-	message := buildRFC2822Message(draftTo, draftSubject, draftBody, draftCc, draftBcc)
+	message := buildRFC2822Message(draftTo, draftSubject, appendPlainSignature(draftBody, signature), draftCc, draftBcc, extra...)
 
 	// Base64url encode the message
 	encodedMessage := base64.URLEncoding.EncodeToString([]byte(message))
@@ -478,7 +503,7 @@ func runDraftsUpdate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Extract existing values from headers
-	var existingTo, existingSubject, existingCc, existingBcc string
+	var existingTo, existingSubject, existingCc, existingBcc, existingFrom string
 	if existing.Message != nil && existing.Message.Payload != nil {
 		for _, header := range existing.Message.Payload.Headers {
 			switch header.Name {
@@ -490,6 +515,8 @@ func runDraftsUpdate(cmd *cobra.Command, args []string) error {
 				existingCc = header.Value
 			case "Bcc":
 				existingBcc = header.Value
+			case "From":
+				existingFrom = header.Value
 			}
 		}
 	}
@@ -519,8 +546,14 @@ func runDraftsUpdate(cmd *cobra.Command, args []string) error {
 		finalBcc = draftBcc
 	}
 
+	// Keep the sender chosen with --from when the draft was created
+	var extra []mailHeader
+	if existingFrom != "" {
+		extra = append(extra, mailHeader{Name: "From", Value: existingFrom})
+	}
+
 	// Build updated RFC 2822 message
-	message := buildRFC2822Message(finalTo, finalSubject, finalBody, finalCc, finalBcc)
+	message := buildRFC2822Message(finalTo, finalSubject, finalBody, finalCc, finalBcc, extra...)
 
 	// Base64url encode the message
 	encodedMessage := base64.URLEncoding.EncodeToString([]byte(message))
@@ -624,7 +657,7 @@ func runDraftsDelete(cmd *cobra.Command, args []string) error {
 }
 
 // buildRFC2822Message builds an RFC 2822 formatted email message.
-func buildRFC2822Message(to, subject, body, cc, bcc string) string {
+func buildRFC2822Message(to, subject, body, cc, bcc string, extra ...mailHeader) string {
 	var msg string
 
 	msg += fmt.Sprintf("To: %s\r\n", to)
@@ -635,6 +668,9 @@ func buildRFC2822Message(to, subject, body, cc, bcc string) string {
 		msg += fmt.Sprintf("Bcc: %s\r\n", bcc)
 	}
 	msg += fmt.Sprintf("Subject: %s\r\n", subject)
+	for _, h := range extra {
+		msg += fmt.Sprintf("%s: %s\r\n", h.Name, h.Value)
+	}
 	msg += "MIME-Version: 1.0\r\n"
 	msg += "Content-Type: text/plain; charset=\"UTF-8\"\r\n"
 	msg += "\r\n"
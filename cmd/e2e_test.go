@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/khang/google-suite-cli/internal/fakegoogle"
+	"github.com/spf13/pflag"
+)
+
+const e2eInboxMessage = "From: Alice <alice@example.com>\r\n" +
+	"To: me@example.com\r\n" +
+	"Subject: Quarterly report\r\n" +
+	"Date: Thu, 15 Jan 2026 09:00:00 +0000\r\n" +
+	"Message-ID: <report@example.com>\r\n" +
+	"\r\n" +
+	"The numbers are in.\r\n"
+
+// newE2EServer starts a fake Google API server and points the CLI at it.
+// Tests using it must not run in parallel: the CLI keeps state in globals.
+func newE2EServer(t *testing.T) *fakegoogle.Server {
+	t.Helper()
+	srv := fakegoogle.NewServer("me@example.com")
+	t.Cleanup(srv.Close)
+	t.Setenv(auth.APIEndpointEnv, srv.URL)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GSUITE_ACCOUNT", "")
+	t.Setenv(signatureEnv, "")
+	return srv
+}
+
+// runCLI executes gsuite with args and returns what it printed to stdout.
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	// Flag variables are globals, so reset the target command's flags to
+	// their defaults before each run, as a fresh process would have them.
+	target, _, err := rootCmd.Find(args)
+	if err != nil {
+		t.Fatalf("unknown command %v: %v", args, err)
+	}
+	resetFlags(rootCmd.PersistentFlags())
+	resetFlags(target.Flags())
+
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() error: %v", err)
+	}
+	os.Stdout = w
+	var buf bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r) //nolint:errcheck
+		close(copied)
+	}()
+
+	rootCmd.SetArgs(args)
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+	runErr := rootCmd.Execute()
+	rootCmd.SetOut(nil)
+	rootCmd.SetErr(nil)
+
+	w.Close()
+	<-copied
+	os.Stdout = oldStdout
+	return buf.String(), runErr
+}
+
+// resetFlags restores every flag in fs to its default value.
+func resetFlags(fs *pflag.FlagSet) {
+	fs.VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace(nil) //nolint:errcheck
+		} else {
+			f.Value.Set(f.DefValue) //nolint:errcheck
+		}
+		f.Changed = false
+	})
+}
+
+// mustRunCLI runs gsuite with args, failing the test on error.
+func mustRunCLI(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := runCLI(t, args...)
+	if err != nil {
+		t.Fatalf("gsuite %s: %v", strings.Join(args, " "), err)
+	}
+	return out
+}
+
+func TestE2EMessagesListAndGet(t *testing.T) {
+	srv := newE2EServer(t)
+	id := srv.AddMessage(e2eInboxMessage, "INBOX", "UNREAD")
+
+	var list []struct {
+		ID      string `json:"id"`
+		Snippet string `json:"snippet"`
+	}
+	out := mustRunCLI(t, "messages", "list", "-f", "json")
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatalf("messages list output is not a JSON array: %v\n%s", err, out)
+	}
+	if len(list) != 1 || list[0].ID != id || list[0].Snippet != "The numbers are in." {
+		t.Errorf("messages list = %+v, want the seeded message", list)
+	}
+
+	out = mustRunCLI(t, "messages", "get", id)
+	if !strings.Contains(out, "The numbers are in.") {
+		t.Errorf("messages get output missing body:\n%s", out)
+	}
+
+	mustRunCLI(t, "messages", "modify", id, "--remove-labels", "UNREAD")
+	if msg, _ := srv.Message(id); strings.Contains(strings.Join(msg.LabelIds, ","), "UNREAD") {
+		t.Errorf("messages modify left labels %v", msg.LabelIds)
+	}
+}
+
+const e2eHTMLMessage = "From: Shop <news@shop.example.com>\r\n" +
+	"To: me@example.com\r\n" +
+	"Subject: Sale\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: text/html; charset=UTF-8\r\n" +
+	"\r\n" +
+	"<html><body><p>Big <b>sale</b> today.</p><p><a href=\"https://shop.example.com/sale\">Shop now</a></p></body></html>\r\n"
+
+func TestE2EMessagesGetHTML(t *testing.T) {
+	srv := newE2EServer(t)
+	id := srv.AddMessage(e2eHTMLMessage, "INBOX")
+
+	out := mustRunCLI(t, "messages", "get", id)
+	if !strings.Contains(out, "Big sale today.\n\nShop now[1]\n\n[1] https://shop.example.com/sale") {
+		t.Errorf("messages get output is not the HTML body as text:\n%s", out)
+	}
+
+	out = mustRunCLI(t, "messages", "get", id, "--html")
+	if !strings.Contains(out, "<p>Big <b>sale</b> today.</p>") {
+		t.Errorf("messages get --html output = %q, want the HTML part", out)
+	}
+
+	out = mustRunCLI(t, "messages", "get", id, "--raw")
+	if out != e2eHTMLMessage {
+		t.Errorf("messages get --raw output = %q, want the original source", out)
+	}
+
+	plain := srv.AddMessage(e2eInboxMessage, "INBOX")
+	if _, err := runCLI(t, "messages", "get", plain, "--html"); err == nil || !strings.Contains(err.Error(), "no HTML part") {
+		t.Errorf("messages get --html on a plain message error = %v, want no HTML part", err)
+	}
+
+	thread, _ := srv.Message(id)
+	out = mustRunCLI(t, "threads", "get", thread.ThreadId)
+	if !strings.Contains(out, "Shop now[1]") {
+		t.Errorf("threads get output is not the HTML body as text:\n%s", out)
+	}
+}
+
+func TestE2ESendAndReply(t *testing.T) {
+	srv := newE2EServer(t)
+	original := srv.AddMessage(e2eInboxMessage, "INBOX")
+
+	mustRunCLI(t, "send", "--to", "bob@example.com", "--subject", "Hello", "--body", "Hi **Bob**")
+	sent := srv.MessageIDs("SENT")
+	if len(sent) != 1 {
+		t.Fatalf("after send, SENT has %d messages, want 1", len(sent))
+	}
+	raw, _ := srv.RawMessage(sent[0])
+	if !strings.Contains(string(raw), "To: bob@example.com") || !strings.Contains(string(raw), "<strong>Bob</strong>") {
+		t.Errorf("sent message missing recipient or HTML body:\n%s", raw)
+	}
+
+	mustRunCLI(t, "messages", "reply", original, "--body", "Thanks!")
+	sent = srv.MessageIDs("SENT")
+	if len(sent) != 2 {
+		t.Fatalf("after reply, SENT has %d messages, want 2", len(sent))
+	}
+	reply, _ := srv.Message(sent[1])
+	parent, _ := srv.Message(original)
+	if reply.ThreadId != parent.ThreadId {
+		t.Errorf("reply thread = %s, want %s", reply.ThreadId, parent.ThreadId)
+	}
+	if got := headerValue(reply.Payload.Headers, "In-Reply-To"); got != "<report@example.com>" {
+		t.Errorf("reply In-Reply-To = %q, want <report@example.com>", got)
+	}
+}
+
+func TestE2ELabelsAndDrafts(t *testing.T) {
+	srv := newE2EServer(t)
+
+	out := mustRunCLI(t, "labels", "create", "--name", "Receipts", "-f", "json")
+	if !strings.Contains(out, `"Receipts"`) {
+		t.Errorf("labels create output = %s, want the new label", out)
+	}
+	out = mustRunCLI(t, "labels", "list")
+	if !strings.Contains(out, "Receipts") || !strings.Contains(out, "INBOX") {
+		t.Errorf("labels list output missing labels:\n%s", out)
+	}
+
+	mustRunCLI(t, "drafts", "create", "--to", "bob@example.com", "--subject", "Draft", "--body", "Later")
+	drafts := srv.DraftIDs()
+	if len(drafts) != 1 {
+		t.Fatalf("after drafts create, %d drafts exist, want 1", len(drafts))
+	}
+	mustRunCLI(t, "drafts", "send", drafts[0])
+	if len(srv.DraftIDs()) != 0 || len(srv.MessageIDs("SENT")) != 1 {
+		t.Errorf("drafts send left %d drafts and %d sent messages, want 0 and 1", len(srv.DraftIDs()), len(srv.MessageIDs("SENT")))
+	}
+}
+
+func TestE2ECalendarEvents(t *testing.T) {
+	newE2EServer(t)
+
+	out := mustRunCLI(t, "calendar", "create", "--summary", "Planning", "--start", "2026-03-15T09:00:00Z", "--duration", "30m", "-f", "json")
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(out), &created); err != nil || created.ID == "" {
+		t.Fatalf("calendar create output = %s, want JSON with an id (err %v)", out, err)
+	}
+
+	out = mustRunCLI(t, "calendar", "list", "--after", "2026-03-15", "--before", "2026-03-16")
+	if !strings.Contains(out, "Planning") {
+		t.Errorf("calendar list output missing event:\n%s", out)
+	}
+
+	mustRunCLI(t, "calendar", "delete", created.ID)
+	out = mustRunCLI(t, "calendar", "list", "--after", "2026-03-15", "--before", "2026-03-16")
+	if strings.Contains(out, "Planning") {
+		t.Errorf("calendar list still shows deleted event:\n%s", out)
+	}
+
+	if _, err := runCLI(t, "calendar", "get", "missing"); err == nil {
+		t.Error("calendar get of an unknown event succeeded, want an error")
+	}
+}
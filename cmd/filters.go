@@ -0,0 +1,513 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+var (
+	// filtersCreateCmd flags
+	filterFrom          string
+	filterTo            string
+	filterSubject       string
+	filterQuery         string
+	filterNegatedQuery  string
+	filterHasAttachment bool
+	filterSize          string
+	filterAddLabels     string
+	filterRemoveLabels  string
+	filterForward       string
+	filterArchive       bool
+	filterMarkRead      bool
+)
+
+// filtersCmd represents the filters parent command
+var filtersCmd = &cobra.Command{
+	Use:   "filters",
+	Short: "Manage Gmail filters",
+	Long: `Manage Gmail filters for the authenticated user.
+
+Filters apply actions such as labeling, archiving or forwarding to incoming
+messages that match their criteria. This command group provides operations
+for listing, inspecting, creating, and deleting filters.`,
+}
+
+// filtersListCmd represents the filters list command
+var filtersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all Gmail filters",
+	Long: `List all filters for the authenticated user's Gmail account.
+
+Each filter shows its ID, the criteria incoming messages are matched against,
+and the actions applied to matching messages. Label IDs are shown by name.`,
+	RunE: runFiltersList,
+}
+
+// filtersGetCmd represents the filters get command
+var filtersGetCmd = &cobra.Command{
+	Use:   "get <filter-id>",
+	Short: "Get a Gmail filter",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runFiltersGet,
+}
+
+// filtersCreateCmd represents the filters create command
+var filtersCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a Gmail filter",
+	Long: `Create a filter that applies actions to matching incoming messages.
+
+At least one criteria flag and one action flag are required.
+
+Criteria flags:
+  --from, --to, --subject: Match the sender, recipient or subject
+  --query: Match messages that have these words (Gmail search syntax)
+  --negated-query: Match messages that do not have these words
+  --has-attachment: Match messages with attachments
+  --size: Match by size, e.g. ">5M" (larger than) or "<100K" (smaller than)
+
+Action flags:
+  --add-labels, --remove-labels: Comma-separated label names or IDs
+  --forward: Forward to this (verified) forwarding address
+  --archive: Skip the inbox
+  --mark-read: Mark as read
+
+Label names are matched case-insensitively against the labels shown by
+'gsuite labels list'. System labels can be given by ID, e.g. STARRED.`,
+	Example: `  # Label and archive mail from a mailing list
+  gsuite filters create --from "list@example.com" --add-labels "Lists" --archive
+
+  # Star and mark read invoices with attachments
+  gsuite filters create --subject "invoice" --has-attachment --add-labels STARRED --mark-read
+
+  # Forward large messages to an archive address
+  gsuite filters create --size ">10M" --forward "archive@example.com"`,
+	RunE: runFiltersCreate,
+}
+
+// filtersDeleteCmd represents the filters delete command
+var filtersDeleteCmd = &cobra.Command{
+	Use:   "delete <filter-id>",
+	Short: "Delete a Gmail filter",
+	Long: `Delete a filter. Messages it already acted on are not changed.
+
+Args:
+  filter-id: The ID of the filter to delete (required)`,
+	Example: `  # Delete a filter
+  gsuite filters delete ANe1BmhJ2u9yQ`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFiltersDelete,
+}
+
+func init() {
+	rootCmd.AddCommand(filtersCmd)
+	filtersCmd.AddCommand(filtersListCmd)
+	filtersCmd.AddCommand(filtersGetCmd)
+	filtersCmd.AddCommand(filtersCreateCmd)
+	filtersCmd.AddCommand(filtersDeleteCmd)
+
+	// filtersCreateCmd criteria flags
+	filtersCreateCmd.Flags().StringVar(&filterFrom, "from", "", "Match sender")
+	filtersCreateCmd.Flags().StringVar(&filterTo, "to", "", "Match recipient")
+	filtersCreateCmd.Flags().StringVar(&filterSubject, "subject", "", "Match subject")
+	filtersCreateCmd.Flags().StringVar(&filterQuery, "query", "", "Match messages that have these words (Gmail search syntax)")
+	filtersCreateCmd.Flags().StringVar(&filterNegatedQuery, "negated-query", "", "Match messages that don't have these words")
+	filtersCreateCmd.Flags().BoolVar(&filterHasAttachment, "has-attachment", false, "Match messages with attachments")
+	filtersCreateCmd.Flags().StringVar(&filterSize, "size", "", "Match by size: >N (larger) or <N (smaller), with optional K, M or G suffix")
+
+	// filtersCreateCmd action flags
+	filtersCreateCmd.Flags().StringVar(&filterAddLabels, "add-labels", "", "Comma-separated label names or IDs to add")
+	filtersCreateCmd.Flags().StringVar(&filterRemoveLabels, "remove-labels", "", "Comma-separated label names or IDs to remove")
+	filtersCreateCmd.Flags().StringVar(&filterForward, "forward", "", "Forward to this verified forwarding address")
+	filtersCreateCmd.Flags().BoolVar(&filterArchive, "archive", false, "Skip the inbox (remove INBOX)")
+	filtersCreateCmd.Flags().BoolVar(&filterMarkRead, "mark-read", false, "Mark as read (remove UNREAD)")
+}
+
+// filterCriteriaJSON is the JSON form of a filter's criteria.
+type filterCriteriaJSON struct {
+	From           string `json:"from,omitempty"`
+	To             string `json:"to,omitempty"`
+	Subject        string `json:"subject,omitempty"`
+	Query          string `json:"query,omitempty"`
+	NegatedQuery   string `json:"negated_query,omitempty"`
+	HasAttachment  bool   `json:"has_attachment,omitempty"`
+	Size           int64  `json:"size,omitempty"`
+	SizeComparison string `json:"size_comparison,omitempty"`
+}
+
+// filterActionJSON is the JSON form of a filter's actions.
+type filterActionJSON struct {
+	AddLabelIDs    []string `json:"add_label_ids"`
+	RemoveLabelIDs []string `json:"remove_label_ids"`
+	Forward        string   `json:"forward,omitempty"`
+}
+
+// filterJSON is the JSON form of a filter.
+type filterJSON struct {
+	ID       string             `json:"id"`
+	Criteria filterCriteriaJSON `json:"criteria"`
+	Action   filterActionJSON   `json:"action"`
+}
+
+// newFilterJSON converts an API filter to its JSON form.
+func newFilterJSON(f *gmail.Filter) filterJSON {
+	result := filterJSON{
+		ID: f.Id,
+		Action: filterActionJSON{
+			AddLabelIDs:    []string{},
+			RemoveLabelIDs: []string{},
+		},
+	}
+	if c := f.Criteria; c != nil {
+		result.Criteria = filterCriteriaJSON{
+			From:           c.From,
+			To:             c.To,
+			Subject:        c.Subject,
+			Query:          c.Query,
+			NegatedQuery:   c.NegatedQuery,
+			HasAttachment:  c.HasAttachment,
+			Size:           c.Size,
+			SizeComparison: c.SizeComparison,
+		}
+	}
+	if a := f.Action; a != nil {
+		if a.AddLabelIds != nil {
+			result.Action.AddLabelIDs = a.AddLabelIds
+		}
+		if a.RemoveLabelIds != nil {
+			result.Action.RemoveLabelIDs = a.RemoveLabelIds
+		}
+		result.Action.Forward = a.Forward
+	}
+	return result
+}
+
+func runFiltersList(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	resp, err := service.Users.Settings.Filters.List("me").Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		results := []filterJSON{}
+		for _, f := range resp.Filter {
+			results = append(results, newFilterJSON(f))
+		}
+		return outputJSON(results)
+	}
+
+	if len(resp.Filter) == 0 {
+		fmt.Println("No filters found.")
+		return nil
+	}
+
+	names, err := newLabelResolver(service).NamesByID()
+	if err != nil {
+		return err
+	}
+
+	for i, f := range resp.Filter {
+		if i > 0 {
+			fmt.Println()
+		}
+		printFilter(f, names)
+	}
+	fmt.Printf("\n[Total: %d filters]\n", len(resp.Filter))
+	return nil
+}
+
+func runFiltersGet(cmd *cobra.Command, args []string) error {
+	filterID := args[0]
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	f, err := service.Users.Settings.Filters.Get("me", filterID).Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		return outputJSON(newFilterJSON(f))
+	}
+
+	names, err := newLabelResolver(service).NamesByID()
+	if err != nil {
+		return err
+	}
+	printFilter(f, names)
+	return nil
+}
+
+func runFiltersCreate(cmd *cobra.Command, args []string) error {
+	criteria := &gmail.FilterCriteria{
+		From:          filterFrom,
+		To:            filterTo,
+		Subject:       filterSubject,
+		Query:         filterQuery,
+		NegatedQuery:  filterNegatedQuery,
+		HasAttachment: filterHasAttachment,
+	}
+	if filterSize != "" {
+		size, comparison, err := parseFilterSize(filterSize)
+		if err != nil {
+			return err
+		}
+		criteria.Size = size
+		criteria.SizeComparison = comparison
+	}
+	if !hasFilterCriteria(criteria) {
+		return fmt.Errorf("at least one of --from, --to, --subject, --query, --negated-query, --has-attachment, or --size is required")
+	}
+
+	addNames := splitCommaList(filterAddLabels)
+	removeNames := splitCommaList(filterRemoveLabels)
+	if len(addNames) == 0 && len(removeNames) == 0 && filterForward == "" && !filterArchive && !filterMarkRead {
+		return fmt.Errorf("at least one of --add-labels, --remove-labels, --forward, --archive, or --mark-read is required")
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	action := &gmail.FilterAction{Forward: filterForward}
+	resolver := newLabelResolver(service)
+	if action.AddLabelIds, err = resolver.Resolve(addNames); err != nil {
+		return err
+	}
+	if action.RemoveLabelIds, err = resolver.Resolve(removeNames); err != nil {
+		return err
+	}
+	if filterArchive {
+		action.RemoveLabelIds = appendUnique(action.RemoveLabelIds, "INBOX")
+	}
+	if filterMarkRead {
+		action.RemoveLabelIds = appendUnique(action.RemoveLabelIds, "UNREAD")
+	}
+
+	created, err := service.Users.Settings.Filters.Create("me", &gmail.Filter{
+		Criteria: criteria,
+		Action:   action,
+	}).Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		return outputJSON(newFilterJSON(created))
+	}
+
+	fmt.Printf("Filter created: %s\n", created.Id)
+	return nil
+}
+
+func runFiltersDelete(cmd *cobra.Command, args []string) error {
+	filterID := args[0]
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	if err := service.Users.Settings.Filters.Delete("me", filterID).Do(); err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		type filterDeleteResult struct {
+			ID      string `json:"id"`
+			Deleted bool   `json:"deleted"`
+		}
+		return outputJSON(filterDeleteResult{
+			ID:      filterID,
+			Deleted: true,
+		})
+	}
+
+	fmt.Printf("Filter deleted: %s\n", filterID)
+	return nil
+}
+
+// printFilter prints a filter's ID, criteria and actions in text mode.
+func printFilter(f *gmail.Filter, labelNames map[string]string) {
+	fmt.Printf("ID: %s\n", f.Id)
+	fmt.Printf("  Criteria: %s\n", describeFilterCriteria(f.Criteria))
+	fmt.Printf("  Actions:  %s\n", describeFilterAction(f.Action, labelNames))
+}
+
+// describeFilterCriteria renders criteria in Gmail search syntax.
+func describeFilterCriteria(c *gmail.FilterCriteria) string {
+	if c == nil {
+		return "(none)"
+	}
+	var parts []string
+	if c.From != "" {
+		parts = append(parts, "from:("+c.From+")")
+	}
+	if c.To != "" {
+		parts = append(parts, "to:("+c.To+")")
+	}
+	if c.Subject != "" {
+		parts = append(parts, "subject:("+c.Subject+")")
+	}
+	if c.Query != "" {
+		parts = append(parts, c.Query)
+	}
+	if c.NegatedQuery != "" {
+		parts = append(parts, "-{"+c.NegatedQuery+"}")
+	}
+	if c.HasAttachment {
+		parts = append(parts, "has:attachment")
+	}
+	if c.Size > 0 {
+		op := "larger:"
+		if c.SizeComparison == "smaller" {
+			op = "smaller:"
+		}
+		parts = append(parts, op+formatFilterSize(c.Size))
+	}
+	if len(parts) == 0 {
+		return "(none)"
+	}
+	return strings.Join(parts, " ")
+}
+
+// describeFilterAction renders actions as a comma-separated list, showing
+// archive and mark-read for their label removals and label names for IDs.
+func describeFilterAction(a *gmail.FilterAction, labelNames map[string]string) string {
+	if a == nil {
+		return "(none)"
+	}
+	name := func(id string) string {
+		if n, ok := labelNames[id]; ok {
+			return n
+		}
+		return id
+	}
+
+	var parts []string
+	for _, id := range a.AddLabelIds {
+		parts = append(parts, "add label "+name(id))
+	}
+	for _, id := range a.RemoveLabelIds {
+		switch id {
+		case "INBOX":
+			parts = append(parts, "archive")
+		case "UNREAD":
+			parts = append(parts, "mark read")
+		default:
+			parts = append(parts, "remove label "+name(id))
+		}
+	}
+	if a.Forward != "" {
+		parts = append(parts, "forward to "+a.Forward)
+	}
+	if len(parts) == 0 {
+		return "(none)"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// hasFilterCriteria reports whether any criteria field is set.
+func hasFilterCriteria(c *gmail.FilterCriteria) bool {
+	return c.From != "" || c.To != "" || c.Subject != "" || c.Query != "" ||
+		c.NegatedQuery != "" || c.HasAttachment || c.Size > 0
+}
+
+// parseFilterSize parses a size criterion such as ">5M", "<100K" or "2048"
+// into a byte count and size comparison. Without a "<" prefix the
+// comparison is "larger".
+func parseFilterSize(input string) (int64, string, error) {
+	s := strings.TrimSpace(input)
+	comparison := "larger"
+	switch {
+	case strings.HasPrefix(s, ">"):
+		s = s[1:]
+	case strings.HasPrefix(s, "<"):
+		comparison = "smaller"
+		s = s[1:]
+	}
+
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		factor int64
+	}{
+		{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+		{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	} {
+		if strings.HasSuffix(s, unit.suffix) {
+			multiplier = unit.factor
+			s = strings.TrimSuffix(s, unit.suffix)
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, "", fmt.Errorf("invalid --size %q: use a positive number with optional > or < prefix and K, M or G suffix", input)
+	}
+	return n * multiplier, comparison, nil
+}
+
+// formatFilterSize renders a byte count with the largest exact unit.
+func formatFilterSize(size int64) string {
+	switch {
+	case size%(1<<30) == 0:
+		return fmt.Sprintf("%dG", size>>30)
+	case size%(1<<20) == 0:
+		return fmt.Sprintf("%dM", size>>20)
+	case size%(1<<10) == 0:
+		return fmt.Sprintf("%dK", size>>10)
+	default:
+		return strconv.FormatInt(size, 10)
+	}
+}
+
+// splitCommaList splits a comma-separated flag value, dropping empty items.
+func splitCommaList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// appendUnique appends value to list unless it is already present.
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestParseFilterSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		input          string
+		wantSize       int64
+		wantComparison string
+		wantErr        bool
+	}{
+		{name: "should default to larger for bare bytes", input: "2048", wantSize: 2048, wantComparison: "larger"},
+		{name: "should parse larger with megabytes", input: ">5M", wantSize: 5 << 20, wantComparison: "larger"},
+		{name: "should parse smaller with kilobytes", input: "<100K", wantSize: 100 << 10, wantComparison: "smaller"},
+		{name: "should accept two-letter units in any case", input: "> 1gb", wantSize: 1 << 30, wantComparison: "larger"},
+		{name: "should reject zero", input: "0", wantErr: true},
+		{name: "should reject unknown units", input: "5T", wantErr: true},
+		{name: "should reject empty input", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			size, comparison, err := parseFilterSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseFilterSize(%q) expected error, got %d %s", tt.input, size, comparison)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFilterSize(%q) unexpected error: %v", tt.input, err)
+			}
+			if size != tt.wantSize || comparison != tt.wantComparison {
+				t.Errorf("parseFilterSize(%q) = %d %s, want %d %s", tt.input, size, comparison, tt.wantSize, tt.wantComparison)
+			}
+		})
+	}
+}
+
+func TestDescribeFilter(t *testing.T) {
+	t.Parallel()
+
+	names := map[string]string{"Label_1": "Lists"}
+	tests := []struct {
+		name         string
+		criteria     *gmail.FilterCriteria
+		action       *gmail.FilterAction
+		wantCriteria string
+		wantAction   string
+	}{
+		{
+			name:         "should render sender, label and archive",
+			criteria:     &gmail.FilterCriteria{From: "list@example.com"},
+			action:       &gmail.FilterAction{AddLabelIds: []string{"Label_1"}, RemoveLabelIds: []string{"INBOX"}},
+			wantCriteria: "from:(list@example.com)",
+			wantAction:   "add label Lists, archive",
+		},
+		{
+			name:         "should render size, attachment and negated query",
+			criteria:     &gmail.FilterCriteria{HasAttachment: true, NegatedQuery: "draft", Size: 10 << 20, SizeComparison: "smaller"},
+			action:       &gmail.FilterAction{RemoveLabelIds: []string{"UNREAD", "Label_9"}, Forward: "a@example.com"},
+			wantCriteria: "-{draft} has:attachment smaller:10M",
+			wantAction:   "mark read, remove label Label_9, forward to a@example.com",
+		},
+		{
+			name:         "should render missing parts as none",
+			wantCriteria: "(none)",
+			wantAction:   "(none)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := describeFilterCriteria(tt.criteria); got != tt.wantCriteria {
+				t.Errorf("describeFilterCriteria() = %q, want %q", got, tt.wantCriteria)
+			}
+			if got := describeFilterAction(tt.action, names); got != tt.wantAction {
+				t.Errorf("describeFilterAction() = %q, want %q", got, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestResolveLabelIDs(t *testing.T) {
+	t.Parallel()
+
+	labels := []*gmail.Label{
+		{Id: "INBOX", Name: "INBOX", Type: "system"},
+		{Id: "Label_1", Name: "Team/Support", Type: "user"},
+	}
+	tests := []struct {
+		name    string
+		input   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "should resolve names case-insensitively", input: []string{"team/support"}, want: []string{"Label_1"}},
+		{name: "should ignore space around path separators", input: []string{"Team / Support"}, want: []string{"Label_1"}},
+		{name: "should pass through system label IDs not in the list", input: []string{"UNREAD"}, want: []string{"UNREAD"}},
+		{name: "should accept label IDs", input: []string{"INBOX", "Label_1"}, want: []string{"INBOX", "Label_1"}},
+		{name: "should skip blank entries", input: []string{" ", ""}, want: nil},
+		{name: "should reject unknown labels", input: []string{"Missing"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := resolveLabelIDs(labels, tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("resolveLabelIDs(%v) expected error, got %v", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveLabelIDs(%v) unexpected error: %v", tt.input, err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("resolveLabelIDs(%v) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestE2EFilters(t *testing.T) {
+	srv := newE2EServer(t)
+	labelID := srv.AddLabel("Team Support")
+
+	out := mustRunCLI(t, "filters", "create", "--from", "help@example.com", "--size", ">1M",
+		"--add-labels", "team support,STARRED", "--archive", "--mark-read", "-f", "json")
+	var created filterJSON
+	if err := json.Unmarshal([]byte(out), &created); err != nil {
+		t.Fatalf("filters create output is not JSON: %v\n%s", err, out)
+	}
+	if strings.Join(created.Action.AddLabelIDs, ",") != labelID+",STARRED" {
+		t.Errorf("created add_label_ids = %v, want [%s STARRED]", created.Action.AddLabelIDs, labelID)
+	}
+	if strings.Join(created.Action.RemoveLabelIDs, ",") != "INBOX,UNREAD" {
+		t.Errorf("created remove_label_ids = %v, want [INBOX UNREAD]", created.Action.RemoveLabelIDs)
+	}
+	if created.Criteria.Size != 1<<20 || created.Criteria.SizeComparison != "larger" {
+		t.Errorf("created size = %d %s, want 1048576 larger", created.Criteria.Size, created.Criteria.SizeComparison)
+	}
+
+	out = mustRunCLI(t, "filters", "list")
+	if !strings.Contains(out, "add label Team Support, add label STARRED, archive, mark read") {
+		t.Errorf("filters list output missing actions:\n%s", out)
+	}
+
+	if _, err := runCLI(t, "filters", "create", "--from", "x@example.com", "--add-labels", "Nope"); err == nil || !strings.Contains(err.Error(), "label not found: Nope") {
+		t.Errorf("filters create with unknown label error = %v, want label not found", err)
+	}
+	if _, err := runCLI(t, "filters", "create", "--archive"); err == nil {
+		t.Error("filters create without criteria succeeded, want an error")
+	}
+
+	mustRunCLI(t, "filters", "delete", created.ID)
+	if len(srv.Filters()) != 0 {
+		t.Errorf("after filters delete, %d filters remain", len(srv.Filters()))
+	}
+}
@@ -0,0 +1,657 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+var (
+	// filtersImportCmd flags
+	filtersImportYes   bool
+	filtersImportPrune bool
+)
+
+// appsNamespace is the namespace of the apps:property elements in mailFilters.xml.
+const appsNamespace = "http://schemas.google.com/apps/2006"
+
+// filtersExportCmd represents the filters export command
+var filtersExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export filters as mailFilters.xml",
+	Long: `Write all filters to stdout in the Atom mailFilters.xml format used by
+Gmail's web export (Settings > Filters > Export).
+
+Labels are written by name. Actions that apply system labels are written as
+the matching properties: shouldArchive, shouldMarkAsRead, shouldStar,
+shouldTrash, shouldNeverSpam, shouldAlwaysMarkAsImportant,
+shouldNeverMarkAsImportant and smartLabelToApply. Removing a user label has no
+mailFilters.xml equivalent; such actions are skipped with a warning.`,
+	Example: `  # Save filters to a file
+  gsuite filters export > filters.xml`,
+	RunE: runFiltersExport,
+}
+
+// filtersImportCmd represents the filters import command
+var filtersImportCmd = &cobra.Command{
+	Use:   "import <file.xml>",
+	Short: "Import filters from mailFilters.xml",
+	Long: `Create filters from a mailFilters.xml file, as written by 'filters export'
+or Gmail's web export.
+
+The file is compared with the existing filters and the difference is shown:
+  + filters in the file that will be created
+  - existing filters not in the file that will be deleted (with --prune)
+
+Nothing is changed unless --yes is given. Filters that already exist are left
+alone, and labels the file refers to that don't exist yet are created.`,
+	Example: `  # Preview the changes
+  gsuite filters import filters.xml
+
+  # Apply them
+  gsuite filters import filters.xml --yes
+
+  # Make the mailbox match the file exactly
+  gsuite filters import filters.xml --prune --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFiltersImport,
+}
+
+func init() {
+	filtersCmd.AddCommand(filtersExportCmd)
+	filtersCmd.AddCommand(filtersImportCmd)
+
+	filtersImportCmd.Flags().BoolVar(&filtersImportYes, "yes", false, "Apply the changes instead of only showing them")
+	filtersImportCmd.Flags().BoolVar(&filtersImportPrune, "prune", false, "Also delete existing filters that are not in the file")
+}
+
+// mailFiltersFeed is the Atom feed of a mailFilters.xml file.
+type mailFiltersFeed struct {
+	XMLName xml.Name         `xml:"http://www.w3.org/2005/Atom feed"`
+	Entries []mailFilterItem `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+// mailFilterItem is one filter entry of a mailFilters.xml file.
+type mailFilterItem struct {
+	Properties []mailFilterProperty `xml:"http://schemas.google.com/apps/2006 property"`
+}
+
+// mailFilterProperty is an apps:property element.
+type mailFilterProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// portableFilter is a filter with labels referenced by name rather than ID,
+// which is how mailFilters.xml stores them and how imports are compared.
+type portableFilter struct {
+	Criteria     gmail.FilterCriteria
+	AddLabels    []string
+	RemoveLabels []string
+	Forward      string
+}
+
+// Mappings between system label actions and mailFilters.xml properties.
+var (
+	addLabelProperties = map[string]string{
+		"STARRED":   "shouldStar",
+		"TRASH":     "shouldTrash",
+		"IMPORTANT": "shouldAlwaysMarkAsImportant",
+	}
+	removeLabelProperties = map[string]string{
+		"INBOX":     "shouldArchive",
+		"UNREAD":    "shouldMarkAsRead",
+		"SPAM":      "shouldNeverSpam",
+		"IMPORTANT": "shouldNeverMarkAsImportant",
+	}
+	smartLabels = map[string]string{
+		"CATEGORY_PERSONAL":   "^smartlabel_personal",
+		"CATEGORY_SOCIAL":     "^smartlabel_social",
+		"CATEGORY_PROMOTIONS": "^smartlabel_promo",
+		"CATEGORY_UPDATES":    "^smartlabel_notification",
+		"CATEGORY_FORUMS":     "^smartlabel_group",
+	}
+)
+
+// sizeUnits are the mailFilters.xml size units, largest first.
+var sizeUnits = []struct {
+	name   string
+	factor int64
+}{
+	{"s_smb", 1 << 20},
+	{"s_skb", 1 << 10},
+	{"s_sb", 1},
+}
+
+// newPortableFilter converts an API filter, replacing user label IDs with
+// names. System label IDs are kept as they are.
+func newPortableFilter(f *gmail.Filter, labelNames map[string]string) portableFilter {
+	p := portableFilter{}
+	if f.Criteria != nil {
+		p.Criteria = *f.Criteria
+		p.Criteria.ForceSendFields = nil
+		p.Criteria.NullFields = nil
+	}
+	name := func(id string) string {
+		if systemLabelIDs[id] {
+			return id
+		}
+		if n, ok := labelNames[id]; ok {
+			return n
+		}
+		return id
+	}
+	if f.Action != nil {
+		for _, id := range f.Action.AddLabelIds {
+			p.AddLabels = append(p.AddLabels, name(id))
+		}
+		for _, id := range f.Action.RemoveLabelIds {
+			p.RemoveLabels = append(p.RemoveLabels, name(id))
+		}
+		p.Forward = f.Action.Forward
+	}
+	return p
+}
+
+// key returns a string identifying the filter's criteria and actions,
+// independent of the order labels are listed in.
+func (p portableFilter) key() string {
+	add := append([]string(nil), p.AddLabels...)
+	remove := append([]string(nil), p.RemoveLabels...)
+	for i := range add {
+		add[i] = strings.ToLower(add[i])
+	}
+	for i := range remove {
+		remove[i] = strings.ToLower(remove[i])
+	}
+	sort.Strings(add)
+	sort.Strings(remove)
+	c := p.Criteria
+	return strings.Join([]string{
+		c.From, c.To, c.Subject, c.Query, c.NegatedQuery,
+		strconv.FormatBool(c.HasAttachment), strconv.FormatBool(c.ExcludeChats),
+		strconv.FormatInt(c.Size, 10), c.SizeComparison,
+		strings.Join(add, ","), strings.Join(remove, ","), strings.ToLower(p.Forward),
+	}, "\x00")
+}
+
+// describe renders the filter as "criteria => actions" for diffs.
+func (p portableFilter) describe() string {
+	criteria := p.Criteria
+	action := &gmail.FilterAction{AddLabelIds: p.AddLabels, RemoveLabelIds: p.RemoveLabels, Forward: p.Forward}
+	return describeFilterCriteria(&criteria) + " => " + describeFilterAction(action, nil)
+}
+
+// mailFilterProperties converts a filter to mailFilters.xml properties. It
+// returns a warning for each action the format cannot express.
+func (p portableFilter) mailFilterProperties() ([]mailFilterProperty, []string) {
+	var props []mailFilterProperty
+	var warnings []string
+	add := func(name, value string) {
+		props = append(props, mailFilterProperty{Name: name, Value: value})
+	}
+
+	c := p.Criteria
+	for _, field := range []struct{ name, value string }{
+		{"from", c.From},
+		{"to", c.To},
+		{"subject", c.Subject},
+		{"hasTheWord", c.Query},
+		{"doesNotHaveTheWord", c.NegatedQuery},
+	} {
+		if field.value != "" {
+			add(field.name, field.value)
+		}
+	}
+	if c.HasAttachment {
+		add("hasAttachment", "true")
+	}
+	if c.ExcludeChats {
+		add("excludeChats", "true")
+	}
+	if c.Size > 0 {
+		for _, unit := range sizeUnits {
+			if c.Size%unit.factor == 0 {
+				add("size", strconv.FormatInt(c.Size/unit.factor, 10))
+				operator := "s_sl"
+				if c.SizeComparison == "smaller" {
+					operator = "s_ss"
+				}
+				add("sizeOperator", operator)
+				add("sizeUnit", unit.name)
+				break
+			}
+		}
+	}
+
+	for _, label := range p.AddLabels {
+		switch {
+		case addLabelProperties[label] != "":
+			add(addLabelProperties[label], "true")
+		case smartLabels[label] != "":
+			add("smartLabelToApply", smartLabels[label])
+		default:
+			add("label", label)
+		}
+	}
+	for _, label := range p.RemoveLabels {
+		if removeLabelProperties[label] != "" {
+			add(removeLabelProperties[label], "true")
+		} else {
+			warnings = append(warnings, fmt.Sprintf("removing label %q cannot be expressed in mailFilters.xml; skipped", label))
+		}
+	}
+	if p.Forward != "" {
+		add("forwardTo", p.Forward)
+	}
+	return props, warnings
+}
+
+// portableFilterFromProperties converts mailFilters.xml properties to a filter.
+func portableFilterFromProperties(props []mailFilterProperty) (portableFilter, error) {
+	p := portableFilter{}
+	var size int64
+	sizeOperator, sizeUnit := "s_sl", "s_sb"
+
+	for _, prop := range props {
+		value := prop.Value
+		switch prop.Name {
+		case "from":
+			p.Criteria.From = value
+		case "to":
+			p.Criteria.To = value
+		case "subject":
+			p.Criteria.Subject = value
+		case "hasTheWord":
+			p.Criteria.Query = value
+		case "doesNotHaveTheWord":
+			p.Criteria.NegatedQuery = value
+		case "hasAttachment":
+			p.Criteria.HasAttachment = value == "true"
+		case "excludeChats":
+			p.Criteria.ExcludeChats = value == "true"
+		case "size":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 0 {
+				return p, fmt.Errorf("invalid size %q", value)
+			}
+			size = n
+		case "sizeOperator":
+			sizeOperator = value
+		case "sizeUnit":
+			sizeUnit = value
+		case "label":
+			p.AddLabels = append(p.AddLabels, value)
+		case "smartLabelToApply":
+			found := false
+			for id, smart := range smartLabels {
+				if smart == value {
+					p.AddLabels = append(p.AddLabels, id)
+					found = true
+				}
+			}
+			if !found {
+				return p, fmt.Errorf("unknown smartLabelToApply %q", value)
+			}
+		case "forwardTo":
+			p.Forward = value
+		default:
+			if value != "true" {
+				continue
+			}
+			if id := labelForProperty(addLabelProperties, prop.Name); id != "" {
+				p.AddLabels = append(p.AddLabels, id)
+			} else if id := labelForProperty(removeLabelProperties, prop.Name); id != "" {
+				p.RemoveLabels = append(p.RemoveLabels, id)
+			}
+		}
+	}
+
+	if size > 0 {
+		factor := int64(0)
+		for _, unit := range sizeUnits {
+			if unit.name == sizeUnit {
+				factor = unit.factor
+			}
+		}
+		if factor == 0 {
+			return p, fmt.Errorf("unknown sizeUnit %q", sizeUnit)
+		}
+		p.Criteria.Size = size * factor
+		p.Criteria.SizeComparison = "larger"
+		if sizeOperator == "s_ss" {
+			p.Criteria.SizeComparison = "smaller"
+		}
+	}
+	return p, nil
+}
+
+// labelForProperty returns the label ID whose property is name, or "".
+func labelForProperty(properties map[string]string, name string) string {
+	for id, prop := range properties {
+		if prop == name {
+			return id
+		}
+	}
+	return ""
+}
+
+// writeMailFilters renders filters as a mailFilters.xml document.
+func writeMailFilters(filters []portableFilter, email string, now time.Time) ([]byte, []string) {
+	var buf bytes.Buffer
+	var warnings []string
+	updated := now.UTC().Format(time.RFC3339)
+	escape := func(s string) string {
+		var b bytes.Buffer
+		xml.EscapeText(&b, []byte(s)) //nolint:errcheck
+		return b.String()
+	}
+
+	buf.WriteString("<?xml version='1.0' encoding='UTF-8'?>")
+	buf.WriteString("<feed xmlns='http://www.w3.org/2005/Atom' xmlns:apps='" + appsNamespace + "'>\n")
+	buf.WriteString("\t<title>Mail Filters</title>\n")
+	fmt.Fprintf(&buf, "\t<id>tag:mail.google.com,2008:filters:%d</id>\n", len(filters))
+	fmt.Fprintf(&buf, "\t<updated>%s</updated>\n", updated)
+	fmt.Fprintf(&buf, "\t<author>\n\t\t<email>%s</email>\n\t</author>\n", escape(email))
+	for i, f := range filters {
+		props, filterWarnings := f.mailFilterProperties()
+		for _, w := range filterWarnings {
+			warnings = append(warnings, fmt.Sprintf("filter %d: %s", i+1, w))
+		}
+		buf.WriteString("\t<entry>\n")
+		buf.WriteString("\t\t<category term='filter'></category>\n")
+		buf.WriteString("\t\t<title>Mail Filter</title>\n")
+		fmt.Fprintf(&buf, "\t\t<id>tag:mail.google.com,2008:filter:%d</id>\n", i+1)
+		fmt.Fprintf(&buf, "\t\t<updated>%s</updated>\n", updated)
+		buf.WriteString("\t\t<content></content>\n")
+		for _, prop := range props {
+			fmt.Fprintf(&buf, "\t\t<apps:property name='%s' value='%s'/>\n", escape(prop.Name), escape(prop.Value))
+		}
+		buf.WriteString("\t</entry>\n")
+	}
+	buf.WriteString("</feed>\n")
+	return buf.Bytes(), warnings
+}
+
+// parseMailFilters reads the filters from a mailFilters.xml document.
+func parseMailFilters(data []byte) ([]portableFilter, error) {
+	var feed mailFiltersFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("invalid mailFilters.xml: %w", err)
+	}
+	var filters []portableFilter
+	for i, entry := range feed.Entries {
+		f, err := portableFilterFromProperties(entry.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mailFilters.xml entry %d: %w", i+1, err)
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// existingFilter is an existing filter in portable form, with its ID.
+type existingFilter struct {
+	ID string
+	portableFilter
+}
+
+// filterImportPlan is the difference between a mailFilters.xml file and the
+// existing filters.
+type filterImportPlan struct {
+	Create    []portableFilter
+	Delete    []existingFilter
+	Unchanged int
+	// Existing filters not in the file that are kept because --prune is off.
+	Kept int
+}
+
+// planFilterImport compares wanted with the existing filters. Duplicate
+// entries in wanted are created once.
+func planFilterImport(wanted []portableFilter, existing []*gmail.Filter, labelNames map[string]string, prune bool) filterImportPlan {
+	var plan filterImportPlan
+	existingKeys := make(map[string]bool)
+	for _, f := range existing {
+		existingKeys[newPortableFilter(f, labelNames).key()] = true
+	}
+	wantedKeys := make(map[string]bool)
+	for _, f := range wanted {
+		key := f.key()
+		if wantedKeys[key] {
+			continue
+		}
+		wantedKeys[key] = true
+		if existingKeys[key] {
+			plan.Unchanged++
+		} else {
+			plan.Create = append(plan.Create, f)
+		}
+	}
+	for _, f := range existing {
+		p := newPortableFilter(f, labelNames)
+		if wantedKeys[p.key()] {
+			continue
+		}
+		if prune {
+			plan.Delete = append(plan.Delete, existingFilter{ID: f.Id, portableFilter: p})
+		} else {
+			plan.Kept++
+		}
+	}
+	return plan
+}
+
+// missingLabels returns the user label names plan would add that don't exist yet.
+func (plan filterImportPlan) missingLabels(labels []*gmail.Label) []string {
+	var missing []string
+	for _, f := range plan.Create {
+		for _, name := range append(append([]string(nil), f.AddLabels...), f.RemoveLabels...) {
+			if systemLabelIDs[name] {
+				continue
+			}
+			if _, err := resolveLabelIDs(labels, []string{name}); err == nil {
+				continue
+			}
+			if !containsFold(missing, name) {
+				missing = append(missing, name)
+			}
+		}
+	}
+	return missing
+}
+
+// containsFold reports whether list contains s, case-insensitively.
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func runFiltersExport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	resp, err := service.Users.Settings.Filters.List("me").Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+	labels, err := newLabelResolver(service).Labels()
+	if err != nil {
+		return err
+	}
+	profile, err := service.Users.GetProfile("me").Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	names := labelNamesByID(labels)
+	var filters []portableFilter
+	for _, f := range resp.Filter {
+		filters = append(filters, newPortableFilter(f, names))
+	}
+
+	data, warnings := writeMailFilters(filters, profile.EmailAddress, time.Now())
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+func runFiltersImport(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read filters file: %w", err)
+	}
+	wanted, err := parseMailFilters(data)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	resp, err := service.Users.Settings.Filters.List("me").Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+	labels, err := newLabelResolver(service).Labels()
+	if err != nil {
+		return err
+	}
+
+	plan := planFilterImport(wanted, resp.Filter, labelNamesByID(labels), filtersImportPrune)
+	missing := plan.missingLabels(labels)
+
+	if !filtersImportYes {
+		return printFilterImportPlan(plan, missing, false)
+	}
+
+	// Create missing labels first so the new filters can refer to them
+	for _, name := range missing {
+		created, err := service.Users.Labels.Create("me", &gmail.Label{Name: name}).Do()
+		if err != nil {
+			return fmt.Errorf("failed to create label %s: %w", name, err)
+		}
+		labels = append(labels, created)
+	}
+	if len(missing) > 0 {
+		invalidateLabelCache(service)
+	}
+
+	for _, f := range plan.Create {
+		addIDs, err := resolveLabelIDs(labels, f.AddLabels)
+		if err != nil {
+			return err
+		}
+		removeIDs, err := resolveLabelIDs(labels, f.RemoveLabels)
+		if err != nil {
+			return err
+		}
+		criteria := f.Criteria
+		_, err = service.Users.Settings.Filters.Create("me", &gmail.Filter{
+			Criteria: &criteria,
+			Action: &gmail.FilterAction{
+				AddLabelIds:    addIDs,
+				RemoveLabelIds: removeIDs,
+				Forward:        f.Forward,
+			},
+		}).Do()
+		if err != nil {
+			return fmt.Errorf("failed to create filter %s: %w", f.describe(), auth.HandleGmailError(err))
+		}
+	}
+
+	for _, f := range plan.Delete {
+		if err := service.Users.Settings.Filters.Delete("me", f.ID).Do(); err != nil {
+			return fmt.Errorf("failed to delete filter %s: %w", f.ID, auth.HandleGmailError(err))
+		}
+	}
+
+	return printFilterImportPlan(plan, missing, true)
+}
+
+// printFilterImportPlan reports an import plan, before or after applying it.
+func printFilterImportPlan(plan filterImportPlan, missingLabels []string, applied bool) error {
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		type filterImportResult struct {
+			Applied        bool     `json:"applied"`
+			Create         []string `json:"create"`
+			Delete         []string `json:"delete"`
+			Unchanged      int      `json:"unchanged"`
+			Kept           int      `json:"kept"`
+			LabelsCreated  []string `json:"labels_created"`
+			LabelsToCreate []string `json:"labels_to_create"`
+		}
+		result := filterImportResult{
+			Applied:        applied,
+			Create:         []string{},
+			Delete:         []string{},
+			Unchanged:      plan.Unchanged,
+			Kept:           plan.Kept,
+			LabelsCreated:  []string{},
+			LabelsToCreate: []string{},
+		}
+		for _, f := range plan.Create {
+			result.Create = append(result.Create, f.describe())
+		}
+		for _, f := range plan.Delete {
+			result.Delete = append(result.Delete, f.ID)
+		}
+		// Missing labels are only created when the plan is applied.
+		switch {
+		case missingLabels == nil:
+		case applied:
+			result.LabelsCreated = missingLabels
+		default:
+			result.LabelsToCreate = missingLabels
+		}
+		return outputJSON(result)
+	}
+
+	for _, f := range plan.Create {
+		fmt.Printf("+ %s\n", f.describe())
+	}
+	for _, f := range plan.Delete {
+		fmt.Printf("- %s  (%s)\n", f.describe(), f.ID)
+	}
+	for _, name := range missingLabels {
+		fmt.Printf("+ label %s\n", name)
+	}
+	if len(plan.Create) == 0 && len(plan.Delete) == 0 {
+		fmt.Println("Filters are up to date.")
+	}
+
+	fmt.Printf("\n[%d to create, %d to delete, %d unchanged", len(plan.Create), len(plan.Delete), plan.Unchanged)
+	if plan.Kept > 0 {
+		fmt.Printf(", %d not in file kept (use --prune to delete)", plan.Kept)
+	}
+	fmt.Println("]")
+
+	if !applied && (len(plan.Create) > 0 || len(plan.Delete) > 0) {
+		fmt.Println("Dry run: no changes made. Re-run with --yes to apply.")
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestParseMailFiltersGmailExport(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "mailFilters.xml"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	filters, err := parseMailFilters(data)
+	if err != nil {
+		t.Fatalf("parseMailFilters() unexpected error: %v", err)
+	}
+	if len(filters) != 2 {
+		t.Fatalf("parseMailFilters() = %d filters, want 2", len(filters))
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "should map criteria and label actions", got: filters[0].describe(), want: "from:(alerts@example.com) => add label Alerts/Pager, archive, mark read"},
+		{name: "should map size, system actions and forwarding", got: filters[1].describe(), want: "invoice OR receipt has:attachment smaller:5M => add label STARRED, add label CATEGORY_UPDATES, remove label SPAM, forward to billing@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if tt.got != tt.want {
+				t.Errorf("describe() = %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMailFiltersRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		filter portableFilter
+	}{
+		{
+			name: "should round-trip user labels and archive",
+			filter: portableFilter{
+				Criteria:     gmail.FilterCriteria{From: "a@example.com", Subject: "weekly <report> & notes"},
+				AddLabels:    []string{"Reports/Weekly"},
+				RemoveLabels: []string{"INBOX"},
+			},
+		},
+		{
+			name: "should round-trip byte sizes and every system action",
+			filter: portableFilter{
+				Criteria:     gmail.FilterCriteria{NegatedQuery: "unsubscribe", Size: 1500, SizeComparison: "larger", ExcludeChats: true},
+				AddLabels:    []string{"STARRED", "TRASH", "IMPORTANT", "CATEGORY_SOCIAL"},
+				RemoveLabels: []string{"UNREAD", "SPAM"},
+				Forward:      "me@example.org",
+			},
+		},
+		{
+			name: "should round-trip kilobyte sizes",
+			filter: portableFilter{
+				Criteria:     gmail.FilterCriteria{To: "team@example.com", Size: 200 << 10, SizeComparison: "smaller"},
+				RemoveLabels: []string{"IMPORTANT"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			data, warnings := writeMailFilters([]portableFilter{tt.filter}, "me@example.com", time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC))
+			if len(warnings) > 0 {
+				t.Errorf("writeMailFilters() warnings = %v, want none", warnings)
+			}
+			parsed, err := parseMailFilters(data)
+			if err != nil {
+				t.Fatalf("parseMailFilters() unexpected error: %v\n%s", err, data)
+			}
+			if len(parsed) != 1 || parsed[0].key() != tt.filter.key() {
+				t.Errorf("round trip = %+v, want %+v\n%s", parsed, tt.filter, data)
+			}
+		})
+	}
+}
+
+func TestWriteMailFiltersWarnsOnUserLabelRemoval(t *testing.T) {
+	t.Parallel()
+
+	_, warnings := writeMailFilters([]portableFilter{{
+		Criteria:     gmail.FilterCriteria{From: "a@example.com"},
+		RemoveLabels: []string{"Projects"},
+	}}, "me@example.com", time.Now())
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"Projects"`) {
+		t.Errorf("writeMailFilters() warnings = %v, want one about Projects", warnings)
+	}
+}
+
+func TestPlanFilterImport(t *testing.T) {
+	t.Parallel()
+
+	names := map[string]string{"Label_1": "Lists"}
+	existing := []*gmail.Filter{
+		{Id: "f1", Criteria: &gmail.FilterCriteria{From: "list@example.com"}, Action: &gmail.FilterAction{AddLabelIds: []string{"Label_1"}, RemoveLabelIds: []string{"INBOX"}}},
+		{Id: "f2", Criteria: &gmail.FilterCriteria{From: "old@example.com"}, Action: &gmail.FilterAction{RemoveLabelIds: []string{"UNREAD"}}},
+	}
+	wanted := []portableFilter{
+		{Criteria: gmail.FilterCriteria{From: "list@example.com"}, AddLabels: []string{"lists"}, RemoveLabels: []string{"INBOX"}},
+		{Criteria: gmail.FilterCriteria{From: "new@example.com"}, AddLabels: []string{"STARRED"}},
+		{Criteria: gmail.FilterCriteria{From: "new@example.com"}, AddLabels: []string{"STARRED"}},
+	}
+
+	tests := []struct {
+		name          string
+		prune         bool
+		wantCreate    int
+		wantDelete    []string
+		wantUnchanged int
+		wantKept      int
+	}{
+		{name: "should keep filters missing from the file by default", wantCreate: 1, wantUnchanged: 1, wantKept: 1},
+		{name: "should delete filters missing from the file with prune", prune: true, wantCreate: 1, wantDelete: []string{"f2"}, wantUnchanged: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			plan := planFilterImport(wanted, existing, names, tt.prune)
+			var deleted []string
+			for _, f := range plan.Delete {
+				deleted = append(deleted, f.ID)
+			}
+			if len(plan.Create) != tt.wantCreate || strings.Join(deleted, ",") != strings.Join(tt.wantDelete, ",") ||
+				plan.Unchanged != tt.wantUnchanged || plan.Kept != tt.wantKept {
+				t.Errorf("plan = %d create, delete %v, %d unchanged, %d kept; want %d, %v, %d, %d",
+					len(plan.Create), deleted, plan.Unchanged, plan.Kept,
+					tt.wantCreate, tt.wantDelete, tt.wantUnchanged, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestE2EFiltersExportImport(t *testing.T) {
+	srv := newE2EServer(t)
+	srv.AddLabel("Lists")
+	mustRunCLI(t, "filters", "create", "--from", "list@example.com", "--add-labels", "Lists", "--archive")
+	mustRunCLI(t, "filters", "create", "--subject", "urgent", "--add-labels", "STARRED")
+
+	exported := mustRunCLI(t, "filters", "export")
+	if !strings.Contains(exported, "<apps:property name='label' value='Lists'/>") {
+		t.Fatalf("filters export missing label property:\n%s", exported)
+	}
+	path := filepath.Join(t.TempDir(), "filters.xml")
+	if err := os.WriteFile(path, []byte(exported), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// An unchanged mailbox needs no changes
+	out := mustRunCLI(t, "filters", "import", path)
+	if !strings.Contains(out, "Filters are up to date.") {
+		t.Errorf("filters import of an unchanged export:\n%s", out)
+	}
+
+	// Add a filter using a label that doesn't exist yet
+	withNew := strings.Replace(exported, "</feed>", `<entry><category term='filter'></category><title>Mail Filter</title>
+<apps:property name='from' value='ci@example.com'/><apps:property name='label' value='Builds'/></entry></feed>`, 1)
+	if err := os.WriteFile(path, []byte(withNew), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out = mustRunCLI(t, "filters", "import", path)
+	if !strings.Contains(out, "+ from:(ci@example.com) => add label Builds") || !strings.Contains(out, "+ label Builds") || !strings.Contains(out, "Dry run") {
+		t.Errorf("filters import dry run output:\n%s", out)
+	}
+	if len(srv.Filters()) != 2 {
+		t.Fatalf("dry run changed filters: %d, want 2", len(srv.Filters()))
+	}
+
+	out = mustRunCLI(t, "filters", "import", path, "-f", "json")
+	var plan struct {
+		Applied        bool     `json:"applied"`
+		LabelsCreated  []string `json:"labels_created"`
+		LabelsToCreate []string `json:"labels_to_create"`
+	}
+	if err := json.Unmarshal([]byte(out), &plan); err != nil {
+		t.Fatalf("filters import output is not JSON: %v\n%s", err, out)
+	}
+	if plan.Applied || len(plan.LabelsCreated) != 0 || strings.Join(plan.LabelsToCreate, ",") != "Builds" {
+		t.Errorf("filters import dry run result = %+v, want the Builds label to create and none created", plan)
+	}
+
+	out = mustRunCLI(t, "filters", "import", path, "--yes", "-f", "json")
+	var result struct {
+		Applied       bool     `json:"applied"`
+		Create        []string `json:"create"`
+		LabelsCreated []string `json:"labels_created"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("filters import output is not JSON: %v\n%s", err, out)
+	}
+	if !result.Applied || len(result.Create) != 1 || strings.Join(result.LabelsCreated, ",") != "Builds" {
+		t.Errorf("filters import result = %+v, want 1 filter and the Builds label created", result)
+	}
+	if len(srv.Filters()) != 3 {
+		t.Errorf("after import, %d filters exist, want 3", len(srv.Filters()))
+	}
+}
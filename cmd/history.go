@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// historyTypes maps the record types gsuite prints to the historyTypes
+// values Users.History.List accepts.
+var historyTypes = map[string]string{
+	"messagesAdded":   "messageAdded",
+	"messagesDeleted": "messageDeleted",
+	"labelsAdded":     "labelAdded",
+	"labelsRemoved":   "labelRemoved",
+}
+
+var (
+	// historyCmd flags
+	historySince    uint64
+	historyTypeList string
+	historyLabel    string
+	historyReset    bool
+	historyNoSave   bool
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Print mailbox changes since a history ID as NDJSON",
+	Long: `Print the changes made to the mailbox since a history ID, one JSON
+object per line (NDJSON), regardless of --format.
+
+Each record has a type (messagesAdded, messagesDeleted, labelsAdded or
+labelsRemoved), the history_id of the change, the message_id and thread_id,
+the message's label_ids and, for label changes, the changed_label_ids.
+
+After a successful run the mailbox's latest history ID is saved for the
+account in history.json next to accounts.json, and the next run without
+--since continues from there. The first run for an account only records the
+current position. Runs with --types or --label never save the position:
+they skip changes they do not print, and saving would hide those from the
+next unfiltered run. Gmail keeps roughly a week of history; if the saved
+position has expired, re-sync the mailbox and run with --reset.`,
+	Example: `  # Start tracking the mailbox, then poll for changes
+  gsuite history
+  gsuite history
+
+  # Changes since a known history ID, without saving the position
+  gsuite history --since 123456 --no-save
+
+  # Only new messages in the inbox (the saved position is left as it was)
+  gsuite history --types messagesAdded --label INBOX`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	// historyCmd flags
+	historyCmd.Flags().Uint64Var(&historySince, "since", 0, "History ID to start after (default: the saved position)")
+	historyCmd.Flags().StringVar(&historyTypeList, "types", "", "Comma-separated record types to include (messagesAdded, messagesDeleted, labelsAdded, labelsRemoved)")
+	historyCmd.Flags().StringVar(&historyLabel, "label", "", "Only include changes to messages with this label name or ID")
+	historyCmd.Flags().BoolVar(&historyReset, "reset", false, "Save the mailbox's current history ID as the position and exit")
+	historyCmd.Flags().BoolVar(&historyNoSave, "no-save", false, "Do not save the new position (implied by --types and --label)")
+	historyCmd.MarkFlagsMutuallyExclusive("since", "reset")
+}
+
+// historyRecord is one mailbox change as printed by the history command.
+type historyRecord struct {
+	Type            string   `json:"type"`
+	HistoryID       uint64   `json:"history_id"`
+	MessageID       string   `json:"message_id"`
+	ThreadID        string   `json:"thread_id"`
+	LabelIDs        []string `json:"label_ids,omitempty"`
+	ChangedLabelIDs []string `json:"changed_label_ids,omitempty"`
+}
+
+// historyExpiredError reports a start history ID older than the history
+// Gmail still keeps.
+type historyExpiredError struct {
+	start uint64
+}
+
+func (e *historyExpiredError) Error() string {
+	return fmt.Sprintf("history ID %d has expired; re-sync the mailbox and run 'gsuite history --reset'", e.start)
+}
+
+// historyQuery selects the changes listHistory returns.
+type historyQuery struct {
+	// types holds Users.History.List historyTypes values; empty means all.
+	types []string
+	// labelID, if set, limits changes to messages with this label.
+	labelID string
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	types, err := parseHistoryTypes(historyTypeList)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	account, err := currentAccount(service)
+	if err != nil {
+		return err
+	}
+
+	start := historySince
+	if !cmd.Flags().Changed("since") && !historyReset {
+		if start, err = auth.LoadHistoryID(account); err != nil {
+			return err
+		}
+	}
+	if start == 0 {
+		// No position yet: record where the mailbox is now.
+		profile, err := service.Users.GetProfile("me").Do()
+		if err != nil {
+			return fmt.Errorf("Gmail API error: %w", err)
+		}
+		if err := auth.SaveHistoryID(account, profile.HistoryId, true); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "History position for %s set to %d. Run again to see changes after it.\n", account, profile.HistoryId)
+		return nil
+	}
+
+	query := historyQuery{types: types}
+	if historyLabel != "" {
+		if query.labelID, err = newLabelResolver(service).ResolveOne(historyLabel); err != nil {
+			return err
+		}
+	}
+
+	records, latest, err := listHistory(ctx, service, start, query)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := outputNDJSON(record); err != nil {
+			return err
+		}
+	}
+
+	// A filtered run skips changes it did not print; saving its position
+	// would hide them from the next unfiltered run.
+	filtered := len(types) > 0 || historyLabel != ""
+	if !historyNoSave && !filtered {
+		if err := auth.SaveHistoryID(account, latest, false); err != nil {
+			return err
+		}
+	}
+	if GetVerbose() {
+		fmt.Fprintf(os.Stderr, "%d changes since %d; mailbox is at history ID %d\n", len(records), start, latest)
+	}
+	return nil
+}
+
+// parseHistoryTypes converts a comma-separated list of record types into
+// Users.History.List historyTypes values.
+func parseHistoryTypes(list string) ([]string, error) {
+	var types []string
+	for _, name := range splitCommaList(list) {
+		apiType, ok := historyTypes[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid history type: %s (must be messagesAdded, messagesDeleted, labelsAdded or labelsRemoved)", name)
+		}
+		types = append(types, apiType)
+	}
+	return types, nil
+}
+
+// listHistory returns the mailbox changes after start, following all pages,
+// and the mailbox's latest history ID.
+func listHistory(ctx context.Context, service *gmail.Service, start uint64, query historyQuery) ([]historyRecord, uint64, error) {
+	call := service.Users.History.List("me").StartHistoryId(start).MaxResults(gmailMaxPageSize)
+	if len(query.types) > 0 {
+		call.HistoryTypes(query.types...)
+	}
+	if query.labelID != "" {
+		call.LabelId(query.labelID)
+	}
+
+	var records []historyRecord
+	latest := start
+	err := call.Pages(ctx, func(resp *gmail.ListHistoryResponse) error {
+		for _, h := range resp.History {
+			records = append(records, historyRecords(h)...)
+		}
+		latest = max(latest, resp.HistoryId)
+		return nil
+	})
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return nil, 0, &historyExpiredError{start: start}
+		}
+		return nil, 0, fmt.Errorf("Gmail API error: %w", err)
+	}
+	return records, latest, nil
+}
+
+// historyRecords flattens one history entry into a record per changed message.
+func historyRecords(h *gmail.History) []historyRecord {
+	var records []historyRecord
+	add := func(recordType string, m *gmail.Message, changed []string) {
+		if m == nil {
+			return
+		}
+		records = append(records, historyRecord{
+			Type:            recordType,
+			HistoryID:       h.Id,
+			MessageID:       m.Id,
+			ThreadID:        m.ThreadId,
+			LabelIDs:        m.LabelIds,
+			ChangedLabelIDs: changed,
+		})
+	}
+	for _, added := range h.MessagesAdded {
+		add("messagesAdded", added.Message, nil)
+	}
+	for _, deleted := range h.MessagesDeleted {
+		add("messagesDeleted", deleted.Message, nil)
+	}
+	for _, added := range h.LabelsAdded {
+		add("labelsAdded", added.Message, added.LabelIds)
+	}
+	for _, removed := range h.LabelsRemoved {
+		add("labelsRemoved", removed.Message, removed.LabelIds)
+	}
+	return records
+}
+
+// currentAccount returns the email of the account service acts for: the
+// --account flag or GSUITE_ACCOUNT, else the active account, else the
+// profile's address.
+func currentAccount(service *gmail.Service) (string, error) {
+	if account := GetAccountEmail(); account != "" {
+		return account, nil
+	}
+	if store, err := auth.LoadAccountStore(); err == nil {
+		if account, err := store.GetActive(); err == nil && account != "" {
+			return account, nil
+		}
+	}
+	profile, err := service.Users.GetProfile("me").Do()
+	if err != nil {
+		return "", fmt.Errorf("Gmail API error: %w", err)
+	}
+	return profile.EmailAddress, nil
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestParseHistoryTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "should return nil for an empty list", input: "", want: nil},
+		{name: "should map record types to API types", input: "messagesAdded, labelsRemoved", want: []string{"messageAdded", "labelRemoved"}},
+		{name: "should reject unknown types", input: "messagesAdded,threadsAdded", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseHistoryTypes(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseHistoryTypes(%q) expected error, got %v", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHistoryTypes(%q) unexpected error: %v", tt.input, err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("parseHistoryTypes(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryRecords(t *testing.T) {
+	t.Parallel()
+
+	msg := &gmail.Message{Id: "m1", ThreadId: "t1", LabelIds: []string{"INBOX", "STARRED"}}
+	h := &gmail.History{
+		Id:            42,
+		MessagesAdded: []*gmail.HistoryMessageAdded{{Message: msg}},
+		LabelsAdded:   []*gmail.HistoryLabelAdded{{Message: msg, LabelIds: []string{"STARRED"}}},
+		LabelsRemoved: []*gmail.HistoryLabelRemoved{{Message: msg, LabelIds: []string{"UNREAD"}}},
+		MessagesDeleted: []*gmail.HistoryMessageDeleted{
+			{Message: &gmail.Message{Id: "m2", ThreadId: "t2"}},
+		},
+	}
+
+	got := historyRecords(h)
+	want := []string{
+		"messagesAdded 42 m1 t1 INBOX,STARRED ",
+		"messagesDeleted 42 m2 t2  ",
+		"labelsAdded 42 m1 t1 INBOX,STARRED STARRED",
+		"labelsRemoved 42 m1 t1 INBOX,STARRED UNREAD",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("historyRecords() = %d records, want %d", len(got), len(want))
+	}
+	for i, r := range got {
+		line := strings.Join([]string{r.Type, "42", r.MessageID, r.ThreadID, strings.Join(r.LabelIDs, ","), strings.Join(r.ChangedLabelIDs, ",")}, " ")
+		if r.HistoryID != 42 || line != want[i] {
+			t.Errorf("historyRecords()[%d] = %q (history %d), want %q", i, line, r.HistoryID, want[i])
+		}
+	}
+}
+
+// decodeHistory parses the NDJSON printed by the history command.
+func decodeHistory(t *testing.T, out string) []historyRecord {
+	t.Helper()
+	var records []historyRecord
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		var r historyRecord
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("history output line is not JSON: %v\n%s", err, line)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestE2EHistory(t *testing.T) {
+	srv := newE2EServer(t)
+	srv.AddMessage(e2eInboxMessage, "INBOX")
+
+	// The first run only records the current position.
+	if out := mustRunCLI(t, "history"); out != "" {
+		t.Errorf("first history run printed %q, want nothing", out)
+	}
+	if id, _ := auth.LoadHistoryID(srv.Email()); id != srv.HistoryID() {
+		t.Errorf("saved history ID = %d, want %d", id, srv.HistoryID())
+	}
+	start := srv.HistoryID()
+
+	id := srv.AddMessage(e2eInboxMessage, "INBOX", "UNREAD")
+	mustRunCLI(t, "messages", "modify", id, "--remove-labels", "UNREAD")
+
+	records := decodeHistory(t, mustRunCLI(t, "history"))
+	if len(records) != 2 || records[0].Type != "messagesAdded" || records[0].MessageID != id ||
+		records[1].Type != "labelsRemoved" || strings.Join(records[1].ChangedLabelIDs, ",") != "UNREAD" {
+		t.Errorf("history records = %+v, want messagesAdded and labelsRemoved for %s", records, id)
+	}
+	if saved, _ := auth.LoadHistoryID(srv.Email()); saved != srv.HistoryID() {
+		t.Errorf("saved history ID = %d, want %d", saved, srv.HistoryID())
+	}
+
+	// Nothing new since the saved position.
+	if out := mustRunCLI(t, "history"); out != "" {
+		t.Errorf("history with no changes printed %q, want nothing", out)
+	}
+
+	// A filtered run leaves the position alone, so the next unfiltered run
+	// still sees the change it skipped.
+	saved, _ := auth.LoadHistoryID(srv.Email())
+	other := srv.AddMessage(e2eInboxMessage, "INBOX")
+	if out := mustRunCLI(t, "history", "--types", "labelsRemoved"); out != "" {
+		t.Errorf("history --types labelsRemoved printed %q, want nothing", out)
+	}
+	if id, _ := auth.LoadHistoryID(srv.Email()); id != saved {
+		t.Errorf("saved history ID after a filtered run = %d, want %d", id, saved)
+	}
+	records = decodeHistory(t, mustRunCLI(t, "history"))
+	if len(records) != 1 || records[0].Type != "messagesAdded" || records[0].MessageID != other {
+		t.Errorf("history after a filtered run = %+v, want messagesAdded for %s", records, other)
+	}
+
+	records = decodeHistory(t, mustRunCLI(t, "history", "--since", strconv.FormatUint(start, 10), "--types", "labelsRemoved", "--no-save"))
+	if len(records) != 1 || records[0].Type != "labelsRemoved" {
+		t.Errorf("history --types labelsRemoved = %+v, want one labelsRemoved record", records)
+	}
+
+	srv.ExpireHistory()
+	if _, err := runCLI(t, "history", "--since", strconv.FormatUint(start, 10)); err == nil || !strings.Contains(err.Error(), "--reset") {
+		t.Errorf("history with an expired ID error = %v, want a --reset hint", err)
+	}
+	mustRunCLI(t, "history", "--reset")
+	if saved, _ := auth.LoadHistoryID(srv.Email()); saved != srv.HistoryID() {
+		t.Errorf("saved history ID after --reset = %d, want %d", saved, srv.HistoryID())
+	}
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlToText renders an HTML message body as plain text for the terminal.
+// Block elements start new lines, list items get bullets or numbers,
+// blockquotes are prefixed with "> ", and whitespace is collapsed outside
+// <pre>. Links keep their text followed by a [n] marker, and their targets
+// are listed as numbered footnotes after the text.
+func htmlToText(src string) string {
+	r := &htmlTextRenderer{linkNumbers: make(map[string]int)}
+	z := html.NewTokenizer(strings.NewReader(src))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return r.String()
+		case html.TextToken:
+			if r.skip == 0 {
+				r.text(string(z.Text()))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			var href string
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "href" {
+					href = strings.TrimSpace(string(val))
+				}
+			}
+			r.start(atom.Lookup(name), href, tt == html.SelfClosingTagToken)
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			r.end(atom.Lookup(name))
+		}
+	}
+}
+
+// htmlTextRenderer accumulates the text rendering of an HTML document.
+type htmlTextRenderer struct {
+	out strings.Builder
+	// newlines is the number of line breaks owed before the next text.
+	newlines int
+	// space is set when collapsed whitespace is owed before the next text.
+	space bool
+	// lineStart is set when the output is at the start of a line.
+	lineStart bool
+	// skip is the depth of elements whose content is not shown.
+	skip int
+	// pre is the depth of <pre> elements.
+	pre int
+	// quote is the depth of <blockquote> elements.
+	quote int
+	// lists holds, for every open list, -1 for <ul> or the last number
+	// used for <ol>.
+	lists []int
+	// bullet is written before the next text, at the start of a list item.
+	bullet string
+	// href and linkText describe the <a> being rendered.
+	href     string
+	linkText strings.Builder
+	// links holds the footnote targets; linkNumbers maps them to their number.
+	links       []string
+	linkNumbers map[string]int
+}
+
+// blockBreaks is the number of line breaks around each block element; 2
+// leaves a blank line.
+var blockBreaks = map[atom.Atom]int{
+	atom.P: 2, atom.H1: 2, atom.H2: 2, atom.H3: 2, atom.H4: 2, atom.H5: 2, atom.H6: 2,
+	atom.Blockquote: 2, atom.Pre: 2, atom.Table: 2, atom.Ul: 2, atom.Ol: 2,
+	atom.Div: 1, atom.Tr: 1, atom.Li: 1, atom.Section: 1, atom.Article: 1,
+	atom.Header: 1, atom.Footer: 1, atom.Nav: 1, atom.Center: 1, atom.Address: 1,
+	atom.Dl: 1, atom.Dt: 1, atom.Dd: 1, atom.Form: 1, atom.Hr: 1,
+}
+
+func (r *htmlTextRenderer) start(a atom.Atom, href string, selfClosing bool) {
+	switch a {
+	case atom.Script, atom.Style, atom.Head, atom.Title, atom.Template:
+		if !selfClosing {
+			r.skip++
+		}
+		return
+	case atom.Br:
+		r.newlines++
+		return
+	case atom.Td, atom.Th:
+		r.space = true
+		return
+	case atom.A:
+		if isFootnoteLink(href) {
+			r.href = href
+			r.linkText.Reset()
+		}
+		return
+	}
+
+	r.block(r.breaks(a))
+	switch a {
+	case atom.Pre:
+		r.pre++
+	case atom.Blockquote:
+		r.quote++
+	case atom.Ul:
+		r.lists = append(r.lists, -1)
+	case atom.Ol:
+		r.lists = append(r.lists, 0)
+	case atom.Li:
+		depth := max(len(r.lists), 1)
+		marker := "- "
+		if n := len(r.lists); n > 0 && r.lists[n-1] >= 0 {
+			r.lists[n-1]++
+			marker = fmt.Sprintf("%d. ", r.lists[n-1])
+		}
+		r.bullet = strings.Repeat("  ", depth-1) + marker
+	case atom.Hr:
+		r.write("---")
+		r.block(1)
+	}
+}
+
+func (r *htmlTextRenderer) end(a atom.Atom) {
+	switch a {
+	case atom.Script, atom.Style, atom.Head, atom.Title, atom.Template:
+		r.skip = max(r.skip-1, 0)
+		return
+	case atom.A:
+		r.endLink()
+		return
+	case atom.Pre:
+		r.pre = max(r.pre-1, 0)
+	case atom.Blockquote:
+		r.quote = max(r.quote-1, 0)
+	case atom.Ul, atom.Ol:
+		if len(r.lists) > 0 {
+			r.lists = r.lists[:len(r.lists)-1]
+		}
+	}
+	r.block(r.breaks(a))
+}
+
+// breaks returns the line breaks owed around element a. Lists nested in
+// another list only start a new line.
+func (r *htmlTextRenderer) breaks(a atom.Atom) int {
+	if (a == atom.Ul || a == atom.Ol) && len(r.lists) > 0 {
+		return 1
+	}
+	return blockBreaks[a]
+}
+
+// endLink writes the footnote marker for the link being closed, unless its
+// text already shows the target.
+func (r *htmlTextRenderer) endLink() {
+	href := r.href
+	if href == "" {
+		return
+	}
+	r.href = ""
+	text := strings.TrimSpace(r.linkText.String())
+	if text == href || "mailto:"+text == href {
+		return
+	}
+	n, ok := r.linkNumbers[href]
+	if !ok {
+		r.links = append(r.links, href)
+		n = len(r.links)
+		r.linkNumbers[href] = n
+	}
+	r.write(fmt.Sprintf("[%d]", n))
+}
+
+// isFootnoteLink reports whether href points somewhere worth listing.
+func isFootnoteLink(href string) bool {
+	lower := strings.ToLower(href)
+	return href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(lower, "javascript:")
+}
+
+// block owes at least n line breaks before the next text.
+func (r *htmlTextRenderer) block(n int) {
+	r.newlines = max(r.newlines, n)
+}
+
+// text renders a text node, collapsing whitespace outside <pre>.
+func (r *htmlTextRenderer) text(s string) {
+	if r.pre > 0 {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				r.newlines++
+			}
+			if line != "" {
+				r.write(line)
+			}
+		}
+		return
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" {
+			r.space = true
+		}
+		return
+	}
+	if strings.TrimLeftFunc(s, unicode.IsSpace) != s {
+		r.space = true
+	}
+	r.write(strings.Join(fields, " "))
+	r.space = strings.TrimRightFunc(s, unicode.IsSpace) != s
+}
+
+// write appends s, first emitting any owed line breaks or space.
+func (r *htmlTextRenderer) write(s string) {
+	if r.out.Len() > 0 {
+		if r.newlines > 0 {
+			r.out.WriteString(strings.Repeat("\n", r.newlines))
+			r.lineStart = true
+		} else if r.space && !r.lineStart && r.bullet == "" {
+			r.out.WriteByte(' ')
+		}
+	} else {
+		r.lineStart = true
+	}
+	if r.lineStart {
+		r.out.WriteString(strings.Repeat("> ", r.quote))
+		r.out.WriteString(r.bullet)
+		r.bullet = ""
+	}
+	r.newlines = 0
+	r.space = false
+	r.lineStart = false
+	r.out.WriteString(s)
+	if r.href != "" {
+		r.linkText.WriteString(s)
+	}
+}
+
+// String returns the rendered text followed by the link footnotes.
+func (r *htmlTextRenderer) String() string {
+	text := strings.TrimRight(r.out.String(), " \n")
+	if len(r.links) == 0 {
+		return text
+	}
+	var b strings.Builder
+	b.WriteString(text)
+	b.WriteString("\n\n")
+	for i, link := range r.links {
+		fmt.Fprintf(&b, "[%d] %s\n", i+1, link)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
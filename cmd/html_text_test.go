@@ -0,0 +1,78 @@
+package cmd
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "should separate paragraphs and collapse whitespace",
+			input: "<p>Hello\n   <b>there</b>,</p><p>Second&nbsp;paragraph</p>",
+			want:  "Hello there,\n\nSecond paragraph",
+		},
+		{
+			name:  "should turn br and div into line breaks",
+			input: "<div>one<br>two</div><div>three</div>",
+			want:  "one\ntwo\nthree",
+		},
+		{
+			name:  "should skip head, style and script",
+			input: "<html><head><title>T</title><style>p{color:red}</style></head><body><script>x()</script><p>Body</p></body></html>",
+			want:  "Body",
+		},
+		{
+			name:  "should list links as footnotes",
+			input: `<p>Read the <a href="https://example.com/report">report</a> and <a href="https://example.com/faq">FAQ</a>, or the <a href="https://example.com/report">report</a> again.</p>`,
+			want:  "Read the report[1] and FAQ[2], or the report[1] again.\n\n[1] https://example.com/report\n[2] https://example.com/faq",
+		},
+		{
+			name:  "should not footnote links that show their target or are anchors",
+			input: `<a href="https://example.com">https://example.com</a> <a href="mailto:a@example.com">a@example.com</a> <a href="#top">top</a>`,
+			want:  "https://example.com a@example.com top",
+		},
+		{
+			name:  "should render bullet and numbered lists",
+			input: "<ul><li>apples</li><li>pears<ol><li>first</li><li>second</li></ol></li></ul><p>after</p>",
+			want:  "- apples\n- pears\n  1. first\n  2. second\n\nafter",
+		},
+		{
+			name:  "should prefix blockquotes",
+			input: "<p>Reply</p><blockquote><p>Original line</p></blockquote>",
+			want:  "Reply\n\n> Original line",
+		},
+		{
+			name:  "should keep whitespace in pre",
+			input: "<pre>a  b\n  c</pre>",
+			want:  "a  b\n  c",
+		},
+		{
+			name:  "should separate table cells",
+			input: "<table><tr><td>Name</td><td>Total</td></tr><tr><td>Alice</td><td>42</td></tr></table>",
+			want:  "Name Total\nAlice 42",
+		},
+		{
+			name:  "should render a signature with breaks and entities",
+			input: "<p><strong>Jane Doe</strong><br>\nSupport Lead</p>\n<p>R&amp;D &lt;team&gt;</p>",
+			want:  "Jane Doe\nSupport Lead\n\nR&D <team>",
+		},
+		{
+			name:  "should return empty for empty input",
+			input: "",
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := htmlToText(tt.input); got != tt.want {
+				t.Errorf("htmlToText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
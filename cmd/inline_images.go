@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// inlineImage is a local image embedded in an outgoing HTML body, which
+// refers to it with a "cid:" URL.
+type inlineImage struct {
+	ContentID string
+	Filename  string
+	MimeType  string
+	Data      []byte
+}
+
+// inlineImageCollector is a goldmark AST transformer that embeds the local
+// files referenced by markdown images (![logo](./logo.png)): it reads each
+// file once and points the image at a cid: URL instead. Remote and data:
+// URLs are left alone.
+type inlineImageCollector struct {
+	// baseDir is what relative image paths are resolved against; empty means
+	// the current directory.
+	baseDir string
+	images  []inlineImage
+	// cids maps each embedded file's path to its Content-ID.
+	cids map[string]string
+	// err is the first file that could not be read.
+	err error
+}
+
+func newInlineImageCollector(baseDir string) *inlineImageCollector {
+	return &inlineImageCollector{baseDir: baseDir, cids: make(map[string]string)}
+}
+
+// Transform implements parser.ASTTransformer.
+func (c *inlineImageCollector) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		img, ok := n.(*ast.Image)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		path, ok := localImagePath(string(img.Destination))
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		cid, err := c.add(path)
+		if err != nil {
+			if c.err == nil {
+				c.err = err
+			}
+			return ast.WalkContinue, nil
+		}
+		img.Destination = []byte("cid:" + cid)
+		return ast.WalkContinue, nil
+	})
+}
+
+// add embeds the file at path, if it is not already, and returns its
+// Content-ID.
+func (c *inlineImageCollector) add(path string) (string, error) {
+	if c.baseDir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(c.baseDir, path)
+	}
+	if cid, ok := c.cids[path]; ok {
+		return cid, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read inline image %s: %w", path, err)
+	}
+	mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	cid := fmt.Sprintf("image%d.%s@gsuite", len(c.images)+1, strings.ToLower(rand.Text()))
+	c.images = append(c.images, inlineImage{
+		ContentID: cid,
+		Filename:  filepath.Base(path),
+		MimeType:  mimeType,
+		Data:      data,
+	})
+	c.cids[path] = cid
+	return cid, nil
+}
+
+// localImagePath returns the file an image destination refers to, if it is
+// a relative or absolute path or a file: URL rather than a remote URL.
+func localImagePath(dest string) (string, bool) {
+	if dest == "" || strings.HasPrefix(dest, "#") || strings.HasPrefix(dest, "//") {
+		return "", false
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		// Not a valid URL, e.g. a path with a stray "%": treat it as a path.
+		return dest, true
+	}
+	switch u.Scheme {
+	case "", "file":
+		return u.Path, u.Path != ""
+	}
+	// A Windows drive letter parses as a one-letter scheme.
+	if len(u.Scheme) == 1 && filepath.VolumeName(dest) != "" {
+		return dest, true
+	}
+	return "", false
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalImagePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		dest   string
+		want   string
+		wantOK bool
+	}{
+		{name: "should accept a relative path", dest: "./logo.png", want: "./logo.png", wantOK: true},
+		{name: "should accept an absolute path", dest: "/srv/report/chart.png", want: "/srv/report/chart.png", wantOK: true},
+		{name: "should decode percent-escapes", dest: "my%20logo.png", want: "my logo.png", wantOK: true},
+		{name: "should accept a file URL", dest: "file:///tmp/logo.png", want: "/tmp/logo.png", wantOK: true},
+		{name: "should skip https URLs", dest: "https://example.com/logo.png"},
+		{name: "should skip protocol-relative URLs", dest: "//cdn.example.com/logo.png"},
+		{name: "should skip data URLs", dest: "data:image/png;base64,iVBORw0KGgo="},
+		{name: "should skip cid URLs", dest: "cid:logo@example.com"},
+		{name: "should skip fragments", dest: "#top"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := localImagePath(tt.dest)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("localImagePath(%q) = %q, %v, want %q, %v", tt.dest, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// writeTestPNG writes a tiny PNG-like file named name to dir.
+func writeTestPNG(t *testing.T, dir, name string) (string, []byte) {
+	t.Helper()
+	data := []byte("\x89PNG\r\n\x1a\n" + name)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path, data
+}
+
+func TestComposeBodies_InlineImages(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	logo, logoData := writeTestPNG(t, dir, "logo.png")
+	chart, _ := writeTestPNG(t, dir, "chart.png")
+
+	body := "![logo](" + logo + ")\n\nChart: ![chart](file://" + chart + ") and again ![logo](" + logo + ")\n\n![remote](https://example.com/x.png)"
+	plain, htmlBody, images, err := composeBodies(body, "", "> ![quoted]("+chart+")")
+	if err != nil {
+		t.Fatalf("composeBodies() error = %v", err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("got %d images, want 2 (each file once)", len(images))
+	}
+	if images[0].Filename != "logo.png" || images[0].MimeType != "image/png" || !bytes.Equal(images[0].Data, logoData) {
+		t.Errorf("images[0] = %s %s, want logo.png image/png", images[0].Filename, images[0].MimeType)
+	}
+	if n := strings.Count(htmlBody, `src="cid:`+images[0].ContentID+`"`); n != 2 {
+		t.Errorf("html refers to the logo %d times, want 2:\n%s", n, htmlBody)
+	}
+	if !strings.Contains(htmlBody, `src="https://example.com/x.png"`) {
+		t.Errorf("html should keep the remote image:\n%s", htmlBody)
+	}
+	if !strings.Contains(htmlBody, `src="`+chart+`"`) {
+		t.Errorf("html should not embed images in quoted text:\n%s", htmlBody)
+	}
+	if !strings.Contains(plain, "![logo]("+logo+")") {
+		t.Errorf("plain body should keep the markdown:\n%s", plain)
+	}
+
+	if _, _, _, err := composeBodies("![missing]("+filepath.Join(dir, "missing.png")+")", "", ""); err == nil || !strings.Contains(err.Error(), "missing.png") {
+		t.Errorf("composeBodies() with a missing image error = %v, want it to name the file", err)
+	}
+}
+
+func TestBuildAlternativeParts_InlineImages(t *testing.T) {
+	t.Parallel()
+	logo, logoData := writeTestPNG(t, t.TempDir(), "logo.png")
+
+	plain, html, images, err := composeBodies("Hello ![logo]("+logo+")", "", "")
+	if err != nil {
+		t.Fatalf("composeBodies() error = %v", err)
+	}
+	altBody, boundary, err := buildAlternativeParts(plain, html, images...)
+	if err != nil {
+		t.Fatalf("buildAlternativeParts() error = %v", err)
+	}
+
+	alt := multipart.NewReader(bytes.NewReader(altBody), boundary)
+	if part, err := alt.NextPart(); err != nil || !strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain") {
+		t.Fatalf("first part = %v, %v, want text/plain", part, err)
+	}
+	part, err := alt.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart() error = %v", err)
+	}
+	mediaType, params, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+	if mediaType != "multipart/related" || params["type"] != "text/html" {
+		t.Fatalf("second part is %s, want multipart/related of text/html", part.Header.Get("Content-Type"))
+	}
+
+	related := multipart.NewReader(part, params["boundary"])
+	htmlPart, err := related.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart() error = %v", err)
+	}
+	htmlBody, _ := io.ReadAll(htmlPart)
+	imgPart, err := related.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart() error = %v", err)
+	}
+	cid := strings.Trim(imgPart.Header.Get("Content-ID"), "<>")
+	if !strings.Contains(string(htmlBody), `src="cid:`+cid+`"`) {
+		t.Errorf("html does not refer to Content-ID %q:\n%s", cid, htmlBody)
+	}
+	if got := imgPart.Header.Get("Content-Disposition"); got != `inline; filename="logo.png"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	data, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, imgPart))
+	if !bytes.Equal(data, logoData) {
+		t.Errorf("image data = %q, want %q", data, logoData)
+	}
+	if _, err := related.NextPart(); err != io.EOF {
+		t.Errorf("related has extra parts: %v", err)
+	}
+}
+
+func TestE2ESendInlineImage(t *testing.T) {
+	srv := newE2EServer(t)
+	dir := t.TempDir()
+	chart, _ := writeTestPNG(t, dir, "chart.png")
+	report := filepath.Join(dir, "report.csv")
+	if err := os.WriteFile(report, []byte("week,signups\n1,42\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mustRunCLI(t, "send", "--to", "bob@example.com", "-s", "Weekly report", "-b", "Signups:\\n\\n![chart]("+chart+")", "--attach", report)
+	sent := srv.MessageIDs("SENT")
+	if len(sent) != 1 {
+		t.Fatalf("SENT has %d messages, want 1", len(sent))
+	}
+	raw, _ := srv.RawMessage(sent[0])
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if ct := msg.Header.Get("Content-Type"); !strings.HasPrefix(ct, "multipart/mixed") {
+		t.Errorf("Content-Type = %q, want multipart/mixed", ct)
+	}
+	for _, want := range []string{"multipart/related", "Content-Id: <image1.", `src="cid:image1.`, `Content-Disposition: inline; filename="chart.png"`, `Content-Disposition: attachment; filename="report.csv"`} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("sent message missing %q", want)
+		}
+	}
+
+	if _, err := runCLI(t, "send", "--to", "bob@example.com", "-s", "x", "-b", "![gone]("+filepath.Join(dir, "gone.png")+")"); err == nil {
+		t.Error("send with a missing inline image should fail")
+	}
+}
@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"google.golang.org/api/gmail/v1"
+)
+
+// labelCacheTTLEnv enables the on-disk label cache. It holds how long a
+// cached label list is used before it is fetched again, e.g. "10m".
+const labelCacheTTLEnv = "GSUITE_LABEL_CACHE_TTL"
+
+// userLabelIDRegexp matches the IDs Gmail assigns to user labels.
+var userLabelIDRegexp = regexp.MustCompile(`^Label_\d+$`)
+
+// labelListCache holds label lists fetched in this process, keyed by
+// labelCacheKey, so commands that resolve several label flags list labels once.
+var labelListCache = struct {
+	sync.Mutex
+	labels map[string][]*gmail.Label
+}{labels: make(map[string][]*gmail.Label)}
+
+// labelNotFoundError reports a label name that matches no label.
+type labelNotFoundError struct {
+	name        string
+	suggestions []string
+}
+
+func (e *labelNotFoundError) Error() string {
+	if len(e.suggestions) == 0 {
+		return fmt.Sprintf("label not found: %s (see 'gsuite labels list')", e.name)
+	}
+	return fmt.Sprintf("label not found: %s (did you mean %s?)", e.name, strings.Join(quoteAll(e.suggestions), ", "))
+}
+
+// labelResolver resolves label names to IDs for the account of a Gmail
+// service. Labels are listed at most once per process, or read from the
+// on-disk cache when GSUITE_LABEL_CACHE_TTL is set. A name that is not found
+// triggers one fresh listing, so labels created elsewhere are still found.
+type labelResolver struct {
+	service *gmail.Service
+	labels  []*gmail.Label
+	fresh   bool
+}
+
+// newLabelResolver returns a resolver for the account of service.
+func newLabelResolver(service *gmail.Service) *labelResolver {
+	return &labelResolver{service: service}
+}
+
+// Labels returns every label of the account, from a cache if possible.
+func (r *labelResolver) Labels() ([]*gmail.Label, error) {
+	if r.labels != nil {
+		return r.labels, nil
+	}
+	key := labelCacheKey(r.service)
+
+	labelListCache.Lock()
+	cached, ok := labelListCache.labels[key]
+	labelListCache.Unlock()
+	if ok {
+		r.labels = cached
+		return r.labels, nil
+	}
+
+	if cached, ok := readLabelDiskCache(); ok {
+		r.labels = cached
+		labelListCache.Lock()
+		labelListCache.labels[key] = cached
+		labelListCache.Unlock()
+		return r.labels, nil
+	}
+
+	return r.refresh()
+}
+
+// refresh lists labels from the API and updates both caches.
+func (r *labelResolver) refresh() ([]*gmail.Label, error) {
+	labels, err := listLabels(r.service)
+	if err != nil {
+		return nil, fmt.Errorf("Gmail API error: %w", err)
+	}
+	r.labels = labels
+	r.fresh = true
+
+	labelListCache.Lock()
+	labelListCache.labels[labelCacheKey(r.service)] = labels
+	labelListCache.Unlock()
+	writeLabelDiskCache(labels)
+	return labels, nil
+}
+
+// Resolve returns the IDs of the labels named in names, which may be IDs or
+// case-insensitive names. System and user label IDs are passed through
+// without listing labels. Blank entries are skipped.
+func (r *labelResolver) Resolve(names []string) ([]string, error) {
+	needsLookup := false
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" && !isLabelID(name) {
+			needsLookup = true
+		}
+	}
+	if !needsLookup {
+		return resolveLabelIDs(nil, names)
+	}
+
+	labels, err := r.Labels()
+	if err != nil {
+		return nil, err
+	}
+	ids, err := resolveLabelIDs(labels, names)
+	var notFound *labelNotFoundError
+	if errors.As(err, &notFound) && !r.fresh {
+		if labels, err = r.refresh(); err != nil {
+			return nil, err
+		}
+		ids, err = resolveLabelIDs(labels, names)
+	}
+	return ids, err
+}
+
+// ResolveOne resolves a single label name or ID.
+func (r *labelResolver) ResolveOne(name string) (string, error) {
+	ids, err := r.Resolve([]string{name})
+	if err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("label name or ID is required")
+	}
+	return ids[0], nil
+}
+
+// NamesByID returns a map from label ID to name.
+func (r *labelResolver) NamesByID() (map[string]string, error) {
+	labels, err := r.Labels()
+	if err != nil {
+		return nil, err
+	}
+	return labelNamesByID(labels), nil
+}
+
+// invalidateLabelCache drops the cached label lists after labels change.
+func invalidateLabelCache(service *gmail.Service) {
+	labelListCache.Lock()
+	delete(labelListCache.labels, labelCacheKey(service))
+	labelListCache.Unlock()
+	if path, ok := labelDiskCachePath(); ok {
+		os.Remove(path)
+	}
+}
+
+// labelCacheKey identifies the account and API endpoint a label list belongs to.
+func labelCacheKey(service *gmail.Service) string {
+	return service.BasePath + "|" + GetAccountEmail()
+}
+
+// isLabelID reports whether name is a system label ID or has the form of a
+// user label ID.
+func isLabelID(name string) bool {
+	return systemLabelIDs[name] || userLabelIDRegexp.MatchString(name)
+}
+
+// labelDiskCache is the on-disk form of an account's cached label list.
+type labelDiskCache struct {
+	FetchedAt time.Time      `json:"fetched_at"`
+	Labels    []*gmail.Label `json:"labels"`
+}
+
+// labelDiskCachePath returns the cache file for the current account. It
+// reports false when the disk cache is disabled or the account is unknown.
+func labelDiskCachePath() (string, bool) {
+	if labelCacheTTL() <= 0 {
+		return "", false
+	}
+	account := GetAccountEmail()
+	if account == "" {
+		store, err := auth.LoadAccountStore()
+		if err != nil {
+			return "", false
+		}
+		if account, err = store.GetActive(); err != nil || account == "" {
+			return "", false
+		}
+	}
+	dir, err := auth.CacheDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(dir, "labels-"+account+".json"), true
+}
+
+// labelCacheTTL returns the disk cache lifetime from GSUITE_LABEL_CACHE_TTL,
+// or 0 when it is unset or invalid.
+func labelCacheTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv(labelCacheTTLEnv))
+	if err != nil {
+		return 0
+	}
+	return ttl
+}
+
+// readLabelDiskCache returns the cached labels if the cache is enabled and fresh.
+func readLabelDiskCache() ([]*gmail.Label, bool) {
+	path, ok := labelDiskCachePath()
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var cache labelDiskCache
+	if err := json.Unmarshal(data, &cache); err != nil || time.Since(cache.FetchedAt) > labelCacheTTL() {
+		return nil, false
+	}
+	return cache.Labels, true
+}
+
+// writeLabelDiskCache stores labels if the cache is enabled. Failures are
+// ignored: the cache only saves an API call.
+func writeLabelDiskCache(labels []*gmail.Label) {
+	path, ok := labelDiskCachePath()
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(labelDiskCache{FetchedAt: time.Now(), Labels: labels})
+	if err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+	}
+}
+
+// normalizeLabelName lowercases a label name and trims the space around each
+// "/"-separated path segment, so "Work / Clients" matches "work/clients".
+func normalizeLabelName(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = strings.TrimSpace(segment)
+	}
+	return strings.ToLower(strings.Join(segments, "/"))
+}
+
+// suggestLabels returns up to three label names close to name: names that
+// contain it, whose last path segment equals it, or that are a few edits away.
+func suggestLabels(labels []*gmail.Label, name string) []string {
+	target := normalizeLabelName(name)
+	maxDistance := max(2, len(target)/3)
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var candidates []candidate
+	for _, label := range labels {
+		candidateName := normalizeLabelName(label.Name)
+		distance := editDistance(target, candidateName)
+		leaf := candidateName[strings.LastIndex(candidateName, "/")+1:]
+		switch {
+		case leaf == target, strings.Contains(candidateName, target), strings.Contains(target, candidateName) && len(candidateName) > 2:
+			distance = min(distance, 1)
+		case distance > maxDistance:
+			continue
+		}
+		candidates = append(candidates, candidate{name: label.Name, distance: distance})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	var names []string
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		names = append(names, candidates[i].name)
+	}
+	return names
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// quoteAll returns each string in quotes.
+func quoteAll(items []string) []string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = fmt.Sprintf("%q", item)
+	}
+	return quoted
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestNormalizeLabelName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "should lowercase names", input: "Receipts", want: "receipts"},
+		{name: "should trim space around path segments", input: " Work / Clients ", want: "work/clients"},
+		{name: "should keep space inside segments", input: "Team Support/Open Tickets", want: "team support/open tickets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := normalizeLabelName(tt.input); got != tt.want {
+				t.Errorf("normalizeLabelName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestLabels(t *testing.T) {
+	t.Parallel()
+
+	labels := []*gmail.Label{
+		{Id: "INBOX", Name: "INBOX"},
+		{Id: "Label_1", Name: "Receipts"},
+		{Id: "Label_2", Name: "Work/Clients"},
+		{Id: "Label_3", Name: "Work/Clients/Acme"},
+		{Id: "Label_4", Name: "Travel"},
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "should suggest names a typo away", input: "Reciepts", want: []string{"Receipts"}},
+		{name: "should suggest nested labels by their last segment", input: "Acme", want: []string{"Work/Clients/Acme"}},
+		{name: "should suggest labels containing the name", input: "clients", want: []string{"Work/Clients", "Work/Clients/Acme"}},
+		{name: "should suggest nothing for unrelated names", input: "Groceries", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := suggestLabels(labels, tt.input); !slices.Equal(got, tt.want) {
+				t.Errorf("suggestLabels(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "abc", want: 3},
+		{a: "receipts", b: "receipts", want: 0},
+		{a: "reciepts", b: "receipts", want: 2},
+		{a: "travel", b: "travels", want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestE2ELabelNames(t *testing.T) {
+	srv := newE2EServer(t)
+	receipts := srv.AddLabel("Receipts")
+	srv.AddLabel("Work")
+	clients := srv.AddLabel("Work/Clients")
+	id := srv.AddMessage(e2eInboxMessage, "INBOX")
+
+	mustRunCLI(t, "messages", "modify", id, "--add-labels", "receipts, work / clients", "--remove-labels", "inbox")
+	msg, _ := srv.Message(id)
+	if !slices.Contains(msg.LabelIds, receipts) || !slices.Contains(msg.LabelIds, clients) || slices.Contains(msg.LabelIds, "INBOX") {
+		t.Errorf("after messages modify, labels = %v, want %s and %s without INBOX", msg.LabelIds, receipts, clients)
+	}
+
+	var list []struct {
+		ID string `json:"id"`
+	}
+	out := mustRunCLI(t, "messages", "list", "--label-ids", "Work/Clients", "-f", "json")
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatalf("messages list output is not JSON: %v\n%s", err, out)
+	}
+	if len(list) != 1 || list[0].ID != id {
+		t.Errorf("messages list --label-ids Work/Clients = %+v, want %s", list, id)
+	}
+
+	// A label created after the list was cached is still found.
+	travel := srv.AddLabel("Travel")
+	mustRunCLI(t, "messages", "modify", id, "--add-labels", "Travel")
+	if msg, _ := srv.Message(id); !slices.Contains(msg.LabelIds, travel) {
+		t.Errorf("after adding a new label by name, labels = %v, want %s", msg.LabelIds, travel)
+	}
+
+	_, err := runCLI(t, "search", "report", "--label-ids", "Reciepts")
+	var notFound *labelNotFoundError
+	if !errors.As(err, &notFound) || !strings.Contains(err.Error(), `did you mean "Receipts"?`) {
+		t.Errorf("search with a misspelled label error = %v, want a suggestion", err)
+	}
+
+	mustRunCLI(t, "labels", "delete", "work/clients")
+	if _, err := runCLI(t, "threads", "list", "--label-ids", "Work/Clients"); err == nil {
+		t.Error("threads list with a deleted label succeeded, want an error")
+	}
+}
+
+func TestE2ELabelDiskCache(t *testing.T) {
+	srv := newE2EServer(t)
+	srv.AddLabel("Receipts")
+	t.Setenv("GSUITE_ACCOUNT", srv.Email())
+	t.Setenv(labelCacheTTLEnv, "1h")
+
+	mustRunCLI(t, "messages", "list", "--label-ids", "Receipts")
+
+	path := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "gsuite", "cache", "labels-"+srv.Email()+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("label cache not written: %v", err)
+	}
+	var cache labelDiskCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		t.Fatalf("label cache is not JSON: %v", err)
+	}
+	if !slices.ContainsFunc(cache.Labels, func(l *gmail.Label) bool { return l.Name == "Receipts" }) {
+		t.Errorf("label cache = %s, want the Receipts label", data)
+	}
+
+	mustRunCLI(t, "labels", "create", "-n", "Travel")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("label cache still exists after labels create: %v", err)
+	}
+}
@@ -28,6 +28,7 @@ var systemLabelIDs = map[string]bool{
 	"STARRED":     true,
 	"UNREAD":      true,
 	"IMPORTANT":   true,
+	"CHAT":        true,
 	"CATEGORY_PERSONAL":    true,
 	"CATEGORY_SOCIAL":      true,
 	"CATEGORY_PROMOTIONS":  true,
@@ -83,14 +84,14 @@ Optional flags:
 
 // labelsUpdateCmd represents the labels update command
 var labelsUpdateCmd = &cobra.Command{
-	Use:   "update <label-id>",
+	Use:   "update <label>",
 	Short: "Update an existing Gmail label",
 	Long: `Update a user-created label's properties.
 
 Note: System labels (INBOX, SENT, SPAM, etc.) cannot be updated.
 
 Args:
-  label-id: The ID of the label to update (required)
+  label: The ID or name of the label to update (required)
 
 Optional flags:
   --name, -n: New display name for the label
@@ -99,15 +100,15 @@ Optional flags:
 	Example: `  # Rename a label
   gsuite labels update Label_123 -n "New Name"
 
-  # Update visibility settings
-  gsuite labels update Label_123 --label-list-visibility labelHide`,
+  # Update visibility settings, naming the label
+  gsuite labels update "Work/Clients" --label-list-visibility labelHide`,
 	Args: cobra.ExactArgs(1),
 	RunE: runLabelsUpdate,
 }
 
 // labelsDeleteCmd represents the labels delete command
 var labelsDeleteCmd = &cobra.Command{
-	Use:   "delete <label-id>",
+	Use:   "delete <label>",
 	Short: "Delete a Gmail label",
 	Long: `Delete a user-created label from the authenticated user's Gmail account.
 
@@ -115,7 +116,7 @@ Note: System labels (INBOX, SENT, SPAM, etc.) cannot be deleted.
 Messages that have this label will not be deleted, only the label will be removed from them.
 
 Args:
-  label-id: The ID of the label to delete (required)`,
+  label: The ID or name of the label to delete (required)`,
 	Example: `  # Delete a label
   gsuite labels delete Label_123`,
 	Args: cobra.ExactArgs(1),
@@ -150,14 +151,14 @@ func runLabelsList(cmd *cobra.Command, args []string) error {
 	}
 
 	// List labels
-	resp, err := service.Users.Labels.List("me").Do()
+	labels, err := listLabels(service)
 	if err != nil {
 		return fmt.Errorf("Gmail API error: %w", err)
 	}
 
 	// Separate system and user labels
 	var systemLabels, userLabels []*gmail.Label
-	for _, label := range resp.Labels {
+	for _, label := range labels {
 		if label.Type == "system" {
 			systemLabels = append(systemLabels, label)
 		} else {
@@ -208,7 +209,7 @@ func runLabelsList(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("\n[Total: %d labels (%d system, %d user)]\n",
-		len(resp.Labels), len(systemLabels), len(userLabels))
+		len(labels), len(systemLabels), len(userLabels))
 
 	return nil
 }
@@ -243,6 +244,7 @@ func runLabelsCreate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("Gmail API error: %w", err)
 	}
+	invalidateLabelCache(service)
 
 	// JSON output mode
 	if GetOutputFormat() == "json" {
@@ -283,6 +285,11 @@ func runLabelsUpdate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
+	// Accept a label name as well as an ID
+	if labelID, err = newLabelResolver(service).ResolveOne(labelID); err != nil {
+		return err
+	}
+
 	// First, get the existing label to check if it exists
 	existing, err := service.Users.Labels.Get("me", labelID).Do()
 	if err != nil {
@@ -317,6 +324,7 @@ func runLabelsUpdate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("Gmail API error: %w", err)
 	}
+	invalidateLabelCache(service)
 
 	// JSON output mode
 	if GetOutputFormat() == "json" {
@@ -349,6 +357,11 @@ func runLabelsDelete(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
+	// Accept a label name as well as an ID
+	if labelID, err = newLabelResolver(service).ResolveOne(labelID); err != nil {
+		return err
+	}
+
 	// First, verify the label exists and check if it's a system label
 	existing, err := service.Users.Labels.Get("me", labelID).Do()
 	if err != nil {
@@ -368,6 +381,7 @@ func runLabelsDelete(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("Gmail API error: %w", err)
 	}
+	invalidateLabelCache(service)
 
 	// JSON output mode
 	if GetOutputFormat() == "json" {
@@ -384,3 +398,51 @@ func runLabelsDelete(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Label deleted: %s\n", labelID)
 	return nil
 }
+
+// listLabels returns every label in the authenticated user's mailbox.
+func listLabels(service *gmail.Service) ([]*gmail.Label, error) {
+	resp, err := service.Users.Labels.List("me").Do()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Labels, nil
+}
+
+// resolveLabelIDs maps each label name or ID in names to its label ID.
+// Names are matched case-insensitively, including nested "Parent/Child"
+// paths. System and user label IDs are accepted as-is, so labels may be nil
+// when names holds only IDs. Unknown names fail with suggestions.
+func resolveLabelIDs(labels []*gmail.Label, names []string) ([]string, error) {
+	var ids []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := ""
+		normalized := normalizeLabelName(name)
+		for _, label := range labels {
+			if label.Id == name || normalizeLabelName(label.Name) == normalized {
+				found = label.Id
+				break
+			}
+		}
+		if found == "" && isLabelID(name) {
+			found = name
+		}
+		if found == "" {
+			return nil, &labelNotFoundError{name: name, suggestions: suggestLabels(labels, name)}
+		}
+		ids = append(ids, found)
+	}
+	return ids, nil
+}
+
+// labelNamesByID returns a map from label ID to display name.
+func labelNamesByID(labels []*gmail.Label) map[string]string {
+	names := make(map[string]string, len(labels))
+	for _, label := range labels {
+		names[label.Id] = label.Name
+	}
+	return names
+}
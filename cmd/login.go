@@ -22,13 +22,24 @@ This command opens your browser to complete the Google OAuth2 consent flow
 using PKCE for security. After authentication, a token is saved locally so
 subsequent commands work without needing to log in again.
 
+By default gsuite asks for access to read, send and organize mail, change
+Gmail settings and manage calendar events. Use --scopes full to also grant
+full mail access, which permanent deletion ('messages batch-delete' and
+'threads delete') needs; log in again without it to give that access up.
+
 Requires OAuth2 client credentials via GOOGLE_CREDENTIALS env var (raw JSON)
 or GOOGLE_APPLICATION_CREDENTIALS env var (file path).`,
 	Example: `  # Login (opens browser)
-  gsuite login`,
+  gsuite login
+
+  # Login with full mail access, for permanent deletion
+  gsuite login --scopes full`,
 	RunE: runLogin,
 }
 
+// loginCmd flags
+var loginScopes string
+
 // logoutCmd represents the logout command
 var logoutCmd = &cobra.Command{
 	Use:   "logout [email]",
@@ -47,9 +58,20 @@ becomes active. Use 'gsuite accounts list' to see remaining accounts.`,
 func init() {
 	rootCmd.AddCommand(loginCmd)
 	rootCmd.AddCommand(logoutCmd)
+
+	loginCmd.Flags().StringVar(&loginScopes, "scopes", "default", "Access to request: default, or full to also allow permanent deletion")
 }
 
 func runLogin(cmd *cobra.Command, args []string) error {
+	var extraScopes []string
+	switch loginScopes {
+	case "default":
+	case "full":
+		extraScopes = auth.FullMailScopes
+	default:
+		return fmt.Errorf("invalid --scopes %q: must be default or full", loginScopes)
+	}
+
 	credJSON, err := auth.LoadCredentials()
 	if err != nil {
 		return fmt.Errorf("no credentials found: %w", err)
@@ -57,7 +79,7 @@ func runLogin(cmd *cobra.Command, args []string) error {
 
 	ctx := context.Background()
 
-	email, err := auth.Login(ctx, credJSON)
+	email, err := auth.Login(ctx, credJSON, extraScopes...)
 	if err != nil {
 		return err
 	}
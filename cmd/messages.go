@@ -18,10 +18,16 @@ var (
 	labelIDs   string
 	query      string
 
+	messagesListPages pageOptions
+
 	// messagesModifyCmd flags
 	addLabels    string
 	removeLabels string
 
+	// messagesGetCmd flags
+	messagesGetRaw  bool
+	messagesGetHTML bool
+
 	// messagesGetAttachmentCmd flags
 	attachmentOutput string
 )
@@ -32,6 +38,9 @@ type attachmentInfo struct {
 	MimeType     string
 	Size         int64
 	AttachmentId string
+	// Data holds the base64url content for small parts that Gmail
+	// returns inline instead of via an attachment ID.
+	Data string
 }
 
 // messagesCmd represents the messages command group
@@ -50,7 +59,14 @@ var messagesListCmd = &cobra.Command{
 	Long: `List messages in the authenticated user's Gmail mailbox.
 
 Supports filtering by labels, search query, and limiting results.
-Returns message ID, thread ID, and snippet for each message.`,
+Returns message ID, thread ID, and snippet for each message.
+
+Only one page is fetched by default. Pass the printed next page token to
+--page-token to continue, use --all to fetch every page, or --limit N to
+fetch pages until N messages are collected. JSON output is an array of
+messages, and the next page token goes to stderr; with any of these flags it
+is an object with "messages" and "next_page_token" instead. Scripts that page
+should always pass one: --page-token "" fetches the first page that way.`,
 	Example: `  # List last 10 messages
   gsuite messages list
 
@@ -61,7 +77,16 @@ Returns message ID, thread ID, and snippet for each message.`,
   gsuite messages list -q "from:example@gmail.com subject:important"
 
   # List unread inbox messages
-  gsuite messages list --label-ids INBOX,UNREAD`,
+  gsuite messages list --label-ids INBOX,UNREAD
+
+  # First page as {"messages": [...], "next_page_token": ...}
+  gsuite messages list --page-token "" -f json
+
+  # Continue from a previous page
+  gsuite messages list --page-token 09876543210
+
+  # Fetch up to 2000 messages across pages
+  gsuite messages list -q "older_than:1y" --limit 2000`,
 	RunE: runMessagesList,
 }
 
@@ -72,9 +97,20 @@ var messagesGetCmd = &cobra.Command{
 	Long: `Retrieve and display a specific Gmail message by its ID.
 
 Displays the message headers (From, To, Subject, Date) and body content.
-Prefers plain text body, falls back to snippet if not available.`,
+Prefers the plain text body. Messages without one show their HTML body
+converted to text, with link targets listed as numbered footnotes; the
+snippet is shown if there is no body at all.
+
+--raw prints the message's complete RFC 822 source and --html prints its
+HTML part as is, both regardless of --format.`,
 	Example: `  # Get a specific message
-  gsuite messages get 18d5a1b2c3d4e5f6`,
+  gsuite messages get 18d5a1b2c3d4e5f6
+
+  # Save the original source
+  gsuite messages get 18d5a1b2c3d4e5f6 --raw > message.eml
+
+  # Open the HTML version in a browser
+  gsuite messages get 18d5a1b2c3d4e5f6 --html > message.html`,
 	Args: cobra.ExactArgs(1),
 	RunE: runMessagesGet,
 }
@@ -86,15 +122,17 @@ var messagesModifyCmd = &cobra.Command{
 	Long: `Add or remove labels from a Gmail message.
 
 At least one of --add-labels or --remove-labels is required.
-Labels can be system labels (INBOX, UNREAD, STARRED, etc.) or user-created label IDs.`,
+Labels can be system labels (INBOX, UNREAD, STARRED, etc.), user label IDs,
+or label names matched case-insensitively, including nested "Parent/Child"
+names.`,
 	Example: `  # Mark message as read (remove UNREAD label)
   gsuite messages modify <id> --remove-labels UNREAD
 
   # Archive message (remove INBOX label)
   gsuite messages modify <id> --remove-labels INBOX
 
-  # Add custom label
-  gsuite messages modify <id> --add-labels Label_123
+  # Add custom label by name
+  gsuite messages modify <id> --add-labels "Work/Clients"
 
   # Star a message
   gsuite messages modify <id> --add-labels STARRED
@@ -137,19 +175,33 @@ func init() {
 	messagesCmd.AddCommand(messagesGetAttachmentCmd)
 
 	// messagesListCmd flags
-	messagesListCmd.Flags().Int64VarP(&maxResults, "max-results", "n", 10, "Maximum number of messages to return (max 500)")
-	messagesListCmd.Flags().StringVar(&labelIDs, "label-ids", "", "Comma-separated label IDs to filter by (e.g., INBOX,UNREAD)")
+	messagesListCmd.Flags().Int64VarP(&maxResults, "max-results", "n", 10, "Maximum number of messages per page (max 500)")
+	messagesListCmd.Flags().StringVar(&labelIDs, "label-ids", "", "Comma-separated label names or IDs to filter by (e.g., INBOX,UNREAD)")
 	messagesListCmd.Flags().StringVarP(&query, "query", "q", "", "Gmail search query string")
+	addPageFlags(messagesListCmd, &messagesListPages)
+	addConcurrencyFlag(messagesListCmd)
+
+	// messagesGetCmd flags
+	messagesGetCmd.Flags().BoolVar(&messagesGetRaw, "raw", false, "Print the raw RFC 822 source")
+	messagesGetCmd.Flags().BoolVar(&messagesGetHTML, "html", false, "Print the HTML part")
+	messagesGetCmd.MarkFlagsMutuallyExclusive("raw", "html")
 
 	// messagesModifyCmd flags
-	messagesModifyCmd.Flags().StringVar(&addLabels, "add-labels", "", "Comma-separated label IDs to add (e.g., STARRED,Label_123)")
-	messagesModifyCmd.Flags().StringVar(&removeLabels, "remove-labels", "", "Comma-separated label IDs to remove (e.g., UNREAD,INBOX)")
+	messagesModifyCmd.Flags().StringVar(&addLabels, "add-labels", "", "Comma-separated label names or IDs to add (e.g., STARRED,Receipts)")
+	messagesModifyCmd.Flags().StringVar(&removeLabels, "remove-labels", "", "Comma-separated label names or IDs to remove (e.g., UNREAD,INBOX)")
 
 	// messagesGetAttachmentCmd flags
 	messagesGetAttachmentCmd.Flags().StringVarP(&attachmentOutput, "output", "o", "", "Output file path (defaults to attachment filename)")
 }
 
 func runMessagesList(cmd *cobra.Command, args []string) error {
+	if err := messagesListPages.validate(); err != nil {
+		return err
+	}
+	if err := validateConcurrency(detailConcurrency); err != nil {
+		return err
+	}
+
 	ctx := context.Background()
 
 	service, err := auth.NewGmailService(ctx, GetAccountEmail())
@@ -160,16 +212,13 @@ func runMessagesList(cmd *cobra.Command, args []string) error {
 	// Build the list request
 	listCall := service.Users.Messages.List("me")
 
-	// Apply max results (cap at 500)
-	if maxResults > 500 {
-		maxResults = 500
-	}
-	listCall.MaxResults(maxResults)
-
 	// Apply label filter if provided
 	if labelIDs != "" {
-		labels := strings.Split(labelIDs, ",")
-		listCall.LabelIds(labels...)
+		ids, err := newLabelResolver(service).Resolve(splitCommaList(labelIDs))
+		if err != nil {
+			return err
+		}
+		listCall.LabelIds(ids...)
 	}
 
 	// Apply search query if provided
@@ -177,20 +226,16 @@ func runMessagesList(cmd *cobra.Command, args []string) error {
 		listCall.Q(query)
 	}
 
-	// Execute the request
-	resp, err := listCall.Do()
+	// Execute the request, following pages if requested
+	messages, nextPageToken, _, err := listMessagePages(ctx, listCall, &messagesListPages, maxResults)
 	if err != nil {
 		return fmt.Errorf("Gmail API error: %w", err)
 	}
 
-	// Check if no messages found
-	if len(resp.Messages) == 0 {
-		if GetOutputFormat() == "json" {
-			return outputJSON([]struct{}{})
-		}
-		fmt.Println("No messages found.")
-		return nil
-	}
+	// Fetch message details in parallel, preserving list order
+	details, detailErrs := fetchConcurrently(len(messages), detailConcurrency, func(i int) (*gmail.Message, error) {
+		return service.Users.Messages.Get("me", messages[i].Id).Format("metadata").Do()
+	})
 
 	// JSON output mode
 	if GetOutputFormat() == "json" {
@@ -199,32 +244,35 @@ func runMessagesList(cmd *cobra.Command, args []string) error {
 			ThreadID string `json:"thread_id"`
 			Snippet  string `json:"snippet"`
 		}
-		var results []messageListItem
-		for _, msg := range resp.Messages {
-			detail, err := service.Users.Messages.Get("me", msg.Id).Format("metadata").Do()
-			if err != nil {
+		results := []messageListItem{}
+		for i, msg := range messages {
+			if detailErrs[i] != nil {
 				results = append(results, messageListItem{ID: msg.Id, ThreadID: msg.ThreadId})
 				continue
 			}
 			results = append(results, messageListItem{
 				ID:       msg.Id,
 				ThreadID: msg.ThreadId,
-				Snippet:  detail.Snippet,
+				Snippet:  details[i].Snippet,
 			})
 		}
-		return outputJSON(results)
+		return outputListJSON(cmd, "messages", results, nextPageToken)
+	}
+
+	// Check if no messages found
+	if len(messages) == 0 {
+		fmt.Println("No messages found.")
+		return nil
 	}
 
 	// Print results (text mode)
-	fmt.Printf("Messages (%d):\n\n", len(resp.Messages))
-	for _, msg := range resp.Messages {
-		// Get message details for snippet
-		detail, err := service.Users.Messages.Get("me", msg.Id).Format("metadata").Do()
-		if err != nil {
+	fmt.Printf("Messages (%d):\n\n", len(messages))
+	for i, msg := range messages {
+		if detailErrs[i] != nil {
 			fmt.Printf("ID: %s  Thread: %s  (error fetching details)\n", msg.Id, msg.ThreadId)
 			continue
 		}
-		snippet := detail.Snippet
+		snippet := details[i].Snippet
 		if len(snippet) > 80 {
 			snippet = snippet[:80] + "..."
 		}
@@ -232,8 +280,8 @@ func runMessagesList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Indicate if more results are available
-	if resp.NextPageToken != "" {
-		fmt.Println("More results available (pagination token exists)")
+	if nextPageToken != "" {
+		fmt.Println(nextPageHint(nextPageToken))
 	}
 
 	return nil
@@ -249,22 +297,47 @@ func runMessagesGet(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
+	if messagesGetRaw {
+		msg, err := service.Users.Messages.Get("me", messageID).Format("raw").Do()
+		if err != nil {
+			return fmt.Errorf("Gmail API error: %w", err)
+		}
+		raw, err := decodeRawMessage(msg.Raw)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(raw)
+		return err
+	}
+
 	// Get the message with full format
 	msg, err := service.Users.Messages.Get("me", messageID).Format("full").Do()
 	if err != nil {
 		return fmt.Errorf("Gmail API error: %w", err)
 	}
 
+	if messagesGetHTML {
+		htmlBody := extractHTMLBody(msg)
+		if htmlBody == "" {
+			return fmt.Errorf("message %s has no HTML part", messageID)
+		}
+		fmt.Print(htmlBody)
+		if !strings.HasSuffix(htmlBody, "\n") {
+			fmt.Println()
+		}
+		return nil
+	}
+
 	// Extract headers
 	var from, to, subject, date string
 	for _, header := range msg.Payload.Headers {
 		switch header.Name {
 		case "From":
-			from = header.Value
+			from = decodeHeader(header.Value)
 		case "To":
-			to = header.Value
+			to = decodeHeader(header.Value)
 		case "Subject":
-			subject = header.Value
+			subject = decodeHeader(header.Value)
 		case "Date":
 			date = header.Value
 		}
@@ -272,6 +345,9 @@ func runMessagesGet(cmd *cobra.Command, args []string) error {
 
 	// Extract body content
 	body := extractBody(msg)
+	if body == "" {
+		body = htmlBodyText(msg.Payload)
+	}
 	if body == "" {
 		body = msg.Snippet
 	}
@@ -357,7 +433,7 @@ func extractBody(msg *gmail.Message) string {
 
 	// Check if the payload itself has body data
 	if msg.Payload.MimeType == "text/plain" && msg.Payload.Body != nil && msg.Payload.Body.Data != "" {
-		return decodeBase64URL(msg.Payload.Body.Data)
+		return partText(msg.Payload)
 	}
 
 	// Search through parts
@@ -368,7 +444,7 @@ func extractBody(msg *gmail.Message) string {
 func findPlainTextPart(parts []*gmail.MessagePart) string {
 	for _, part := range parts {
 		if part.MimeType == "text/plain" && part.Body != nil && part.Body.Data != "" {
-			return decodeBase64URL(part.Body.Data)
+			return partText(part)
 		}
 		// Recurse into nested parts (for multipart messages)
 		if len(part.Parts) > 0 {
@@ -380,6 +456,59 @@ func findPlainTextPart(parts []*gmail.MessagePart) string {
 	return ""
 }
 
+// htmlBodyText returns the text/html body of payload rendered as plain text,
+// or "" if it has none.
+func htmlBodyText(payload *gmail.MessagePart) string {
+	if payload == nil {
+		return ""
+	}
+	htmlBody := extractHTMLBody(&gmail.Message{Payload: payload})
+	if htmlBody == "" {
+		return ""
+	}
+	return htmlToText(htmlBody)
+}
+
+// headerValue returns the value of the first header matching name
+// (case-insensitive), or "" if it is not present.
+func headerValue(headers []*gmail.MessagePartHeader, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// extractHTMLBody extracts the text/html body from a message, or "" if none.
+func extractHTMLBody(msg *gmail.Message) string {
+	if msg.Payload == nil {
+		return ""
+	}
+
+	if msg.Payload.MimeType == "text/html" && msg.Payload.Body != nil && msg.Payload.Body.Data != "" {
+		return partText(msg.Payload)
+	}
+
+	return findPartByMimeType(msg.Payload.Parts, "text/html")
+}
+
+// findPartByMimeType recursively searches MIME parts for the first non-attachment
+// part of the given type and returns its decoded content.
+func findPartByMimeType(parts []*gmail.MessagePart, mimeType string) string {
+	for _, part := range parts {
+		if part.MimeType == mimeType && part.Filename == "" && part.Body != nil && part.Body.Data != "" {
+			return partText(part)
+		}
+		if len(part.Parts) > 0 {
+			if content := findPartByMimeType(part.Parts, mimeType); content != "" {
+				return content
+			}
+		}
+	}
+	return ""
+}
+
 // decodeBase64URL decodes a base64url-encoded string.
 func decodeBase64URL(encoded string) string {
 	decoded, err := base64.URLEncoding.DecodeString(encoded)
@@ -411,26 +540,15 @@ func runMessagesModify(cmd *cobra.Command, args []string) error {
 	// Build the modify request
 	modifyReq := &gmail.ModifyMessageRequest{}
 
-	// Parse and set labels to add
-	var addLabelsList []string
-	if addLabels != "" {
-		addLabelsList = strings.Split(addLabels, ",")
-		// Trim whitespace from each label
-		for i, label := range addLabelsList {
-			addLabelsList[i] = strings.TrimSpace(label)
-		}
-		modifyReq.AddLabelIds = addLabelsList
+	// Resolve label names to IDs
+	addLabelsList := splitCommaList(addLabels)
+	removeLabelsList := splitCommaList(removeLabels)
+	resolver := newLabelResolver(service)
+	if modifyReq.AddLabelIds, err = resolver.Resolve(addLabelsList); err != nil {
+		return err
 	}
-
-	// Parse and set labels to remove
-	var removeLabelsList []string
-	if removeLabels != "" {
-		removeLabelsList = strings.Split(removeLabels, ",")
-		// Trim whitespace from each label
-		for i, label := range removeLabelsList {
-			removeLabelsList[i] = strings.TrimSpace(label)
-		}
-		modifyReq.RemoveLabelIds = removeLabelsList
+	if modifyReq.RemoveLabelIds, err = resolver.Resolve(removeLabelsList); err != nil {
+		return err
 	}
 
 	// Execute the modify request
@@ -489,6 +607,7 @@ func findAttachments(parts []*gmail.MessagePart) []attachmentInfo {
 				MimeType:     part.MimeType,
 				Size:         part.Body.Size,
 				AttachmentId: part.Body.AttachmentId,
+				Data:         part.Body.Data,
 			})
 		}
 		// Recurse into nested parts
@@ -499,6 +618,32 @@ func findAttachments(parts []*gmail.MessagePart) []attachmentInfo {
 	return attachments
 }
 
+// decodeAttachmentData decodes base64url attachment data, with or without padding.
+func decodeAttachmentData(data string) ([]byte, error) {
+	decoded, err := base64.URLEncoding.DecodeString(data)
+	if err != nil {
+		// Try without padding (RawURLEncoding)
+		decoded, err = base64.RawURLEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode attachment data: %w", err)
+		}
+	}
+	return decoded, nil
+}
+
+// downloadAttachment returns the decoded content of an attachment, fetching it
+// with Users.Messages.Attachments.Get unless Gmail returned it inline.
+func downloadAttachment(service *gmail.Service, messageID string, att attachmentInfo) ([]byte, error) {
+	if att.AttachmentId == "" {
+		return decodeAttachmentData(att.Data)
+	}
+	body, err := service.Users.Messages.Attachments.Get("me", messageID, att.AttachmentId).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download attachment %s: %w", att.Filename, err)
+	}
+	return decodeAttachmentData(body.Data)
+}
+
 func runMessagesGetAttachment(cmd *cobra.Command, args []string) error {
 	messageID := args[0]
 	attachmentID := args[1]
@@ -517,13 +662,9 @@ func runMessagesGetAttachment(cmd *cobra.Command, args []string) error {
 	}
 
 	// Decode the attachment data (base64url encoded)
-	decoded, err := base64.URLEncoding.DecodeString(att.Data)
+	decoded, err := decodeAttachmentData(att.Data)
 	if err != nil {
-		// Try without padding (RawURLEncoding)
-		decoded, err = base64.RawURLEncoding.DecodeString(att.Data)
-		if err != nil {
-			return fmt.Errorf("failed to decode attachment data: %w", err)
-		}
+		return err
 	}
 
 	// Determine output filename
@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+// batchModifyLimit is the most message IDs BatchModify and BatchDelete accept per call.
+const batchModifyLimit = 1000
+
+// batchOptions holds the flags shared by the query-based batch commands.
+type batchOptions struct {
+	query  string
+	dryRun bool
+	yes    bool
+}
+
+var (
+	// messagesBatchModifyCmd flags
+	batchModifyOpts         batchOptions
+	batchModifyAddLabels    string
+	batchModifyRemoveLabels string
+
+	// messagesBatchDeleteCmd, messagesTrashCmd and messagesUntrashCmd flags
+	batchDeleteOpts  batchOptions
+	batchTrashOpts   batchOptions
+	batchUntrashOpts batchOptions
+)
+
+// messagesBatchModifyCmd represents the messages batch-modify command
+var messagesBatchModifyCmd = &cobra.Command{
+	Use:   "batch-modify",
+	Short: "Add or remove labels on every message matching a query",
+	Long: `Add or remove labels on every message matching a Gmail search query.
+
+All pages of matches are collected first, then labels are changed in batches
+of 1000 messages. Use --dry-run to see how many messages match; without
+--dry-run, --yes is required to apply the change.
+
+Labels can be given by name or ID.`,
+	Example: `  # Preview how many newsletters would be archived
+  gsuite messages batch-modify -q "from:news@example.com" --remove-labels INBOX --dry-run
+
+  # Archive and mark read every newsletter
+  gsuite messages batch-modify -q "from:news@example.com" --remove-labels INBOX,UNREAD --yes
+
+  # Label every invoice
+  gsuite messages batch-modify -q "subject:invoice has:attachment" --add-labels Receipts --yes`,
+	Args: cobra.NoArgs,
+	RunE: runMessagesBatchModify,
+}
+
+// messagesBatchDeleteCmd represents the messages batch-delete command
+var messagesBatchDeleteCmd = &cobra.Command{
+	Use:   "batch-delete",
+	Short: "Permanently delete every message matching a query",
+	Long: `Permanently delete every message matching a Gmail search query,
+including matches in Spam and Trash. Deleted messages cannot be recovered;
+use 'messages trash' to move messages to the trash instead.
+
+Use --dry-run to see how many messages match; without --dry-run, --yes is
+required.
+
+Permanent deletion needs full mail access, which is only granted by
+'gsuite login --scopes full'; without it the command fails before deleting
+anything.`,
+	Example: `  # Preview
+  gsuite messages batch-delete -q "in:trash older_than:30d" --dry-run
+
+  # Delete
+  gsuite messages batch-delete -q "in:trash older_than:30d" --yes`,
+	Args: cobra.NoArgs,
+	RunE: runMessagesBatchDelete,
+}
+
+// messagesTrashCmd represents the messages trash command
+var messagesTrashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Move every message matching a query to the trash",
+	Long: `Move every message matching a Gmail search query to the trash.
+Trashed messages are deleted by Gmail after 30 days.
+
+Use --dry-run to see how many messages match; without --dry-run, --yes is
+required.`,
+	Example: `  # Trash old promotions
+  gsuite messages trash -q "category:promotions older_than:1y" --yes`,
+	Args: cobra.NoArgs,
+	RunE: runMessagesTrash,
+}
+
+// messagesUntrashCmd represents the messages untrash command
+var messagesUntrashCmd = &cobra.Command{
+	Use:   "untrash",
+	Short: "Restore every trashed message matching a query",
+	Long: `Restore every message in the trash that matches a Gmail search query.
+
+Use --dry-run to see how many messages match; without --dry-run, --yes is
+required.`,
+	Example: `  # Restore messages trashed by mistake
+  gsuite messages untrash -q "from:boss@example.com" --yes`,
+	Args: cobra.NoArgs,
+	RunE: runMessagesUntrash,
+}
+
+func init() {
+	messagesCmd.AddCommand(messagesBatchModifyCmd)
+	messagesCmd.AddCommand(messagesBatchDeleteCmd)
+	messagesCmd.AddCommand(messagesTrashCmd)
+	messagesCmd.AddCommand(messagesUntrashCmd)
+
+	// messagesBatchModifyCmd flags
+	addBatchFlags(messagesBatchModifyCmd, &batchModifyOpts)
+	messagesBatchModifyCmd.Flags().StringVar(&batchModifyAddLabels, "add-labels", "", "Comma-separated label names or IDs to add")
+	messagesBatchModifyCmd.Flags().StringVar(&batchModifyRemoveLabels, "remove-labels", "", "Comma-separated label names or IDs to remove")
+
+	addBatchFlags(messagesBatchDeleteCmd, &batchDeleteOpts)
+	addBatchFlags(messagesTrashCmd, &batchTrashOpts)
+	addBatchFlags(messagesUntrashCmd, &batchUntrashOpts)
+}
+
+// addBatchFlags registers --query, --dry-run and --yes on cmd.
+func addBatchFlags(cmd *cobra.Command, opts *batchOptions) {
+	cmd.Flags().StringVarP(&opts.query, "query", "q", "", "Gmail search query selecting the messages (required)")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Only count the matching messages")
+	cmd.Flags().BoolVar(&opts.yes, "yes", false, "Confirm the change")
+	cmd.MarkFlagRequired("query")
+}
+
+// batchAction describes one of the batch commands.
+type batchAction struct {
+	// verb and past describe the action in messages, e.g. "trash" and "Trashed".
+	verb string
+	past string
+	// includeSpamTrash searches Spam and Trash as well as other mail.
+	includeSpamTrash bool
+	// scope, if set, is prepended to the query to narrow the search.
+	scope string
+	// apply changes one chunk of at most batchModifyLimit messages.
+	apply func(service *gmail.Service, ids []string) error
+	// handleError wraps an error from apply for display; nil means
+	// auth.HandleGmailError.
+	handleError func(error) error
+}
+
+func runMessagesBatchModify(cmd *cobra.Command, args []string) error {
+	addNames := splitCommaList(batchModifyAddLabels)
+	removeNames := splitCommaList(batchModifyRemoveLabels)
+	if len(addNames) == 0 && len(removeNames) == 0 {
+		return fmt.Errorf("at least one of --add-labels or --remove-labels required")
+	}
+
+	return runBatch(&batchModifyOpts, func(service *gmail.Service) (batchAction, error) {
+		resolver := newLabelResolver(service)
+		req := &gmail.BatchModifyMessagesRequest{}
+		var err error
+		if req.AddLabelIds, err = resolver.Resolve(addNames); err != nil {
+			return batchAction{}, err
+		}
+		if req.RemoveLabelIds, err = resolver.Resolve(removeNames); err != nil {
+			return batchAction{}, err
+		}
+		return batchAction{
+			verb: "modify",
+			past: "Modified",
+			apply: func(service *gmail.Service, ids []string) error {
+				req.Ids = ids
+				return service.Users.Messages.BatchModify("me", req).Do()
+			},
+		}, nil
+	})
+}
+
+func runMessagesBatchDelete(cmd *cobra.Command, args []string) error {
+	return runBatch(&batchDeleteOpts, func(service *gmail.Service) (batchAction, error) {
+		return batchAction{
+			verb:             "permanently delete",
+			past:             "Permanently deleted",
+			includeSpamTrash: true,
+			apply: func(service *gmail.Service, ids []string) error {
+				return service.Users.Messages.BatchDelete("me", &gmail.BatchDeleteMessagesRequest{Ids: ids}).Do()
+			},
+			handleError: auth.HandleDeleteError,
+		}, nil
+	})
+}
+
+func runMessagesTrash(cmd *cobra.Command, args []string) error {
+	return runBatch(&batchTrashOpts, func(service *gmail.Service) (batchAction, error) {
+		return batchAction{
+			verb:  "trash",
+			past:  "Trashed",
+			apply: batchModifyLabels([]string{"TRASH"}, nil),
+		}, nil
+	})
+}
+
+func runMessagesUntrash(cmd *cobra.Command, args []string) error {
+	return runBatch(&batchUntrashOpts, func(service *gmail.Service) (batchAction, error) {
+		return batchAction{
+			verb:             "restore",
+			past:             "Restored",
+			includeSpamTrash: true,
+			scope:            "in:trash",
+			apply:            batchModifyLabels(nil, []string{"TRASH"}),
+		}, nil
+	})
+}
+
+// batchModifyLabels returns a batchAction apply function that adds and
+// removes the given label IDs. Trashing and restoring are label changes too:
+// BatchModify accepts the TRASH label.
+func batchModifyLabels(add, remove []string) func(*gmail.Service, []string) error {
+	return func(service *gmail.Service, ids []string) error {
+		return service.Users.Messages.BatchModify("me", &gmail.BatchModifyMessagesRequest{
+			Ids:            ids,
+			AddLabelIds:    add,
+			RemoveLabelIds: remove,
+		}).Do()
+	}
+}
+
+// runBatch collects every message matching opts.query and applies the
+// action built by newAction to them in chunks. Nothing is changed with
+// --dry-run or without --yes.
+func runBatch(opts *batchOptions, newAction func(*gmail.Service) (batchAction, error)) error {
+	if strings.TrimSpace(opts.query) == "" {
+		return fmt.Errorf("--query must not be empty")
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	action, err := newAction(service)
+	if err != nil {
+		return err
+	}
+
+	query := opts.query
+	if action.scope != "" {
+		query = action.scope + " " + query
+	}
+	ids, err := listMatchingMessageIDs(ctx, service, query, nil, action.includeSpamTrash)
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	if !opts.dryRun && !opts.yes && len(ids) > 0 {
+		return fmt.Errorf("this will %s %d messages matching %q. Use --yes to confirm, or --dry-run to preview", action.verb, len(ids), opts.query)
+	}
+
+	handleError := action.handleError
+	if handleError == nil {
+		handleError = auth.HandleGmailError
+	}
+	processed := 0
+	if !opts.dryRun {
+		for _, chunk := range chunkStrings(ids, batchModifyLimit) {
+			if err := action.apply(service, chunk); err != nil {
+				return fmt.Errorf("%w (stopped after %d of %d messages)", handleError(err), processed, len(ids))
+			}
+			processed += len(chunk)
+			if GetVerbose() {
+				fmt.Fprintf(os.Stderr, "%s %d/%d messages\n", action.past, processed, len(ids))
+			}
+		}
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		type batchResult struct {
+			Query     string `json:"query"`
+			Matched   int    `json:"matched"`
+			Processed int    `json:"processed"`
+			DryRun    bool   `json:"dry_run"`
+		}
+		return outputJSON(batchResult{
+			Query:     opts.query,
+			Matched:   len(ids),
+			Processed: processed,
+			DryRun:    opts.dryRun,
+		})
+	}
+
+	switch {
+	case len(ids) == 0:
+		fmt.Printf("No messages match %q.\n", opts.query)
+	case opts.dryRun:
+		fmt.Printf("Would %s %d messages matching %q.\n", action.verb, len(ids), opts.query)
+		fmt.Println("Dry run: no changes made. Re-run with --yes to apply.")
+	default:
+		fmt.Printf("%s %d messages.\n", action.past, processed)
+	}
+	return nil
+}
+
+// listMatchingMessageIDs returns the IDs of every message matching query
+// and carrying all of labelIDs, following all pages.
+func listMatchingMessageIDs(ctx context.Context, service *gmail.Service, query string, labelIDs []string, includeSpamTrash bool) ([]string, error) {
+	call := service.Users.Messages.List("me").Q(query).IncludeSpamTrash(includeSpamTrash).
+		Fields("messages/id", "nextPageToken", "resultSizeEstimate")
+	if len(labelIDs) > 0 {
+		call.LabelIds(labelIDs...)
+	}
+	messages, _, _, err := listMessagePages(ctx, call, &pageOptions{all: true}, gmailMaxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.Id)
+	}
+	return ids, nil
+}
+
+// chunkStrings splits items into consecutive chunks of at most size items.
+func chunkStrings(items []string, size int) [][]string {
+	var chunks [][]string
+	for len(items) > size {
+		chunks = append(chunks, items[:size])
+		items = items[size:]
+	}
+	if len(items) > 0 {
+		chunks = append(chunks, items)
+	}
+	return chunks
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestChunkStrings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		count int
+		size  int
+		want  []int
+	}{
+		{name: "should return no chunks for no items", count: 0, size: 3, want: nil},
+		{name: "should keep a short list in one chunk", count: 2, size: 3, want: []int{2}},
+		{name: "should split exact multiples evenly", count: 6, size: 3, want: []int{3, 3}},
+		{name: "should put the remainder in a last chunk", count: 7, size: 3, want: []int{3, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			items := make([]string, tt.count)
+			for i := range items {
+				items[i] = fmt.Sprint(i)
+			}
+			var sizes []int
+			var joined []string
+			for _, chunk := range chunkStrings(items, tt.size) {
+				sizes = append(sizes, len(chunk))
+				joined = append(joined, chunk...)
+			}
+			if !slices.Equal(sizes, tt.want) || !slices.Equal(joined, items) {
+				t.Errorf("chunkStrings(%d items, %d) sizes = %v, want %v", tt.count, tt.size, sizes, tt.want)
+			}
+		})
+	}
+}
+
+func e2eNewsletter(i int) string {
+	return fmt.Sprintf("From: news@example.com\r\nTo: me@example.com\r\nSubject: Issue %d\r\n\r\nRead all about it.\r\n", i)
+}
+
+func TestE2EMessagesBatchModify(t *testing.T) {
+	srv := newE2EServer(t)
+	srv.AddLabel("Newsletters")
+	keep := srv.AddMessage(e2eInboxMessage, "INBOX", "UNREAD")
+	// More than one BatchModify call's worth of messages
+	for i := 0; i < batchModifyLimit+5; i++ {
+		srv.AddMessage(e2eNewsletter(i), "INBOX", "UNREAD")
+	}
+
+	out := mustRunCLI(t, "messages", "batch-modify", "-q", "from:news@example.com", "--remove-labels", "INBOX,UNREAD", "--dry-run")
+	if !strings.Contains(out, "Would modify 1005 messages") {
+		t.Errorf("batch-modify --dry-run output:\n%s", out)
+	}
+	if _, err := runCLI(t, "messages", "batch-modify", "-q", "from:news@example.com", "--remove-labels", "INBOX"); err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("batch-modify without --yes error = %v, want a --yes hint", err)
+	}
+	if got := len(srv.MessageIDs("INBOX")); got != 1006 {
+		t.Fatalf("unconfirmed batch-modify changed messages: INBOX has %d, want 1006", got)
+	}
+
+	out = mustRunCLI(t, "messages", "batch-modify", "-q", "from:news@example.com",
+		"--add-labels", "newsletters", "--remove-labels", "INBOX,UNREAD", "--yes", "-f", "json")
+	var result struct {
+		Matched   int `json:"matched"`
+		Processed int `json:"processed"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("batch-modify output is not JSON: %v\n%s", err, out)
+	}
+	if result.Matched != 1005 || result.Processed != 1005 {
+		t.Errorf("batch-modify result = %+v, want 1005 matched and processed", result)
+	}
+	if inbox := srv.MessageIDs("INBOX"); len(inbox) != 1 || inbox[0] != keep {
+		t.Errorf("after batch-modify INBOX = %v, want only %s", inbox, keep)
+	}
+	if unread := srv.MessageIDs("UNREAD"); len(unread) != 1 {
+		t.Errorf("after batch-modify %d messages are unread, want 1", len(unread))
+	}
+}
+
+func TestE2EMessagesTrashUntrashDelete(t *testing.T) {
+	srv := newE2EServer(t)
+	keep := srv.AddMessage(e2eInboxMessage, "INBOX")
+	for i := 0; i < 3; i++ {
+		srv.AddMessage(e2eNewsletter(i), "INBOX")
+	}
+
+	out := mustRunCLI(t, "messages", "trash", "-q", "from:news@example.com", "--yes")
+	if !strings.Contains(out, "Trashed 3 messages.") || len(srv.MessageIDs("TRASH")) != 3 {
+		t.Errorf("trash output = %q, TRASH has %d messages, want 3", out, len(srv.MessageIDs("TRASH")))
+	}
+
+	// Only trashed matches are restored
+	out = mustRunCLI(t, "messages", "untrash", "-q", `subject:"Issue 1"`, "--yes")
+	if !strings.Contains(out, "Restored 1 messages.") || len(srv.MessageIDs("TRASH")) != 2 {
+		t.Errorf("untrash output = %q, TRASH has %d messages, want 2", out, len(srv.MessageIDs("TRASH")))
+	}
+
+	out = mustRunCLI(t, "messages", "batch-delete", "-q", "in:trash", "--yes")
+	if !strings.Contains(out, "Permanently deleted 2 messages.") {
+		t.Errorf("batch-delete output = %q", out)
+	}
+	if all := srv.MessageIDs(""); len(all) != 2 || !slices.Contains(all, keep) {
+		t.Errorf("after batch-delete messages = %v, want %s and the restored one", all, keep)
+	}
+
+	out = mustRunCLI(t, "messages", "trash", "-q", "from:nobody@example.com")
+	if !strings.Contains(out, "No messages match") {
+		t.Errorf("trash with no matches output = %q", out)
+	}
+}
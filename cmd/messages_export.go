@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	// exportMboxFile is the mbox file written into the --out directory.
+	exportMboxFile = "export.mbox"
+	// exportIndexSuffix names the index that records which messages are
+	// complete in the mbox file, and where each one ends.
+	exportIndexSuffix = ".index"
+	// exportChunkSize is how many raw messages are fetched before writing.
+	exportChunkSize = 100
+)
+
+var (
+	// messagesExportCmd flags
+	messagesExportQuery            string
+	messagesExportLabelIDs         string
+	messagesExportFormat           string
+	messagesExportOut              string
+	messagesExportIncludeSpamTrash bool
+)
+
+// messagesExportCmd represents the messages export command
+var messagesExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export messages to an mbox file or .eml files",
+	Long: `Export every message matching a Gmail search query and/or labels as its
+original RFC 2822 source, including all parts and attachments.
+
+With --format mbox (the default), messages are appended oldest first to
+export.mbox in the --out directory, using mboxrd escaping: any line that
+starts with "From " after zero or more ">" gets one more ">". With
+--format eml, each message is written to <message-id>.eml.
+
+An interrupted export can be resumed by running the same command again.
+Messages already written are skipped: .eml files that exist are kept, and
+export.mbox.index records every complete message in the mbox file, so a
+partially written message is discarded and fetched again.
+
+This command's --format selects the file format, so -f and JSON output are
+not available here.`,
+	Example: `  # Archive a label to an mbox file
+  gsuite messages export --label-ids Legal --out ./archive/legal
+
+  # Export last month's invoices as individual .eml files
+  gsuite messages export -q "subject:invoice newer_than:1m" --format eml --out ./invoices
+
+  # Resume an interrupted export
+  gsuite messages export --label-ids Legal --out ./archive/legal`,
+	Args: cobra.NoArgs,
+	RunE: runMessagesExport,
+}
+
+func init() {
+	messagesCmd.AddCommand(messagesExportCmd)
+
+	// messagesExportCmd flags
+	messagesExportCmd.Flags().StringVarP(&messagesExportQuery, "query", "q", "", "Gmail search query selecting the messages to export")
+	messagesExportCmd.Flags().StringVar(&messagesExportLabelIDs, "label-ids", "", "Comma-separated label names or IDs the messages must have")
+	messagesExportCmd.Flags().StringVar(&messagesExportFormat, "format", "mbox", "File format: mbox or eml")
+	messagesExportCmd.Flags().StringVarP(&messagesExportOut, "out", "o", "", "Directory to write the export to (required)")
+	messagesExportCmd.Flags().BoolVar(&messagesExportIncludeSpamTrash, "include-spam-trash", false, "Include messages in Spam and Trash")
+	addConcurrencyFlag(messagesExportCmd)
+	messagesExportCmd.MarkFlagRequired("out")
+}
+
+// messageExporter writes exported messages to disk.
+type messageExporter interface {
+	// exported reports whether an earlier run already wrote the message.
+	exported(id string) bool
+	// write stores one message given its metadata and RFC 2822 source.
+	write(msg *gmail.Message, raw []byte) error
+	// path returns the file or directory the messages are written to.
+	path() string
+	Close() error
+}
+
+func runMessagesExport(cmd *cobra.Command, args []string) error {
+	if messagesExportFormat != "mbox" && messagesExportFormat != "eml" {
+		return fmt.Errorf("invalid --format: %s (must be mbox or eml)", messagesExportFormat)
+	}
+	if strings.TrimSpace(messagesExportQuery) == "" && messagesExportLabelIDs == "" {
+		return fmt.Errorf("--query or --label-ids is required")
+	}
+	if err := validateConcurrency(detailConcurrency); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	labelIDs, err := newLabelResolver(service).Resolve(splitCommaList(messagesExportLabelIDs))
+	if err != nil {
+		return err
+	}
+	ids, err := listMatchingMessageIDs(ctx, service, messagesExportQuery, labelIDs, messagesExportIncludeSpamTrash)
+	if err != nil {
+		return fmt.Errorf("Gmail API error: %w", err)
+	}
+	// Gmail lists newest first; archives read best oldest first.
+	slices.Reverse(ids)
+
+	if err := os.MkdirAll(messagesExportOut, 0700); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	var exporter messageExporter
+	if messagesExportFormat == "eml" {
+		exporter = &emlExporter{dir: messagesExportOut}
+	} else if exporter, err = openMboxExporter(filepath.Join(messagesExportOut, exportMboxFile)); err != nil {
+		return err
+	}
+
+	written, skipped, err := exportMessages(service, exporter, ids)
+	if closeErr := exporter.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write %s: %w", exporter.path(), closeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("%w (exported %d of %d messages; re-run to resume)", err, written+skipped, len(ids))
+	}
+
+	if skipped > 0 {
+		fmt.Printf("Exported %d messages to %s (%d already exported)\n", written, exporter.path(), skipped)
+	} else {
+		fmt.Printf("Exported %d messages to %s\n", written, exporter.path())
+	}
+	return nil
+}
+
+// exportMessages fetches the raw source of every message in ids that
+// exporter does not have yet and writes it, keeping the order of ids. It
+// returns how many messages were written and how many were already exported.
+func exportMessages(service *gmail.Service, exporter messageExporter, ids []string) (int, int, error) {
+	var pending []string
+	for _, id := range ids {
+		if !exporter.exported(id) {
+			pending = append(pending, id)
+		}
+	}
+	skipped := len(ids) - len(pending)
+
+	written := 0
+	for _, chunk := range chunkStrings(pending, exportChunkSize) {
+		msgs, errs := fetchConcurrently(len(chunk), detailConcurrency, func(i int) (*gmail.Message, error) {
+			return service.Users.Messages.Get("me", chunk[i]).Format("raw").Do()
+		})
+		for i, msg := range msgs {
+			if err := errs[i]; err != nil {
+				var apiErr *googleapi.Error
+				if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+					fmt.Fprintf(os.Stderr, "Warning: message %s no longer exists, skipping\n", chunk[i])
+					continue
+				}
+				return written, skipped, fmt.Errorf("Gmail API error: %w", err)
+			}
+			raw, err := decodeRawMessage(msg.Raw)
+			if err != nil {
+				return written, skipped, fmt.Errorf("message %s: %w", msg.Id, err)
+			}
+			if err := exporter.write(msg, raw); err != nil {
+				return written, skipped, err
+			}
+			written++
+		}
+		if GetVerbose() {
+			fmt.Fprintf(os.Stderr, "Exported %d/%d messages\n", written+skipped, len(ids))
+		}
+	}
+	return written, skipped, nil
+}
+
+// decodeRawMessage decodes the base64url "raw" field of a message.
+func decodeRawMessage(data string) ([]byte, error) {
+	decoded, err := base64.URLEncoding.DecodeString(data)
+	if err != nil {
+		decoded, err = base64.RawURLEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode raw message: %w", err)
+		}
+	}
+	return decoded, nil
+}
+
+// emlExporter writes each message to <id>.eml in dir.
+type emlExporter struct {
+	dir string
+}
+
+func (e *emlExporter) file(id string) string {
+	return filepath.Join(e.dir, id+".eml")
+}
+
+func (e *emlExporter) exported(id string) bool {
+	_, err := os.Stat(e.file(id))
+	return err == nil
+}
+
+// write creates the file under a temporary name and renames it into place,
+// so an interrupted write never leaves a truncated .eml behind.
+func (e *emlExporter) write(msg *gmail.Message, raw []byte) error {
+	path := e.file(msg.Id)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func (e *emlExporter) path() string { return e.dir }
+
+func (e *emlExporter) Close() error { return nil }
+
+// mboxExporter appends messages to an mbox file. After each message, its ID
+// and the file offset where it ends are appended to the index, so a resumed
+// export knows which messages are complete and where to continue writing.
+type mboxExporter struct {
+	file   *os.File
+	index  *os.File
+	done   map[string]bool
+	offset int64
+}
+
+// openMboxExporter opens the mbox file at path for appending, discarding
+// anything written after the last message recorded in its index.
+func openMboxExporter(path string) (*mboxExporter, error) {
+	indexPath := path + exportIndexSuffix
+	done, offset, indexLen, err := readMboxIndex(indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	switch {
+	case indexLen < 0 && info.Size() > 0:
+		file.Close()
+		return nil, fmt.Errorf("%s exists but has no index %s; move it away or choose another --out", path, filepath.Base(indexPath))
+	case offset > info.Size():
+		file.Close()
+		return nil, fmt.Errorf("%s is shorter than its index records; remove both files to start over", path)
+	}
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to truncate %s: %w", path, err)
+	}
+	if _, err := file.Seek(offset, 0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to seek %s: %w", path, err)
+	}
+
+	index, err := os.OpenFile(indexPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err == nil {
+		// Drop a partially written last line.
+		if err = index.Truncate(max(indexLen, 0)); err == nil {
+			_, err = index.Seek(0, 2)
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", indexPath, err)
+	}
+	return &mboxExporter{file: file, index: index, done: done, offset: offset}, nil
+}
+
+// readMboxIndex reads the index at path. It returns the IDs of the complete
+// messages, the offset where the last one ends, and the length of the
+// index's complete lines, or -1 if there is no index.
+func readMboxIndex(path string) (map[string]bool, int64, int64, error) {
+	done := make(map[string]bool)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return done, 0, -1, nil
+		}
+		return nil, 0, 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var offset, length int64
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break // partially written line
+		}
+		id, value, ok := strings.Cut(string(data[:end]), " ")
+		n, err := strconv.ParseInt(value, 10, 64)
+		if !ok || id == "" || err != nil || n < offset {
+			return nil, 0, 0, fmt.Errorf("invalid index %s at byte %d", path, length)
+		}
+		done[id] = true
+		offset = n
+		length += int64(end + 1)
+		data = data[end+1:]
+	}
+	return done, offset, length, nil
+}
+
+func (e *mboxExporter) exported(id string) bool {
+	return e.done[id]
+}
+
+func (e *mboxExporter) write(msg *gmail.Message, raw []byte) error {
+	entry := mboxEntry(raw, time.UnixMilli(msg.InternalDate))
+	if _, err := e.file.Write(entry); err != nil {
+		return fmt.Errorf("failed to write %s: %w", e.file.Name(), err)
+	}
+	e.offset += int64(len(entry))
+	if _, err := fmt.Fprintf(e.index, "%s %d\n", msg.Id, e.offset); err != nil {
+		return fmt.Errorf("failed to write %s: %w", e.index.Name(), err)
+	}
+	e.done[msg.Id] = true
+	return nil
+}
+
+func (e *mboxExporter) path() string { return e.file.Name() }
+
+func (e *mboxExporter) Close() error {
+	err := e.file.Close()
+	if indexErr := e.index.Close(); err == nil {
+		err = indexErr
+	}
+	return err
+}
+
+// mboxEntry renders one message as an mbox entry: a "From " separator line,
+// the message with LF line endings and mboxrd escaping, and a blank line.
+func mboxEntry(raw []byte, received time.Time) []byte {
+	var b bytes.Buffer
+	b.WriteString(mboxFromLine(raw, received))
+	b.Write(mboxrdEscape(bytes.ReplaceAll(raw, []byte("\r\n"), []byte("\n"))))
+	if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+// mboxFromLine returns the separator line that starts an mbox entry:
+// "From <sender> <asctime date>". The sender is the Return-Path or From
+// address, or MAILER-DAEMON if neither can be parsed.
+func mboxFromLine(raw []byte, received time.Time) string {
+	sender := "MAILER-DAEMON"
+	if msg, err := mail.ReadMessage(bytes.NewReader(raw)); err == nil {
+		for _, name := range []string{"Return-Path", "From"} {
+			addr, err := mail.ParseAddress(msg.Header.Get(name))
+			if err == nil && addr.Address != "" && !strings.ContainsAny(addr.Address, " \t") {
+				sender = addr.Address
+				break
+			}
+		}
+	}
+	return fmt.Sprintf("From %s %s\n", sender, received.UTC().Format(time.ANSIC))
+}
+
+// mboxrdEscape prefixes every line matching ^>*From  with one more ">", so
+// the lines can't be mistaken for entry separators and readers can restore
+// them by removing one ">".
+func mboxrdEscape(data []byte) []byte {
+	var b bytes.Buffer
+	b.Grow(len(data))
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i+1]
+		}
+		if bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) {
+			b.WriteByte('>')
+		}
+		b.Write(line)
+		data = data[len(line):]
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMboxrdEscape(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "should leave ordinary lines alone", input: "Hello\nFromage\n", want: "Hello\nFromage\n"},
+		{name: "should escape a From line", input: "Hi\nFrom here on\n", want: "Hi\n>From here on\n"},
+		{name: "should escape an already quoted From line", input: ">>From the archive\n", want: ">>>From the archive\n"},
+		{name: "should not escape an indented From line", input: " From me\n", want: " From me\n"},
+		{name: "should escape a last line without a newline", input: "a\nFrom b", want: "a\n>From b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := string(mboxrdEscape([]byte(tt.input))); got != tt.want {
+				t.Errorf("mboxrdEscape(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMboxFromLine(t *testing.T) {
+	t.Parallel()
+
+	received := time.Date(2026, 1, 5, 9, 3, 4, 0, time.FixedZone("CET", 3600))
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "should use the From address", raw: "From: Alice <alice@example.com>\r\n\r\nHi\r\n", want: "From alice@example.com Mon Jan  5 08:03:04 2026\n"},
+		{name: "should prefer the Return-Path", raw: "Return-Path: <bounce@example.com>\r\nFrom: alice@example.com\r\n\r\nHi\r\n", want: "From bounce@example.com Mon Jan  5 08:03:04 2026\n"},
+		{name: "should fall back to MAILER-DAEMON", raw: "Subject: no sender\r\n\r\nHi\r\n", want: "From MAILER-DAEMON Mon Jan  5 08:03:04 2026\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := mboxFromLine([]byte(tt.raw), received); got != tt.want {
+				t.Errorf("mboxFromLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+const e2eQuotingMessage = "From: Bob <bob@example.com>\r\n" +
+	"To: me@example.com\r\n" +
+	"Subject: Minutes\r\n" +
+	"\r\n" +
+	"From the meeting:\r\n" +
+	">From the last one\r\n"
+
+func TestE2EMessagesExportMbox(t *testing.T) {
+	srv := newE2EServer(t)
+	legal := srv.AddLabel("Legal")
+	first := srv.AddMessage(e2eInboxMessage, "INBOX", legal)
+	second := srv.AddMessage(e2eQuotingMessage, "INBOX", legal)
+	srv.AddMessage(e2eNewsletter(1), "INBOX")
+	dir := t.TempDir()
+	mbox := filepath.Join(dir, exportMboxFile)
+
+	out := mustRunCLI(t, "messages", "export", "--label-ids", "Legal", "--out", dir)
+	if !strings.Contains(out, "Exported 2 messages to "+mbox) {
+		t.Errorf("messages export output = %q, want 2 messages exported", out)
+	}
+	data, err := os.ReadFile(mbox)
+	if err != nil {
+		t.Fatalf("reading mbox: %v", err)
+	}
+	got := string(data)
+	if strings.Count(got, "\nFrom ") != 1 || !strings.HasPrefix(got, "From alice@example.com ") {
+		t.Errorf("mbox does not hold two entries, oldest first:\n%s", got)
+	}
+	if !strings.Contains(got, "\n>From the meeting:\n>>From the last one\n\n") {
+		t.Errorf("mbox body is not mboxrd-escaped:\n%s", got)
+	}
+	if strings.Contains(got, "\r") || strings.Contains(got, "Issue 1") {
+		t.Errorf("mbox has CRLF line endings or an unlabeled message:\n%s", got)
+	}
+	index, err := os.ReadFile(mbox + exportIndexSuffix)
+	if err != nil {
+		t.Fatalf("reading index: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(index)), "\n"); len(lines) != 2 ||
+		!strings.HasPrefix(lines[0], first+" ") || !strings.HasPrefix(lines[1], second+" ") {
+		t.Errorf("index = %q, want one line per message in order", index)
+	}
+
+	// Simulate an export interrupted while writing a message: the partial
+	// entry has no index line and must be discarded on resume.
+	f, err := os.OpenFile(mbox, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("opening mbox: %v", err)
+	}
+	f.WriteString("From partial@example.com Mon Jan  5 08:03:04 2026\nSubject: cut off") //nolint:errcheck
+	f.Close()
+	srv.AddMessage(e2eNewsletter(2), legal)
+
+	out = mustRunCLI(t, "messages", "export", "--label-ids", "Legal", "--out", dir)
+	if !strings.Contains(out, "Exported 1 messages to "+mbox+" (2 already exported)") {
+		t.Errorf("resumed export output = %q, want 1 new and 2 skipped", out)
+	}
+	data, _ = os.ReadFile(mbox)
+	if got := string(data); strings.Contains(got, "cut off") || strings.Count(got, "\nFrom ") != 2 ||
+		!strings.HasSuffix(got, "Read all about it.\n\n") {
+		t.Errorf("resumed mbox = %q, want the partial entry replaced by the new message", got)
+	}
+
+	// An mbox without its index is not overwritten.
+	os.Remove(mbox + exportIndexSuffix)
+	if _, err := runCLI(t, "messages", "export", "--label-ids", "Legal", "--out", dir); err == nil || !strings.Contains(err.Error(), "has no index") {
+		t.Errorf("export over an unindexed mbox error = %v, want a no index error", err)
+	}
+}
+
+func TestE2EMessagesExportEML(t *testing.T) {
+	srv := newE2EServer(t)
+	id := srv.AddMessage(e2eInboxMessage, "INBOX")
+	dir := t.TempDir()
+
+	out := mustRunCLI(t, "messages", "export", "-q", "from:alice@example.com", "--format", "eml", "--out", dir)
+	if !strings.Contains(out, "Exported 1 messages") {
+		t.Errorf("messages export output = %q, want 1 message exported", out)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, id+".eml"))
+	if err != nil {
+		t.Fatalf("reading .eml: %v", err)
+	}
+	if string(data) != e2eInboxMessage {
+		t.Errorf(".eml = %q, want the original message source", data)
+	}
+
+	out = mustRunCLI(t, "messages", "export", "-q", "from:alice@example.com", "--format", "eml", "--out", dir)
+	if !strings.Contains(out, "Exported 0 messages to "+dir+" (1 already exported)") {
+		t.Errorf("second export output = %q, want the message skipped", out)
+	}
+
+	if _, err := runCLI(t, "messages", "export", "-q", "x", "--format", "pst", "--out", dir); err == nil || !strings.Contains(err.Error(), "invalid --format") {
+		t.Errorf("export with --format pst error = %v, want invalid --format", err)
+	}
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+var (
+	// messagesForwardCmd flags
+	forwardTo   string
+	forwardCc   string
+	forwardBcc  string
+	forwardBody string
+)
+
+// htmlBodyRegexp captures the content of an HTML document's <body> element.
+var htmlBodyRegexp = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+
+// messagesForwardCmd represents the messages forward command
+var messagesForwardCmd = &cobra.Command{
+	Use:   "forward <message-id>",
+	Short: "Forward a message with its attachments",
+	Long: `Forward a Gmail message to new recipients.
+
+The original plain text and HTML bodies are included below a
+"Forwarded message" block listing the original From, Date, Subject, To and Cc
+headers. Every attachment on the original message is downloaded and
+re-attached. Use --body to add a note above the forwarded content; it supports
+the same markdown formatting as 'send'.`,
+	Example: `  # Forward a message
+  gsuite messages forward 18d5a1b2c3d4e5f6 --to "colleague@example.com"
+
+  # Forward with a note and CC
+  gsuite messages forward 18d5a1b2c3d4e5f6 -t "team@example.com" --cc "lead@example.com" -b "FYI, see below."`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMessagesForward,
+}
+
+func init() {
+	messagesCmd.AddCommand(messagesForwardCmd)
+
+	messagesForwardCmd.Flags().StringVarP(&forwardTo, "to", "t", "", "Recipient email address (required)")
+	messagesForwardCmd.Flags().StringVar(&forwardCc, "cc", "", "CC recipients (comma-separated)")
+	messagesForwardCmd.Flags().StringVar(&forwardBcc, "bcc", "", "BCC recipients (comma-separated)")
+	messagesForwardCmd.Flags().StringVarP(&forwardBody, "body", "b", "", "Note to include above the forwarded message (markdown supported)")
+	messagesForwardCmd.MarkFlagRequired("to")
+}
+
+func runMessagesForward(cmd *cobra.Command, args []string) error {
+	messageID := args[0]
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	original, err := service.Users.Messages.Get("me", messageID).Format("full").Do()
+	if err != nil {
+		return fmt.Errorf("Gmail API error: %w", err)
+	}
+	if original.Payload == nil {
+		return fmt.Errorf("message has no payload: %s", messageID)
+	}
+
+	// Every original attachment is re-attached, downloaded one at a time as
+	// the message is uploaded.
+	var attachments []outgoingAttachment
+	for _, att := range findAttachments(original.Payload.Parts) {
+		attachments = append(attachments, outgoingAttachment{
+			Filename: att.Filename,
+			MimeType: att.MimeType,
+			Open: func() (io.ReadCloser, error) {
+				data, err := downloadAttachment(service, messageID, att)
+				if err != nil {
+					return nil, err
+				}
+				return io.NopCloser(bytes.NewReader(data)), nil
+			},
+		})
+	}
+
+	headers := original.Payload.Headers
+	originalPlain := extractBody(original)
+	if originalPlain == "" {
+		originalPlain = original.Snippet
+	}
+	plainBody, htmlBody := buildForwardBodies(interpretEscapes(forwardBody), headers, originalPlain, extractHTMLBody(original))
+
+	subject := forwardSubject(headerValue(headers, "Subject"))
+
+	sent, err := sendMessageUpload(service, "", func(w io.Writer) error {
+		return writeOutgoingMessage(w, forwardTo, subject, forwardCc, forwardBcc, plainBody, htmlBody, nil, attachments)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to forward message: %w", err)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		type forwardResult struct {
+			MessageID   string `json:"message_id"`
+			Attachments int    `json:"attachments"`
+		}
+		return outputJSON(forwardResult{
+			MessageID:   sent.Id,
+			Attachments: len(attachments),
+		})
+	}
+
+	fmt.Printf("Message forwarded successfully!\nMessage ID: %s\n", sent.Id)
+	if len(attachments) > 0 {
+		fmt.Printf("Attachments: %d\n", len(attachments))
+	}
+	return nil
+}
+
+// forwardSubject prefixes subject with "Fwd: " unless it is already a forward.
+func forwardSubject(subject string) string {
+	trimmed := strings.TrimSpace(subject)
+	lower := strings.ToLower(trimmed)
+	if strings.HasPrefix(lower, "fwd:") || strings.HasPrefix(lower, "fw:") {
+		return trimmed
+	}
+	return "Fwd: " + trimmed
+}
+
+// forwardHeaderLines returns the "Name: value" lines describing the original
+// message in the forwarded block, skipping headers that are absent. Encoded
+// words are decoded, since the block is part of the body.
+func forwardHeaderLines(headers []*gmail.MessagePartHeader) []string {
+	var lines []string
+	for _, name := range []string{"From", "Date", "Subject", "To", "Cc"} {
+		if v := decodedHeaderValue(headers, name); v != "" {
+			lines = append(lines, name+": "+v)
+		}
+	}
+	return lines
+}
+
+// buildForwardBodies returns the plain text and HTML bodies of a forward:
+// the optional note followed by a "Forwarded message" block and the original
+// content. If the original has no HTML part, its plain text is escaped instead.
+func buildForwardBodies(note string, headers []*gmail.MessagePartHeader, originalPlain, originalHTML string) (string, string) {
+	const separator = "---------- Forwarded message ---------"
+	headerLines := forwardHeaderLines(headers)
+
+	var plain strings.Builder
+	if note != "" {
+		plain.WriteString(note + "\n\n")
+	}
+	plain.WriteString(separator + "\n")
+	for _, line := range headerLines {
+		plain.WriteString(line + "\n")
+	}
+	plain.WriteString("\n" + originalPlain)
+
+	var htmlBuf strings.Builder
+	htmlBuf.WriteString("<!DOCTYPE html><html><body>")
+	if note != "" {
+		htmlBuf.WriteString(markdownToHTMLFragment(note))
+	}
+	htmlBuf.WriteString("<div>" + separator + "<br>\n")
+	for _, line := range headerLines {
+		htmlBuf.WriteString(html.EscapeString(line) + "<br>\n")
+	}
+	htmlBuf.WriteString("</div><br>\n")
+	if originalHTML != "" {
+		if m := htmlBodyRegexp.FindStringSubmatch(originalHTML); m != nil {
+			originalHTML = m[1]
+		}
+		htmlBuf.WriteString(originalHTML)
+	} else {
+		htmlBuf.WriteString(strings.ReplaceAll(html.EscapeString(originalPlain), "\n", "<br>\n"))
+	}
+	htmlBuf.WriteString("</body></html>")
+
+	return plain.String(), htmlBuf.String()
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestForwardSubject(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+	}{
+		{name: "should add Fwd prefix", subject: "Invoice", want: "Fwd: Invoice"},
+		{name: "should keep existing Fwd prefix", subject: "Fwd: Invoice", want: "Fwd: Invoice"},
+		{name: "should keep existing FW prefix", subject: "FW: Invoice", want: "FW: Invoice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := forwardSubject(tt.subject); got != tt.want {
+				t.Errorf("forwardSubject(%q) = %q, want %q", tt.subject, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildForwardBodies(t *testing.T) {
+	t.Parallel()
+	headers := []*gmail.MessagePartHeader{
+		{Name: "From", Value: "Alice <alice@example.com>"},
+		{Name: "Subject", Value: "Report"},
+		{Name: "To", Value: "me@example.com"},
+	}
+
+	t.Run("should include note, header block and original HTML body", func(t *testing.T) {
+		t.Parallel()
+		plain, htmlBody := buildForwardBodies("**FYI**", headers, "original text", "<html><body><p>original html</p></body></html>")
+
+		for _, want := range []string{"**FYI**", "---------- Forwarded message ---------", "From: Alice <alice@example.com>", "Subject: Report", "original text"} {
+			if !strings.Contains(plain, want) {
+				t.Errorf("plain body missing %q:\n%s", want, plain)
+			}
+		}
+		if strings.Contains(plain, "Cc:") {
+			t.Error("plain body should not contain absent Cc header")
+		}
+		for _, want := range []string{"<strong>FYI</strong>", "From: Alice &lt;alice@example.com&gt;", "<p>original html</p>"} {
+			if !strings.Contains(htmlBody, want) {
+				t.Errorf("html body missing %q:\n%s", want, htmlBody)
+			}
+		}
+		if strings.Count(htmlBody, "<body>") != 1 {
+			t.Errorf("html body should contain a single <body> element:\n%s", htmlBody)
+		}
+	})
+
+	t.Run("should escape plain text when original has no HTML", func(t *testing.T) {
+		t.Parallel()
+		_, htmlBody := buildForwardBodies("", headers, "a < b\nnext", "")
+		if !strings.Contains(htmlBody, "a &lt; b<br>\nnext") {
+			t.Errorf("html body should contain escaped plain text:\n%s", htmlBody)
+		}
+	})
+}
+
+func TestForwardHeaderLines(t *testing.T) {
+	t.Parallel()
+	headers := []*gmail.MessagePartHeader{
+		{Name: "From", Value: "=?UTF-8?Q?Ren=C3=A9e?= <renee@example.com>"},
+		{Name: "Subject", Value: "=?UTF-8?B?w5xiZXJzaWNodA==?="},
+	}
+	got := strings.Join(forwardHeaderLines(headers), "\n")
+	want := "From: Renée <renee@example.com>\nSubject: Übersicht"
+	if got != want {
+		t.Errorf("forwardHeaderLines() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteOutgoingMessage_OpenedAttachment(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := writeOutgoingMessage(&buf, "to@example.com", "Fwd: Hi", "", "", "plain", "<p>html</p>", nil, []outgoingAttachment{{
+		Filename: "notes.txt",
+		MimeType: "text/plain",
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("attached")), nil
+		},
+	}})
+	if err != nil {
+		t.Fatalf("writeOutgoingMessage() error = %v", err)
+	}
+	msg := buf.String()
+	for _, want := range []string{"multipart/mixed", "Subject: Fwd: Hi", `filename="notes.txt"`, "YXR0YWNoZWQ=", "<p>html</p>"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q", want)
+		}
+	}
+}
+
+func TestE2EMessagesForward(t *testing.T) {
+	srv := newE2EServer(t)
+	original := srv.AddMessage(e2eAttachmentMessage("billing@example.com", "Invoice", "invoice.pdf", "%PDF invoice"), "INBOX")
+
+	out := mustRunCLI(t, "messages", "forward", original, "--to", "accounts@example.com", "--body", "FYI")
+	if !strings.Contains(out, "Attachments: 1") {
+		t.Errorf("forward output = %q, want one attachment", out)
+	}
+	sent := srv.MessageIDs("SENT")
+	if len(sent) != 1 {
+		t.Fatalf("after forward, SENT has %d messages, want 1", len(sent))
+	}
+	raw, _ := srv.RawMessage(sent[0])
+	encoded := base64.StdEncoding.EncodeToString([]byte("%PDF invoice"))
+	for _, want := range []string{"To: accounts@example.com", "Subject: Fwd: Invoice", "From: billing@example.com", `filename="invoice.pdf"`, encoded} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("forwarded message missing %q:\n%s", want, raw)
+		}
+	}
+}
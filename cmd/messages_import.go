@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+const (
+	// importLogSuffix names the result log kept next to the imported source.
+	importLogSuffix = ".import.jsonl"
+	// importProgressEvery is how often progress is reported on stderr.
+	importProgressEvery = 100
+)
+
+var (
+	// messagesImportCmd flags
+	messagesImportLabelIDs           string
+	messagesImportNeverMarkSpam      bool
+	messagesImportProcessForCalendar bool
+	messagesImportInsert             bool
+	messagesImportDateSource         string
+	messagesImportLog                string
+)
+
+// messagesImportCmd represents the messages import command
+var messagesImportCmd = &cobra.Command{
+	Use:   "import <file.mbox|file.eml|dir>",
+	Short: "Import messages from an mbox file or .eml files",
+	Long: `Import messages into the mailbox from an mbox file (mboxrd escaping, as
+written by 'messages export'), a single .eml file, or a directory of .eml
+files, searched recursively.
+
+Messages are added with Users.Messages.Import, which scans them like
+delivered mail, without sending them. With --insert, Users.Messages.Insert
+is used instead, which skips scanning and classification. Imported messages
+only get the labels given with --label-ids; add INBOX to show them in the
+inbox, otherwise they are archived.
+
+The result for every message is appended to a log (by default the source
+path with ".import.jsonl" appended): one JSON object per line with the
+source, the SHA-256 of the message, and the new message ID or the error.
+Re-running the same import skips messages the log records as imported, so
+an interrupted or partly failed import can simply be run again.`,
+	Example: `  # Import a legacy archive into a label
+  gsuite messages import archive.mbox --label-ids "Archive/2019"
+
+  # Import a directory of .eml files into the inbox, keeping them out of spam
+  gsuite messages import ./export --label-ids INBOX,UNREAD --never-mark-spam
+
+  # Add messages without scanning or classification
+  gsuite messages import old.mbox --insert --label-ids Legacy`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMessagesImport,
+}
+
+func init() {
+	messagesCmd.AddCommand(messagesImportCmd)
+
+	// messagesImportCmd flags
+	messagesImportCmd.Flags().StringVar(&messagesImportLabelIDs, "label-ids", "", "Comma-separated label names or IDs to add to every imported message")
+	messagesImportCmd.Flags().BoolVar(&messagesImportNeverMarkSpam, "never-mark-spam", false, "Never send imported messages to Spam")
+	messagesImportCmd.Flags().BoolVar(&messagesImportProcessForCalendar, "process-for-calendar", false, "Add invitations in imported messages to the calendar")
+	messagesImportCmd.Flags().BoolVar(&messagesImportInsert, "insert", false, "Use messages.insert instead of messages.import (no scanning or classification)")
+	messagesImportCmd.Flags().StringVar(&messagesImportDateSource, "date-source", "dateHeader", "Where the message date comes from: dateHeader or receivedTime")
+	messagesImportCmd.Flags().StringVar(&messagesImportLog, "log", "", "Result log path (default: <source>.import.jsonl)")
+	messagesImportCmd.MarkFlagsMutuallyExclusive("insert", "never-mark-spam")
+	messagesImportCmd.MarkFlagsMutuallyExclusive("insert", "process-for-calendar")
+}
+
+// importRecord is one line of the import result log.
+type importRecord struct {
+	// Source is the file, and for mbox files the 1-based message number
+	// ("archive.mbox#12").
+	Source   string `json:"source"`
+	SHA256   string `json:"sha256"`
+	ID       string `json:"id,omitempty"`
+	ThreadID string `json:"thread_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// importSource yields the raw messages to import, one at a time.
+type importSource interface {
+	// next returns the next message and its source description, or io.EOF
+	// when there are no more.
+	next() (raw []byte, source string, err error)
+	Close() error
+}
+
+func runMessagesImport(cmd *cobra.Command, args []string) error {
+	path := args[0]
+	if messagesImportDateSource != "dateHeader" && messagesImportDateSource != "receivedTime" {
+		return fmt.Errorf("invalid --date-source: %s (must be dateHeader or receivedTime)", messagesImportDateSource)
+	}
+	logPath := messagesImportLog
+	if logPath == "" {
+		logPath = filepath.Clean(path) + importLogSuffix
+	}
+
+	source, err := openImportSource(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	imported, err := readImportLog(logPath)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	labelIDs, err := newLabelResolver(service).Resolve(splitCommaList(messagesImportLabelIDs))
+	if err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open import log: %w", err)
+	}
+	defer logFile.Close()
+
+	var added, skipped, failed int
+	for {
+		raw, src, err := source.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(raw)
+		record := importRecord{Source: src, SHA256: hex.EncodeToString(sum[:])}
+		if imported[record.SHA256] {
+			skipped++
+			continue
+		}
+
+		msg, err := importMessage(service, raw, labelIDs)
+		if err != nil {
+			record.Error = err.Error()
+			failed++
+			fmt.Fprintf(os.Stderr, "Warning: failed to import %s: %v\n", src, err)
+		} else {
+			record.ID, record.ThreadID = msg.Id, msg.ThreadId
+			imported[record.SHA256] = true
+			added++
+			if GetVerbose() {
+				fmt.Fprintf(os.Stderr, "Imported %s as %s\n", src, msg.Id)
+			}
+		}
+		if err := appendImportRecord(logFile, record); err != nil {
+			return err
+		}
+		if done := added + failed; done%importProgressEvery == 0 {
+			fmt.Fprintf(os.Stderr, "Processed %d messages (%d imported, %d failed)\n", done, added, failed)
+		}
+	}
+
+	if GetOutputFormat() == "json" {
+		type importResult struct {
+			Imported int    `json:"imported"`
+			Skipped  int    `json:"skipped"`
+			Failed   int    `json:"failed"`
+			Log      string `json:"log"`
+		}
+		if err := outputJSON(importResult{Imported: added, Skipped: skipped, Failed: failed, Log: logPath}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Imported %d messages (%d already imported, %d failed)\n", added, skipped, failed)
+		fmt.Printf("Results logged to %s\n", logPath)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d messages failed to import; see %s and re-run to retry them", failed, logPath)
+	}
+	return nil
+}
+
+// importMessage adds raw to the mailbox with messages.import, or with
+// messages.insert if --insert is set.
+func importMessage(service *gmail.Service, raw []byte, labelIDs []string) (*gmail.Message, error) {
+	msg := &gmail.Message{
+		Raw:      base64.URLEncoding.EncodeToString(raw),
+		LabelIds: labelIDs,
+	}
+	var result *gmail.Message
+	var err error
+	if messagesImportInsert {
+		result, err = service.Users.Messages.Insert("me", msg).
+			InternalDateSource(messagesImportDateSource).Do()
+	} else {
+		result, err = service.Users.Messages.Import("me", msg).
+			InternalDateSource(messagesImportDateSource).
+			NeverMarkSpam(messagesImportNeverMarkSpam).
+			ProcessForCalendar(messagesImportProcessForCalendar).Do()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Gmail API error: %w", err)
+	}
+	return result, nil
+}
+
+// readImportLog returns the SHA-256 sums the log at path records as
+// imported. A missing log is empty. A partially written last line, left by
+// an interrupted run, is removed so new records start on a line of their own.
+func readImportLog(path string) (map[string]bool, error) {
+	imported := make(map[string]bool)
+	err := readJSONLog(path, "import log", func(line []byte) error {
+		var record importRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		if record.ID != "" {
+			imported[record.SHA256] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return imported, nil
+}
+
+// readJSONLog calls fn with each line of the JSON lines log at path; what
+// names the log in errors. A missing log has no lines. A partially written
+// last line, left by an interrupted run, is removed so new records start on
+// a line of their own.
+func readJSONLog(path, what string, fn func(line []byte) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", what, err)
+	}
+
+	complete := data[:bytes.LastIndexByte(data, '\n')+1]
+	if len(complete) < len(data) {
+		if err := os.Truncate(path, int64(len(complete))); err != nil {
+			return fmt.Errorf("failed to repair %s: %w", what, err)
+		}
+	}
+	for i, line := range bytes.Split(complete, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("invalid %s %s line %d: %w", what, path, i+1, err)
+		}
+	}
+	return nil
+}
+
+// appendImportRecord writes record to the log as one JSON line.
+func appendImportRecord(w io.Writer, record importRecord) error {
+	return appendJSONLine(w, "import log", record)
+}
+
+// appendJSONLine writes v to the log w as one JSON line; what names the log
+// in errors.
+func appendJSONLine(w io.Writer, what string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	return nil
+}
+
+// openImportSource opens path as a directory of .eml files, a single .eml
+// file, or an mbox file.
+func openImportSource(path string) (importSource, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if info.IsDir() {
+		var files []string
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".eml") {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no .eml files found in %s", path)
+		}
+		sort.Strings(files)
+		return &emlSource{files: files}, nil
+	}
+	if strings.EqualFold(filepath.Ext(path), ".eml") {
+		return &emlSource{files: []string{path}}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return &mboxSource{file: f, reader: bufio.NewReader(f)}, nil
+}
+
+// emlSource reads one message from each of a list of .eml files.
+type emlSource struct {
+	files []string
+}
+
+func (s *emlSource) next() ([]byte, string, error) {
+	if len(s.files) == 0 {
+		return nil, "", io.EOF
+	}
+	path := s.files[0]
+	s.files = s.files[1:]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, path, nil
+}
+
+func (s *emlSource) Close() error { return nil }
+
+// mboxSource reads the messages of an mboxrd file one at a time, so large
+// archives are never held in memory whole.
+type mboxSource struct {
+	file   *os.File
+	reader *bufio.Reader
+	// count is the number of messages returned so far.
+	count int
+	// inMessage is set after a "From " separator line has been read, until
+	// the end of the file.
+	inMessage bool
+}
+
+func (s *mboxSource) next() ([]byte, string, error) {
+	var msg bytes.Buffer
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, "", fmt.Errorf("failed to read %s: %w", s.file.Name(), err)
+		}
+		switch {
+		case bytes.HasPrefix(line, []byte("From ")):
+			if s.inMessage {
+				// The separator ends this message and starts the next one.
+				return s.message(msg.Bytes())
+			}
+			s.inMessage = true
+		case s.inMessage:
+			if bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) {
+				line = line[1:]
+			}
+			msg.Write(line)
+		case len(bytes.TrimSpace(line)) > 0:
+			return nil, "", fmt.Errorf("%s is not an mbox file (it does not start with a \"From \" line)", s.file.Name())
+		}
+		if err == io.EOF {
+			if !s.inMessage {
+				return nil, "", io.EOF
+			}
+			s.inMessage = false
+			return s.message(msg.Bytes())
+		}
+	}
+}
+
+// message returns one message read from the mbox, without the blank line
+// that separates it from the next entry.
+func (s *mboxSource) message(data []byte) ([]byte, string, error) {
+	if bytes.HasSuffix(data, []byte("\r\n\r\n")) {
+		data = data[:len(data)-2]
+	} else if bytes.HasSuffix(data, []byte("\n\n")) {
+		data = data[:len(data)-1]
+	}
+	s.count++
+	return data, fmt.Sprintf("%s#%d", s.file.Name(), s.count), nil
+}
+
+func (s *mboxSource) Close() error { return s.file.Close() }
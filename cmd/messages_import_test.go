@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMboxSource(t *testing.T) {
+	t.Parallel()
+
+	messages := []string{
+		"From: alice@example.com\nSubject: One\n\nFrom the top\n>From quoted\n",
+		"From: bob@example.com\nSubject: Two\n\nNo trailing newline",
+		"From: carol@example.com\nSubject: Three\n\nEnds with a blank line\n\n",
+	}
+	var mbox strings.Builder
+	for _, msg := range messages {
+		mbox.Write(mboxEntry([]byte(msg), time.Unix(0, 0)))
+	}
+	path := filepath.Join(t.TempDir(), "archive.mbox")
+	if err := os.WriteFile(path, []byte(mbox.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := openImportSource(path)
+	if err != nil {
+		t.Fatalf("openImportSource() error: %v", err)
+	}
+	defer source.Close()
+	for i, want := range messages {
+		if !strings.HasSuffix(want, "\n") {
+			want += "\n" // mbox entries always end with a newline
+		}
+		raw, src, err := source.next()
+		if err != nil {
+			t.Fatalf("next() #%d error: %v", i+1, err)
+		}
+		if string(raw) != want {
+			t.Errorf("next() #%d = %q, want %q", i+1, raw, want)
+		}
+		if wantSrc := path + "#" + string(rune('1'+i)); src != wantSrc {
+			t.Errorf("next() #%d source = %q, want %q", i+1, src, wantSrc)
+		}
+	}
+	if _, _, err := source.next(); err != io.EOF {
+		t.Errorf("next() after the last message error = %v, want io.EOF", err)
+	}
+
+	notMbox := filepath.Join(t.TempDir(), "notes.mbox")
+	if err := os.WriteFile(notMbox, []byte("Subject: hi\n\nbody\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	source, err = openImportSource(notMbox)
+	if err != nil {
+		t.Fatalf("openImportSource() error: %v", err)
+	}
+	defer source.Close()
+	if _, _, err := source.next(); err == nil || !strings.Contains(err.Error(), "not an mbox file") {
+		t.Errorf("next() on a non-mbox file error = %v, want a not an mbox file error", err)
+	}
+}
+
+func TestE2EMessagesImport(t *testing.T) {
+	srv := newE2EServer(t)
+	legacy := srv.AddLabel("Legacy")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "archive.mbox")
+	var mbox strings.Builder
+	for _, msg := range []string{e2eInboxMessage, e2eQuotingMessage} {
+		mbox.Write(mboxEntry([]byte(msg), time.Unix(0, 0)))
+	}
+	if err := os.WriteFile(path, []byte(mbox.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := mustRunCLI(t, "messages", "import", path, "--label-ids", "Legacy", "--never-mark-spam")
+	if !strings.Contains(out, "Imported 2 messages (0 already imported, 0 failed)") {
+		t.Errorf("messages import output = %q, want 2 imported", out)
+	}
+	ids := srv.MessageIDs(legacy)
+	if len(ids) != 2 {
+		t.Fatalf("messages labeled Legacy = %v, want 2", ids)
+	}
+	for _, id := range ids {
+		raw, _ := srv.RawMessage(id)
+		if strings.Contains(string(raw), "Minutes") && !strings.Contains(string(raw), "\nFrom the meeting:\n>From the last one\n") {
+			t.Errorf("imported message %s = %q, want the mboxrd escaping undone", id, raw)
+		}
+	}
+
+	// A record cut off by an interrupted run is dropped, and logged imports
+	// are skipped.
+	logPath := path + importLogSuffix
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("opening import log: %v", err)
+	}
+	f.WriteString(`{"source":"cut`) //nolint:errcheck
+	f.Close()
+	mbox.Write(mboxEntry([]byte(e2eNewsletter(1)), time.Unix(0, 0)))
+	if err := os.WriteFile(path, []byte(mbox.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out = mustRunCLI(t, "messages", "import", path, "--label-ids", "Legacy", "-f", "json")
+	if !strings.Contains(out, `"imported": 1`) || !strings.Contains(out, `"skipped": 2`) {
+		t.Errorf("re-run output = %s, want 1 imported and 2 skipped", out)
+	}
+	if got := len(srv.MessageIDs(legacy)); got != 3 {
+		t.Errorf("messages labeled Legacy after re-run = %d, want 3", got)
+	}
+	data, _ := os.ReadFile(logPath)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 || strings.Contains(string(data), "cut") {
+		t.Errorf("import log = %q, want three complete records", data)
+	}
+
+	// A directory of .eml files, added with messages.insert
+	emlDir := filepath.Join(dir, "eml")
+	os.MkdirAll(filepath.Join(emlDir, "sub"), 0700)                                     //nolint:errcheck
+	os.WriteFile(filepath.Join(emlDir, "sub", "a.eml"), []byte(e2eNewsletter(2)), 0600) //nolint:errcheck
+	os.WriteFile(filepath.Join(emlDir, "notes.txt"), []byte("not mail"), 0600)          //nolint:errcheck
+	out = mustRunCLI(t, "messages", "import", emlDir, "--insert", "--label-ids", "INBOX")
+	if !strings.Contains(out, "Imported 1 messages") || !strings.Contains(out, emlDir+importLogSuffix) {
+		t.Errorf("directory import output = %q, want 1 imported and the log path", out)
+	}
+}
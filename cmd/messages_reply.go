@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"strings"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+var (
+	// messagesReplyCmd flags
+	replyBody   string
+	replyAll    bool
+	replyQuote  bool
+	replyCc     string
+	replyBcc    string
+	replyAttach []string
+	replyFrom   string
+	replySig    bool
+)
+
+// messagesReplyCmd represents the messages reply command
+var messagesReplyCmd = &cobra.Command{
+	Use:   "reply <message-id>",
+	Short: "Reply to a message",
+	Long: `Reply to a Gmail message, keeping the conversation threaded.
+
+The reply is addressed to the original sender (or its Reply-To address) and
+carries In-Reply-To and References headers so mail clients group it with the
+original. The subject is prefixed with "Re:" when needed.
+
+Use --all to also include the original To and Cc recipients (your own
+address is left out). Use --quote to include the original message body
+below your reply. The body supports the same markdown formatting as 'send'.
+
+Use --from to reply from a verified send-as alias and --signature to append
+its signature above any quoted text.`,
+	Example: `  # Reply to the sender
+  gsuite messages reply 18d5a1b2c3d4e5f6 --body "Thanks, got it."
+
+  # Reply to everyone and quote the original
+  gsuite messages reply 18d5a1b2c3d4e5f6 --all --quote --body "See my answers below."
+
+  # Reply with an attachment
+  gsuite messages reply 18d5a1b2c3d4e5f6 -b "Updated report attached." --attach report.pdf`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMessagesReply,
+}
+
+func init() {
+	messagesCmd.AddCommand(messagesReplyCmd)
+
+	messagesReplyCmd.Flags().StringVarP(&replyBody, "body", "b", "", "Reply body with markdown support (required)")
+	messagesReplyCmd.Flags().BoolVar(&replyAll, "all", false, "Reply to all original recipients")
+	messagesReplyCmd.Flags().BoolVar(&replyQuote, "quote", false, "Quote the original message body")
+	messagesReplyCmd.Flags().StringVar(&replyCc, "cc", "", "Additional CC recipients (comma-separated)")
+	messagesReplyCmd.Flags().StringVar(&replyBcc, "bcc", "", "BCC recipients (comma-separated)")
+	messagesReplyCmd.Flags().StringArrayVarP(&replyAttach, "attach", "a", nil, "File path to attach (can be specified multiple times)")
+	addSenderFlags(messagesReplyCmd, &replyFrom, &replySig)
+	messagesReplyCmd.MarkFlagRequired("body")
+}
+
+func runMessagesReply(cmd *cobra.Command, args []string) error {
+	messageID := args[0]
+
+	for _, attachPath := range replyAttach {
+		if _, err := os.Stat(attachPath); err != nil {
+			return fmt.Errorf("attachment file not found: %s", attachPath)
+		}
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	original, err := service.Users.Messages.Get("me", messageID).Format("full").Do()
+	if err != nil {
+		return fmt.Errorf("Gmail API error: %w", err)
+	}
+	if original.Payload == nil {
+		return fmt.Errorf("message has no payload: %s", messageID)
+	}
+
+	profile, err := service.Users.GetProfile("me").Do()
+	if err != nil {
+		return fmt.Errorf("Gmail API error: %w", err)
+	}
+
+	headers := original.Payload.Headers
+	to, cc := replyRecipients(headers, profile.EmailAddress, replyAll)
+	if to == "" {
+		return fmt.Errorf("could not determine reply recipient for message %s", messageID)
+	}
+	cc = joinAddressLists(cc, replyCc)
+
+	extra, signature, err := resolveSender(service, replyFrom, useSignature(cmd, replySig))
+	if err != nil {
+		return err
+	}
+
+	var quoted string
+	if replyQuote {
+		quoted = quoteOriginal(headerValue(headers, "Date"), decodedHeaderValue(headers, "From"), extractBody(original))
+	}
+	plainBody, htmlBody, images, err := composeBodies(interpretEscapes(replyBody), signature, quoted)
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+
+	subject := replySubject(headerValue(headers, "Subject"))
+	extra = append(extra, replyThreadingHeaders(headerValue(headers, "Message-ID"), headerValue(headers, "References"))...)
+
+	if err := checkAttachmentSize(replyAttach, images); err != nil {
+		return err
+	}
+
+	sent, err := sendMessageUpload(service, original.ThreadId, func(w io.Writer) error {
+		return writeOutgoingMessage(w, to, subject, cc, replyBcc, plainBody, htmlBody, images, fileAttachments(replyAttach), extra...)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send reply: %w", err)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		type replyResult struct {
+			MessageID string `json:"message_id"`
+			ThreadID  string `json:"thread_id"`
+			To        string `json:"to"`
+			Cc        string `json:"cc"`
+		}
+		return outputJSON(replyResult{
+			MessageID: sent.Id,
+			ThreadID:  sent.ThreadId,
+			To:        to,
+			Cc:        cc,
+		})
+	}
+
+	fmt.Printf("Reply sent successfully!\nMessage ID: %s\nThread ID: %s\n", sent.Id, sent.ThreadId)
+	return nil
+}
+
+// replySubject prefixes subject with "Re: " unless it already has one.
+func replySubject(subject string) string {
+	trimmed := strings.TrimSpace(subject)
+	if len(trimmed) >= 3 && strings.EqualFold(trimmed[:3], "re:") {
+		return trimmed
+	}
+	return "Re: " + trimmed
+}
+
+// replyThreadingHeaders returns the In-Reply-To and References headers for a
+// reply to a message with the given Message-ID and References values.
+func replyThreadingHeaders(messageID, references string) []mailHeader {
+	messageID = strings.TrimSpace(messageID)
+	if messageID == "" {
+		return nil
+	}
+	refs := strings.Join(strings.Fields(references), " ")
+	if refs == "" {
+		refs = messageID
+	} else if !strings.Contains(refs, messageID) {
+		refs += " " + messageID
+	}
+	return []mailHeader{
+		{Name: "In-Reply-To", Value: messageID},
+		{Name: "References", Value: refs},
+	}
+}
+
+// replyRecipients computes the To and Cc lists for a reply. The reply goes to
+// Reply-To (or From); when replying to one's own message it goes to the
+// original To instead. With all set, the original To and Cc recipients are
+// included too. The user's own address is never included.
+func replyRecipients(headers []*gmail.MessagePartHeader, self string, all bool) (string, string) {
+	sender := headerValue(headers, "Reply-To")
+	if sender == "" {
+		sender = headerValue(headers, "From")
+	}
+
+	seen := map[string]bool{strings.ToLower(self): true}
+	var to, cc []string
+
+	fromSelf := false
+	for _, addr := range parseAddressList(headerValue(headers, "From")) {
+		if strings.EqualFold(addr.Address, self) {
+			fromSelf = true
+		}
+	}
+
+	primary := parseAddressList(sender)
+	if fromSelf {
+		primary = parseAddressList(headerValue(headers, "To"))
+	}
+	to = appendUniqueAddresses(to, primary, seen)
+
+	if all {
+		to = appendUniqueAddresses(to, parseAddressList(headerValue(headers, "To")), seen)
+		cc = appendUniqueAddresses(cc, parseAddressList(headerValue(headers, "Cc")), seen)
+	}
+
+	return strings.Join(to, ", "), strings.Join(cc, ", ")
+}
+
+// parseAddressList parses a header address list, falling back to splitting
+// on commas for values net/mail cannot parse.
+func parseAddressList(value string) []*mail.Address {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	addrs, err := mail.ParseAddressList(value)
+	if err == nil {
+		return addrs
+	}
+	var result []*mail.Address
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if addr, err := mail.ParseAddress(part); err == nil {
+			result = append(result, addr)
+		} else {
+			result = append(result, &mail.Address{Address: part})
+		}
+	}
+	return result
+}
+
+// appendUniqueAddresses appends addrs to list, skipping any address already in seen.
+func appendUniqueAddresses(list []string, addrs []*mail.Address, seen map[string]bool) []string {
+	for _, addr := range addrs {
+		key := strings.ToLower(addr.Address)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		list = append(list, addr.String())
+	}
+	return list
+}
+
+// joinAddressLists joins non-empty comma-separated address lists.
+func joinAddressLists(lists ...string) string {
+	var parts []string
+	for _, l := range lists {
+		if strings.TrimSpace(l) != "" {
+			parts = append(parts, strings.TrimSpace(l))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// quoteOriginal formats the original message body as a quoted block with an
+// attribution line.
+func quoteOriginal(date, from, body string) string {
+	var b strings.Builder
+	if date != "" {
+		b.WriteString(fmt.Sprintf("On %s, %s wrote:\n", date, from))
+	} else {
+		b.WriteString(fmt.Sprintf("%s wrote:\n", from))
+	}
+	body = strings.TrimRight(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	for _, line := range strings.Split(body, "\n") {
+		if line == "" {
+			b.WriteString(">\n")
+		} else {
+			b.WriteString("> " + line + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestReplySubject(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+	}{
+		{name: "should add Re prefix", subject: "Hello", want: "Re: Hello"},
+		{name: "should keep existing Re prefix", subject: "Re: Hello", want: "Re: Hello"},
+		{name: "should keep lowercase re prefix", subject: "re: Hello", want: "re: Hello"},
+		{name: "should handle empty subject", subject: "", want: "Re: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := replySubject(tt.subject); got != tt.want {
+				t.Errorf("replySubject(%q) = %q, want %q", tt.subject, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplyThreadingHeaders(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		messageID  string
+		references string
+		wantRefs   string
+		wantNil    bool
+	}{
+		{
+			name:      "should use message id as references when none exist",
+			messageID: "<a@example.com>",
+			wantRefs:  "<a@example.com>",
+		},
+		{
+			name:       "should append message id to existing references",
+			messageID:  "<b@example.com>",
+			references: "<root@example.com>\r\n <a@example.com>",
+			wantRefs:   "<root@example.com> <a@example.com> <b@example.com>",
+		},
+		{
+			name:    "should return nil without message id",
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := replyThreadingHeaders(tt.messageID, tt.references)
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("replyThreadingHeaders() = %v, want nil", got)
+				}
+				return
+			}
+			if len(got) != 2 {
+				t.Fatalf("replyThreadingHeaders() returned %d headers, want 2", len(got))
+			}
+			if got[0].Name != "In-Reply-To" || got[0].Value != tt.messageID {
+				t.Errorf("In-Reply-To = %q, want %q", got[0].Value, tt.messageID)
+			}
+			if got[1].Name != "References" || got[1].Value != tt.wantRefs {
+				t.Errorf("References = %q, want %q", got[1].Value, tt.wantRefs)
+			}
+		})
+	}
+}
+
+func TestReplyRecipients(t *testing.T) {
+	t.Parallel()
+	hdr := func(kv ...string) []*gmail.MessagePartHeader {
+		var headers []*gmail.MessagePartHeader
+		for i := 0; i < len(kv); i += 2 {
+			headers = append(headers, &gmail.MessagePartHeader{Name: kv[i], Value: kv[i+1]})
+		}
+		return headers
+	}
+
+	tests := []struct {
+		name    string
+		headers []*gmail.MessagePartHeader
+		all     bool
+		wantTo  string
+		wantCc  string
+	}{
+		{
+			name:    "should reply to sender only",
+			headers: hdr("From", "alice@example.com", "To", "me@example.com, bob@example.com"),
+			wantTo:  "<alice@example.com>",
+		},
+		{
+			name:    "should prefer Reply-To over From",
+			headers: hdr("From", "alice@example.com", "Reply-To", "support@example.com"),
+			wantTo:  "<support@example.com>",
+		},
+		{
+			name:    "should include To and Cc on reply all without self",
+			headers: hdr("From", "alice@example.com", "To", "me@example.com, bob@example.com", "Cc", "carol@example.com, ME@example.com"),
+			all:     true,
+			wantTo:  "<alice@example.com>, <bob@example.com>",
+			wantCc:  "<carol@example.com>",
+		},
+		{
+			name:    "should reply to original recipients when replying to own message",
+			headers: hdr("From", "Me <me@example.com>", "To", "dave@example.com"),
+			wantTo:  "<dave@example.com>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			to, cc := replyRecipients(tt.headers, "me@example.com", tt.all)
+			if to != tt.wantTo {
+				t.Errorf("to = %q, want %q", to, tt.wantTo)
+			}
+			if cc != tt.wantCc {
+				t.Errorf("cc = %q, want %q", cc, tt.wantCc)
+			}
+		})
+	}
+}
+
+func TestQuoteOriginal(t *testing.T) {
+	t.Parallel()
+	got := quoteOriginal("Mon, 1 Jan 2026 10:00:00 +0000", "alice@example.com", "line one\r\n\r\nline two\r\n")
+	want := "On Mon, 1 Jan 2026 10:00:00 +0000, alice@example.com wrote:\n> line one\n>\n> line two\n"
+	if got != want {
+		t.Errorf("quoteOriginal() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteOutgoingMessageWithThreadingHeaders(t *testing.T) {
+	t.Parallel()
+	msg, err := writeTestMessage("to@example.com", "Re: Hi", "body", "", "", nil, replyThreadingHeaders("<a@example.com>", "")...)
+	if err != nil {
+		t.Fatalf("writeOutgoingMessage() error = %v", err)
+	}
+	for _, want := range []string{"In-Reply-To: <a@example.com>\r\n", "References: <a@example.com>\r\n"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+var (
+	// messagesWatchCmd flags
+	messagesWatchQuery    string
+	messagesWatchExec     string
+	messagesWatchLabel    string
+	messagesWatchInterval time.Duration
+	messagesWatchSince    uint64
+	messagesWatchReset    bool
+	messagesWatchOnce     bool
+)
+
+// messagesWatchCmd represents the messages watch command
+var messagesWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Print new incoming messages as they arrive",
+	Long: `Poll the mailbox history for new messages and print each one as it
+arrives: one line per message, or one JSON object per line with -f json.
+
+Only messages added with --label (INBOX by default) are reported. With
+--query, only new messages that also match the Gmail search query are
+reported.
+
+With --exec, each message's JSON object is piped to the hook command on
+stdin, run with "sh -c" ("cmd /C" on Windows). GSUITE_MESSAGE_ID and
+GSUITE_THREAD_ID are set in its environment. The hook's output goes to
+stderr, so stdout only carries the reported messages. A failing hook is
+reported on stderr and does not stop the watch.
+
+The position is saved per account after every poll, separately from the
+'history' command, so a restarted watch resumes where it stopped and
+reports the messages that arrived in between. The first run starts from
+now; --reset does the same later on. Press Ctrl+C to stop.`,
+	Example: `  # Print new inbox mail every 30 seconds
+  gsuite messages watch
+
+  # Alert on mail from a specific sender
+  gsuite messages watch -q "from:alerts@example.com" --exec 'notify-send "$GSUITE_MESSAGE_ID"'
+
+  # Check once, e.g. from cron
+  gsuite messages watch -q "is:important" --once -f json`,
+	Args: cobra.NoArgs,
+	RunE: runMessagesWatch,
+}
+
+func init() {
+	messagesCmd.AddCommand(messagesWatchCmd)
+
+	// messagesWatchCmd flags
+	messagesWatchCmd.Flags().StringVarP(&messagesWatchQuery, "query", "q", "", "Only report new messages matching this Gmail search query")
+	messagesWatchCmd.Flags().StringVar(&messagesWatchExec, "exec", "", "Hook command that receives each message as JSON on stdin")
+	messagesWatchCmd.Flags().StringVar(&messagesWatchLabel, "label", "INBOX", "Only report messages added with this label name or ID (empty for all)")
+	messagesWatchCmd.Flags().DurationVar(&messagesWatchInterval, "interval", 30*time.Second, "Time between polls")
+	messagesWatchCmd.Flags().Uint64Var(&messagesWatchSince, "since", 0, "History ID to start after (default: the saved position)")
+	messagesWatchCmd.Flags().BoolVar(&messagesWatchReset, "reset", false, "Ignore the saved position and start from now")
+	messagesWatchCmd.Flags().BoolVar(&messagesWatchOnce, "once", false, "Poll once and exit")
+	messagesWatchCmd.MarkFlagsMutuallyExclusive("since", "reset")
+}
+
+// watchedMessage is a new message as reported by messages watch.
+type watchedMessage struct {
+	ID        string   `json:"id"`
+	ThreadID  string   `json:"thread_id"`
+	HistoryID uint64   `json:"history_id"`
+	Date      string   `json:"date"`
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Subject   string   `json:"subject"`
+	Snippet   string   `json:"snippet"`
+	LabelIDs  []string `json:"label_ids"`
+}
+
+// messageWatcher reports messages added to a mailbox since its position.
+type messageWatcher struct {
+	service *gmail.Service
+	account string
+	// labelID, if set, limits reported messages to those added with it.
+	labelID string
+	// query, if set, limits reported messages to those matching it.
+	query string
+	// hook, if set, is run for every reported message.
+	hook string
+	// position is the history ID changes have been processed up to.
+	position uint64
+}
+
+func runMessagesWatch(cmd *cobra.Command, args []string) error {
+	if messagesWatchInterval < time.Second {
+		return fmt.Errorf("--interval must be at least 1s")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	w := &messageWatcher{service: service, query: messagesWatchQuery, hook: messagesWatchExec}
+	if w.account, err = currentAccount(service); err != nil {
+		return err
+	}
+	if messagesWatchLabel != "" {
+		if w.labelID, err = newLabelResolver(service).ResolveOne(messagesWatchLabel); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case cmd.Flags().Changed("since"):
+		w.position = messagesWatchSince
+	case !messagesWatchReset:
+		if w.position, err = auth.LoadWatchHistoryID(w.account); err != nil {
+			return err
+		}
+	}
+	if w.position == 0 {
+		profile, err := service.Users.GetProfile("me").Do()
+		if err != nil {
+			return fmt.Errorf("Gmail API error: %w", err)
+		}
+		w.position = profile.HistoryId
+		if err := auth.SaveWatchHistoryID(w.account, w.position, true); err != nil {
+			return err
+		}
+	}
+	if GetVerbose() {
+		fmt.Fprintf(os.Stderr, "Watching %s from history ID %d\n", w.account, w.position)
+	}
+
+	for {
+		if _, err := w.poll(ctx); err != nil {
+			var expired *historyExpiredError
+			if errors.As(err, &expired) {
+				return fmt.Errorf("history ID %d has expired; restart with --reset to watch from now", expired.start)
+			}
+			if messagesWatchOnce || ctx.Err() != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %v (retrying in %s)\n", err, messagesWatchInterval)
+		}
+		if messagesWatchOnce {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(messagesWatchInterval):
+		}
+	}
+}
+
+// poll reports the messages added since the watcher's position, then saves
+// the new position. It returns the number of messages reported. If a poll
+// fails part-way, the position is kept, so those messages are reported
+// again by the next poll.
+func (w *messageWatcher) poll(ctx context.Context) (int, error) {
+	records, latest, err := listHistory(ctx, w.service, w.position, historyQuery{
+		types:   []string{historyTypes["messagesAdded"]},
+		labelID: w.labelID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []string
+	historyIDs := make(map[string]uint64)
+	for _, r := range records {
+		if _, seen := historyIDs[r.MessageID]; !seen {
+			ids = append(ids, r.MessageID)
+		}
+		historyIDs[r.MessageID] = r.HistoryID
+	}
+	if w.query != "" && len(ids) > 0 {
+		if ids, err = w.matching(ctx, ids); err != nil {
+			return 0, err
+		}
+	}
+
+	reported := 0
+	for _, id := range ids {
+		msg, err := w.service.Users.Messages.Get("me", id).Format("metadata").
+			MetadataHeaders("From", "To", "Subject", "Date").Do()
+		if err != nil {
+			var apiErr *googleapi.Error
+			if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+				continue // deleted since it arrived
+			}
+			return reported, fmt.Errorf("Gmail API error: %w", err)
+		}
+		watched := newWatchedMessage(msg, historyIDs[id])
+		if err := printWatchedMessage(watched); err != nil {
+			return reported, err
+		}
+		if w.hook != "" {
+			if err := runWatchHook(ctx, w.hook, watched); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: hook failed for message %s: %v\n", id, err)
+			}
+		}
+		reported++
+	}
+
+	if latest != w.position {
+		if err := auth.SaveWatchHistoryID(w.account, latest, false); err != nil {
+			return reported, err
+		}
+		w.position = latest
+	}
+	return reported, nil
+}
+
+// matching returns the IDs in ids that match the watcher's query, keeping
+// their order. Each message is searched for by its Message-ID together with
+// the query, so it is found however far down the results it would sort.
+// Messages without a Message-ID header are compared against the first
+// results of the query instead, which can miss them in a large burst.
+func (w *messageWatcher) matching(ctx context.Context, ids []string) ([]string, error) {
+	matched := make(map[string]bool, len(ids))
+	var unidentified []string
+	for _, id := range ids {
+		msg, err := w.service.Users.Messages.Get("me", id).Format("metadata").MetadataHeaders("Message-ID").Do()
+		if err != nil {
+			var apiErr *googleapi.Error
+			if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+				continue // deleted since it arrived
+			}
+			return nil, fmt.Errorf("Gmail API error: %w", err)
+		}
+		var messageID string
+		if msg.Payload != nil {
+			messageID = strings.Trim(headerValue(msg.Payload.Headers, "Message-ID"), "<> ")
+		}
+		if messageID == "" {
+			unidentified = append(unidentified, id)
+			continue
+		}
+		resp, err := w.service.Users.Messages.List("me").
+			Q(fmt.Sprintf("rfc822msgid:%s (%s)", messageID, w.query)).Fields("messages/id").Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("Gmail API error: %w", err)
+		}
+		for _, m := range resp.Messages {
+			if m.Id == id {
+				matched[id] = true
+			}
+		}
+	}
+
+	if len(unidentified) > 0 {
+		call := w.service.Users.Messages.List("me").Q(w.query).Fields("messages/id", "nextPageToken", "resultSizeEstimate")
+		found, _, _, err := listMessagePages(ctx, call, &pageOptions{limit: int64(max(100, 2*len(unidentified)))}, gmailMaxPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("Gmail API error: %w", err)
+		}
+		for _, m := range found {
+			matched[m.Id] = true
+		}
+	}
+
+	var result []string
+	for _, id := range ids {
+		if matched[id] {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+// newWatchedMessage builds the report for a message fetched in metadata format.
+func newWatchedMessage(msg *gmail.Message, historyID uint64) watchedMessage {
+	watched := watchedMessage{
+		ID:        msg.Id,
+		ThreadID:  msg.ThreadId,
+		HistoryID: historyID,
+		Snippet:   msg.Snippet,
+		LabelIDs:  msg.LabelIds,
+	}
+	if msg.Payload != nil {
+		watched.Date = headerValue(msg.Payload.Headers, "Date")
+		watched.From = decodedHeaderValue(msg.Payload.Headers, "From")
+		watched.To = decodedHeaderValue(msg.Payload.Headers, "To")
+		watched.Subject = decodedHeaderValue(msg.Payload.Headers, "Subject")
+	}
+	if watched.LabelIDs == nil {
+		watched.LabelIDs = []string{}
+	}
+	return watched
+}
+
+// printWatchedMessage prints a new message as an NDJSON line or a text line.
+func printWatchedMessage(msg watchedMessage) error {
+	if GetOutputFormat() == "json" {
+		return outputNDJSON(msg)
+	}
+	subject := msg.Subject
+	if subject == "" {
+		subject = "(no subject)"
+	}
+	fmt.Printf("%s  %s  %s  [%s]\n", msg.Date, msg.From, subject, msg.ID)
+	return nil
+}
+
+// runWatchHook runs command through the shell with msg as JSON on stdin.
+func runWatchHook(ctx context.Context, command string, msg watchedMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		c = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	c.Stdin = bytes.NewReader(append(data, '\n'))
+	// stdout carries the reported messages, which the hook must not mix into.
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(), "GSUITE_MESSAGE_ID="+msg.ID, "GSUITE_THREAD_ID="+msg.ThreadID)
+	return c.Run()
+}
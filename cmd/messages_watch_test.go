@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+)
+
+const e2eAlertMessage = "From: Alerts <alerts@example.com>\r\n" +
+	"To: me@example.com\r\n" +
+	"Subject: Disk almost full\r\n" +
+	"Date: Thu, 15 Jan 2026 10:00:00 +0000\r\n" +
+	"\r\n" +
+	"Disk usage is at 95%.\r\n"
+
+func TestE2EMessagesWatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook test uses sh")
+	}
+	srv := newE2EServer(t)
+	srv.AddMessage(e2eInboxMessage, "INBOX")
+
+	// The first run starts from now and reports nothing.
+	if out := mustRunCLI(t, "messages", "watch", "--once"); out != "" {
+		t.Errorf("first watch printed %q, want nothing", out)
+	}
+	if saved, _ := auth.LoadWatchHistoryID(srv.Email()); saved != srv.HistoryID() {
+		t.Errorf("saved watch position = %d, want %d", saved, srv.HistoryID())
+	}
+
+	srv.AddMessage(e2eInboxMessage, "INBOX")
+	alert := srv.AddMessage(e2eAlertMessage, "INBOX", "UNREAD")
+	srv.AddMessage(e2eAlertMessage, "SENT")
+
+	hookOut := filepath.Join(t.TempDir(), "hook.ndjson")
+	out := mustRunCLI(t, "messages", "watch", "--once", "-q", "from:alerts@example.com", "-f", "json",
+		"--exec", `cat >> "`+hookOut+`"; echo "$GSUITE_MESSAGE_ID" >> "`+hookOut+`"`)
+	var got watchedMessage
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("watch output is not one JSON object: %v\n%s", err, out)
+	}
+	if got.ID != alert || got.Subject != "Disk almost full" || got.From != "Alerts <alerts@example.com>" {
+		t.Errorf("watch reported %+v, want the alert %s", got, alert)
+	}
+
+	hookData, err := os.ReadFile(hookOut)
+	if err != nil {
+		t.Fatalf("hook did not run: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(hookData)), "\n")
+	var piped watchedMessage
+	if len(lines) != 2 || json.Unmarshal([]byte(lines[0]), &piped) != nil || piped.ID != alert || lines[1] != alert {
+		t.Errorf("hook received %q, want the alert JSON and GSUITE_MESSAGE_ID", hookData)
+	}
+
+	// The position was saved, so the next poll reports nothing.
+	if out := mustRunCLI(t, "messages", "watch", "--once", "-q", "from:alerts@example.com"); out != "" {
+		t.Errorf("second watch printed %q, want nothing", out)
+	}
+
+	// Messages that arrive while the watcher is stopped are reported on resume.
+	srv.AddMessage(e2eAlertMessage, "INBOX")
+	out = mustRunCLI(t, "messages", "watch", "--once")
+	if !strings.Contains(out, "Disk almost full") || strings.Count(out, "\n") != 1 {
+		t.Errorf("resumed watch printed %q, want one line for the new alert", out)
+	}
+
+	// A failing hook is reported but does not stop the watch.
+	srv.AddMessage(e2eAlertMessage, "INBOX")
+	if _, err := runCLI(t, "messages", "watch", "--once", "--exec", "exit 3"); err != nil {
+		t.Errorf("watch with a failing hook error = %v, want nil", err)
+	}
+
+	// The hook's output stays out of the NDJSON on stdout.
+	srv.AddMessage(e2eAlertMessage, "INBOX")
+	out = mustRunCLI(t, "messages", "watch", "--once", "-f", "json", "--exec", "echo hook output")
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Errorf("watch output with a printing hook is not one JSON object: %v\n%s", err, out)
+	}
+
+	// Messages with a Message-ID are checked against the query one by one.
+	matching := srv.AddMessage("Message-ID: <alert-1@example.com>\r\n"+e2eAlertMessage, "INBOX")
+	srv.AddMessage("Message-ID: <other-1@example.com>\r\n"+e2eInboxMessage, "INBOX")
+	out = mustRunCLI(t, "messages", "watch", "--once", "-q", "from:alerts@example.com", "-f", "json")
+	if err := json.Unmarshal([]byte(out), &got); err != nil || got.ID != matching {
+		t.Errorf("watch by Message-ID reported %q, want only %s", out, matching)
+	}
+
+	srv.ExpireHistory()
+	srv.AddMessage(e2eAlertMessage, "INBOX")
+	if err := auth.SaveWatchHistoryID(srv.Email(), 1, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCLI(t, "messages", "watch", "--once"); err == nil || !strings.Contains(err.Error(), "--reset") {
+		t.Errorf("watch from an expired position error = %v, want a --reset hint", err)
+	}
+}
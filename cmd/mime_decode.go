@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"google.golang.org/api/gmail/v1"
+)
+
+// headerDecoder decodes RFC 2047 encoded-words in every charset the WHATWG
+// Encoding Standard knows, not only UTF-8 and ISO-8859-1.
+var headerDecoder = &mime.WordDecoder{
+	CharsetReader: charset.NewReaderLabel,
+}
+
+// decodeHeader returns a header value with its RFC 2047 encoded-words
+// decoded, so "=?UTF-8?B?SMOpbGzDsg==?=" becomes "Héllò". Values that cannot
+// be decoded are returned unchanged.
+func decodeHeader(value string) string {
+	if !strings.Contains(value, "=?") {
+		return value
+	}
+	decoded, err := headerDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// decodedHeaderValue is headerValue with RFC 2047 encoded-words decoded, for
+// showing headers to the user.
+func decodedHeaderValue(headers []*gmail.MessagePartHeader, name string) string {
+	return decodeHeader(headerValue(headers, name))
+}
+
+// partText returns the body of a text part converted to UTF-8 from the
+// charset in its Content-Type header. Gmail has already undone the
+// Content-Transfer-Encoding (base64 or quoted-printable) of the data.
+func partText(part *gmail.MessagePart) string {
+	if part == nil || part.Body == nil || part.Body.Data == "" {
+		return ""
+	}
+	label := ""
+	if _, params, err := mime.ParseMediaType(headerValue(part.Headers, "Content-Type")); err == nil {
+		label = params["charset"]
+	}
+	return decodeCharset([]byte(decodeBase64URL(part.Body.Data)), label, part.MimeType)
+}
+
+// decodeCharset converts data in the named charset to UTF-8. Without a
+// charset, valid UTF-8 is kept as is, HTML is checked for a <meta charset>,
+// and anything else is read as windows-1252, the usual legacy default. Bytes
+// that cannot be decoded become U+FFFD.
+func decodeCharset(data []byte, label, mimeType string) string {
+	var enc encoding.Encoding
+	switch {
+	case label != "":
+		enc, _ = charset.Lookup(label)
+	case utf8.Valid(data):
+		return string(data)
+	case mimeType == "text/html":
+		enc, _, _ = charset.DetermineEncoding(data, mimeType)
+	default:
+		enc = charmap.Windows1252
+	}
+	if enc == nil {
+		return strings.ToValidUTF8(string(data), "\uFFFD")
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return strings.ToValidUTF8(string(data), "\uFFFD")
+	}
+	return strings.ToValidUTF8(string(decoded), "\uFFFD")
+}
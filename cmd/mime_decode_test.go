@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"mime"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"google.golang.org/api/gmail/v1"
+)
+
+// mustEncode converts s from UTF-8 to enc.
+func mustEncode(t *testing.T, enc encoding.Encoding, s string) string {
+	t.Helper()
+	encoded, err := enc.NewEncoder().String(s)
+	if err != nil {
+		t.Fatalf("encoding %q: %v", s, err)
+	}
+	return encoded
+}
+
+func TestDecodeHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "should leave plain values alone", input: "Quarterly report", want: "Quarterly report"},
+		{name: "should decode UTF-8 B-encoding", input: "=?UTF-8?B?SMOpbGzDsg==?=", want: "Héllò"},
+		{name: "should decode ISO-8859-1 Q-encoding", input: "=?ISO-8859-1?Q?Caf=E9_ouvert?=", want: "Café ouvert"},
+		{name: "should decode ISO-2022-JP", input: mime.BEncoding.Encode("ISO-2022-JP", mustEncode(t, japanese.ISO2022JP, "会議の件")), want: "会議の件"},
+		{name: "should decode Shift_JIS", input: mime.BEncoding.Encode("Shift_JIS", mustEncode(t, japanese.ShiftJIS, "請求書")), want: "請求書"},
+		{name: "should decode windows-1252", input: mime.QEncoding.Encode("windows-1252", mustEncode(t, charmap.Windows1252, "€5 off")), want: "€5 off"},
+		{name: "should decode a display name in an address", input: "=?UTF-8?Q?Ren=C3=A9e?= <renee@example.com>", want: "Renée <renee@example.com>"},
+		{name: "should join adjacent encoded-words", input: "=?UTF-8?Q?a?= =?UTF-8?Q?b?=", want: "ab"},
+		{name: "should keep a value with an unknown charset", input: "=?x-unknown?Q?abc?=", want: "=?x-unknown?Q?abc?="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := decodeHeader(tt.input); got != tt.want {
+				t.Errorf("decodeHeader(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartText(t *testing.T) {
+	t.Parallel()
+
+	part := func(contentType, data string) *gmail.MessagePart {
+		mimeType, _, _ := mime.ParseMediaType(contentType)
+		return &gmail.MessagePart{
+			MimeType: mimeType,
+			Headers:  []*gmail.MessagePartHeader{{Name: "Content-Type", Value: contentType}},
+			Body:     &gmail.MessagePartBody{Data: base64.URLEncoding.EncodeToString([]byte(data))},
+		}
+	}
+
+	tests := []struct {
+		name string
+		part *gmail.MessagePart
+		want string
+	}{
+		{name: "should keep UTF-8", part: part("text/plain; charset=utf-8", "naïve ✓"), want: "naïve ✓"},
+		{name: "should decode ISO-2022-JP", part: part("text/plain; charset=ISO-2022-JP", mustEncode(t, japanese.ISO2022JP, "こんにちは")), want: "こんにちは"},
+		{name: "should decode Shift_JIS", part: part(`text/plain; charset="shift_jis"`, mustEncode(t, japanese.ShiftJIS, "お世話になります")), want: "お世話になります"},
+		{name: "should decode windows-1252", part: part("text/plain; charset=windows-1252", mustEncode(t, charmap.Windows1252, "“Quotes” – €")), want: "“Quotes” – €"},
+		{name: "should read ISO-8859-1 like windows-1252", part: part("text/plain; charset=iso-8859-1", "caf\xe9"), want: "café"},
+		{name: "should keep valid UTF-8 without a charset", part: part("text/plain", "déjà vu"), want: "déjà vu"},
+		{name: "should read invalid UTF-8 without a charset as windows-1252", part: part("text/plain", "caf\xe9"), want: "café"},
+		{name: "should use the meta charset of HTML", part: part("text/html", `<meta charset="shift_jis"><p>`+mustEncode(t, japanese.ShiftJIS, "日本")+`</p>`), want: "<meta charset=\"shift_jis\"><p>日本</p>"},
+		{name: "should replace invalid bytes in an unknown charset", part: part("text/plain; charset=x-unknown", "ok\xff"), want: "ok\uFFFD"},
+		{name: "should return empty for a part without data", part: &gmail.MessagePart{MimeType: "text/plain"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := partText(tt.part); got != tt.want {
+				t.Errorf("partText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestE2ELegacyCharsets(t *testing.T) {
+	srv := newE2EServer(t)
+	subject := mime.BEncoding.Encode("ISO-2022-JP", mustEncode(t, japanese.ISO2022JP, "会議の件"))
+	body := mustEncode(t, japanese.ShiftJIS, "明日の会議は10時からです。")
+	id := srv.AddMessage("From: =?UTF-8?Q?Ren=C3=A9e?= <renee@example.com>\r\n"+
+		"To: me@example.com\r\n"+
+		"Subject: "+subject+"\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=Shift_JIS\r\n"+
+		"Content-Transfer-Encoding: base64\r\n"+
+		"\r\n"+
+		base64.StdEncoding.EncodeToString([]byte(body))+"\r\n", "INBOX")
+
+	out := mustRunCLI(t, "messages", "get", id)
+	for _, want := range []string{"From: Renée <renee@example.com>", "Subject: 会議の件", "明日の会議は10時からです。"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("messages get output missing %q:\n%s", want, out)
+		}
+	}
+
+	out = mustRunCLI(t, "search", "from:renee@example.com")
+	if !strings.Contains(out, "会議の件") || !strings.Contains(out, "Renée") {
+		t.Errorf("search output has undecoded headers:\n%s", out)
+	}
+
+	msg, _ := srv.Message(id)
+	out = mustRunCLI(t, "threads", "get", msg.ThreadId)
+	if !strings.Contains(out, "Subject: 会議の件") || !strings.Contains(out, "明日の会議は10時からです。") {
+		t.Errorf("threads get output is not decoded:\n%s", out)
+	}
+}
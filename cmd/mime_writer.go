@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+)
+
+// base64LineLength is the longest line MIME allows in base64 content.
+const base64LineLength = 76
+
+// outgoingAttachment is a file attached to an outgoing message. Its content
+// is read from Path, or from what Open returns when Path is empty.
+type outgoingAttachment struct {
+	Filename string
+	// MimeType is detected from the first 512 bytes when empty.
+	MimeType string
+	Path     string
+	Open     func() (io.ReadCloser, error)
+}
+
+// fileAttachments returns attachments for the files at paths.
+func fileAttachments(paths []string) []outgoingAttachment {
+	attachments := make([]outgoingAttachment, 0, len(paths))
+	for _, path := range paths {
+		attachments = append(attachments, outgoingAttachment{Filename: filepath.Base(path), Path: path})
+	}
+	return attachments
+}
+
+// writeOutgoingMessage streams a message with separate text/plain and
+// text/html bodies, the images the HTML embeds and the attachments to w, as
+// multipart/mixed when there are attachments. Attachments are read and
+// encoded as they are written, so only a small buffer of each file is held
+// in memory.
+func writeOutgoingMessage(w io.Writer, to, subject, cc, bcc, plainBody, htmlBody string, images []inlineImage, attachments []outgoingAttachment, extra ...mailHeader) error {
+	altBody, altBoundary, err := buildAlternativeParts(plainBody, htmlBody, images...)
+	if err != nil {
+		return err
+	}
+	if len(attachments) == 0 {
+		_, err := w.Write(buildAlternativeMessage(to, subject, cc, bcc, altBody, altBoundary, extra...))
+		return err
+	}
+
+	mixedWriter := multipart.NewWriter(w)
+	var header bytes.Buffer
+	header.WriteString(fmt.Sprintf("To: %s\r\n", to))
+	if cc != "" {
+		header.WriteString(fmt.Sprintf("Cc: %s\r\n", cc))
+	}
+	if bcc != "" {
+		header.WriteString(fmt.Sprintf("Bcc: %s\r\n", bcc))
+	}
+	header.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	writeExtraHeaders(&header, extra)
+	header.WriteString("MIME-Version: 1.0\r\n")
+	header.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%s\r\n", mixedWriter.Boundary()))
+	header.WriteString("\r\n")
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+
+	altHeader := make(textproto.MIMEHeader)
+	altHeader.Set("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%s", altBoundary))
+	altPart, err := mixedWriter.CreatePart(altHeader)
+	if err != nil {
+		return fmt.Errorf("failed to create alternative part: %w", err)
+	}
+	if _, err := altPart.Write(altBody); err != nil {
+		return fmt.Errorf("failed to write alternative body: %w", err)
+	}
+
+	for _, att := range attachments {
+		if err := writeAttachment(mixedWriter, att); err != nil {
+			return err
+		}
+	}
+
+	if err := mixedWriter.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+	return nil
+}
+
+// writeAttachment adds att to w as a base64 attachment part.
+func writeAttachment(w *multipart.Writer, att outgoingAttachment) error {
+	source := cmp.Or(att.Path, att.Filename)
+	var rc io.ReadCloser
+	var err error
+	if att.Path != "" {
+		rc, err = os.Open(att.Path)
+	} else {
+		rc, err = att.Open()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read attachment %s: %w", source, err)
+	}
+	defer rc.Close()
+
+	r := bufio.NewReaderSize(rc, 512)
+	mimeType := att.MimeType
+	if mimeType == "" {
+		sniff, err := r.Peek(512)
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read attachment %s: %w", source, err)
+		}
+		mimeType = http.DetectContentType(sniff)
+	}
+
+	attachHeader := make(textproto.MIMEHeader)
+	attachHeader.Set("Content-Type", mimeType)
+	attachHeader.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", att.Filename))
+	attachHeader.Set("Content-Transfer-Encoding", "base64")
+	attachPart, err := w.CreatePart(attachHeader)
+	if err != nil {
+		return fmt.Errorf("failed to create attachment part: %w", err)
+	}
+	if err := copyBase64Lines(attachPart, r); err != nil {
+		return fmt.Errorf("failed to write attachment %s: %w", source, err)
+	}
+	return nil
+}
+
+// copyBase64Lines copies r to w in base64, in lines of 76 characters as MIME
+// requires.
+func copyBase64Lines(w io.Writer, r io.Reader) error {
+	lines := &lineBreaker{w: w}
+	enc := base64.NewEncoder(base64.StdEncoding, lines)
+	if _, err := io.Copy(enc, r); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if lines.col > 0 {
+		_, err := io.WriteString(w, "\r\n")
+		return err
+	}
+	return nil
+}
+
+// lineBreaker writes CRLF after every base64LineLength bytes written to it.
+type lineBreaker struct {
+	w   io.Writer
+	col int
+}
+
+func (l *lineBreaker) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(base64LineLength-l.col, len(p))
+		if _, err := l.w.Write(p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		l.col += n
+		p = p[n:]
+		if l.col == base64LineLength {
+			if _, err := io.WriteString(l.w, "\r\n"); err != nil {
+				return written, err
+			}
+			l.col = 0
+		}
+	}
+	return written, nil
+}
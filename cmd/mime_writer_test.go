@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"google.golang.org/api/googleapi"
+)
+
+// writeTestMessage renders markdown body like 'send' and writes the message
+// with the files at attachPaths attached.
+func writeTestMessage(to, subject, body, cc, bcc string, attachPaths []string, extra ...mailHeader) (string, error) {
+	plainBody, htmlBody, images, err := composeBodies(body, "", "")
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = writeOutgoingMessage(&buf, to, subject, cc, bcc, plainBody, htmlBody, images, fileAttachments(attachPaths), extra...)
+	return buf.String(), err
+}
+
+func TestCopyBase64Lines(t *testing.T) {
+	t.Parallel()
+
+	for _, size := range []int{0, 1, 56, 57, 58, 1000, 100000} {
+		data := make([]byte, size)
+		rand.Read(data) //nolint:errcheck
+
+		var want strings.Builder
+		encoded := base64.StdEncoding.EncodeToString(data)
+		for i := 0; i < len(encoded); i += 76 {
+			want.WriteString(encoded[i:min(i+76, len(encoded))] + "\r\n")
+		}
+
+		var got bytes.Buffer
+		// One-byte reads split lines across many writes.
+		if err := copyBase64Lines(&got, iotest.OneByteReader(bytes.NewReader(data))); err != nil {
+			t.Fatalf("copyBase64Lines(%d bytes) error = %v", size, err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("copyBase64Lines(%d bytes) = %q, want %q", size, got.String(), want.String())
+		}
+	}
+}
+
+func TestWriteOutgoingMessage(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	report := filepath.Join(dir, "report.pdf")
+	content := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("0123456789"), 5000)...)
+	if err := os.WriteFile(report, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err := writeOutgoingMessage(&buf, "to@example.com", "Report", "cc@example.com", "", "plain", "<p>html</p>", nil, fileAttachments([]string{report}),
+		mailHeader{Name: "In-Reply-To", Value: "<a@example.com>"})
+	if err != nil {
+		t.Fatalf("writeOutgoingMessage() error = %v", err)
+	}
+
+	msg, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	for name, want := range map[string]string{"To": "to@example.com", "Cc": "cc@example.com", "Subject": "Report", "In-Reply-To": "<a@example.com>"} {
+		if got := msg.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, want multipart/mixed", mediaType)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	if part, err := mr.NextPart(); err != nil || !strings.HasPrefix(part.Header.Get("Content-Type"), "multipart/alternative") {
+		t.Fatalf("first part = %v, %v, want multipart/alternative", part, err)
+	}
+	part, err := mr.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart() error = %v", err)
+	}
+	if got := part.Header.Get("Content-Type"); got != "application/pdf" {
+		t.Errorf("attachment Content-Type = %q, want application/pdf", got)
+	}
+	if got := part.FileName(); got != "report.pdf" {
+		t.Errorf("attachment filename = %q, want report.pdf", got)
+	}
+	data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+	if err != nil || !bytes.Equal(data, content) {
+		t.Errorf("attachment content differs (%d bytes, error %v), want %d bytes", len(data), err, len(content))
+	}
+
+	t.Run("should fail for a missing attachment", func(t *testing.T) {
+		t.Parallel()
+		err := writeOutgoingMessage(io.Discard, "to@example.com", "x", "", "", "plain", "<p>html</p>", nil, fileAttachments([]string{filepath.Join(dir, "missing.pdf")}))
+		if err == nil || !strings.Contains(err.Error(), "missing.pdf") {
+			t.Errorf("error = %v, want it to name the file", err)
+		}
+	})
+}
+
+func TestCheckAttachmentSize(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.txt")
+	if err := os.WriteFile(small, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A sparse file takes no disk space.
+	large := filepath.Join(dir, "large.bin")
+	if err := os.WriteFile(large, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(large, maxAttachmentSize-8); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkAttachmentSize([]string{small, large}, nil); err != nil {
+		t.Errorf("checkAttachmentSize() under the limit error = %v", err)
+	}
+	err := checkAttachmentSize([]string{small, large}, []inlineImage{{Data: []byte("logo.png")}})
+	if err == nil || !strings.Contains(err.Error(), "25 MB limit") {
+		t.Errorf("checkAttachmentSize() over the limit error = %v, want the 25 MB limit", err)
+	}
+	if err := checkAttachmentSize([]string{filepath.Join(dir, "missing")}, nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("checkAttachmentSize() with a missing file error = %v", err)
+	}
+}
+
+func TestE2ESendResumableUpload(t *testing.T) {
+	srv := newE2EServer(t)
+	old := sendUploadChunkSize
+	sendUploadChunkSize = googleapi.MinUploadChunkSize
+	t.Cleanup(func() { sendUploadChunkSize = old })
+
+	content := make([]byte, 600<<10)
+	rand.Read(content) //nolint:errcheck
+	data := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(data, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed chunk is retried rather than restarting the upload.
+	srv.FailUploadChunks(1)
+	mustRunCLI(t, "send", "--to", "bob@example.com", "-s", "Data", "-b", "Attached.", "--attach", data)
+
+	if got := srv.UploadTypes(); len(got) != 1 || got[0] != "resumable" {
+		t.Errorf("upload types = %v, want [resumable]", got)
+	}
+	sent := srv.MessageIDs("SENT")
+	if len(sent) != 1 {
+		t.Fatalf("SENT has %d messages, want 1", len(sent))
+	}
+	msg, _ := srv.Message(sent[0])
+	atts := findAttachments(msg.Payload.Parts)
+	if len(atts) != 1 || atts[0].Filename != "data.bin" {
+		t.Fatalf("attachments = %+v, want data.bin", atts)
+	}
+	out := filepath.Join(t.TempDir(), "out.bin")
+	mustRunCLI(t, "messages", "get-attachment", sent[0], atts[0].AttachmentId, "-o", out)
+	if got, err := os.ReadFile(out); err != nil || !bytes.Equal(got, content) {
+		t.Errorf("downloaded attachment differs from the file sent (%d bytes, error %v)", len(got), err)
+	}
+}
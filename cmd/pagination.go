@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+// gmailMaxPageSize is the largest page size the Gmail list endpoints accept.
+const gmailMaxPageSize = 500
+
+// pageOptions holds the pagination flags shared by the Gmail list commands.
+type pageOptions struct {
+	token string
+	all   bool
+	limit int64
+}
+
+// addPageFlags registers --page-token, --all and --limit on cmd.
+func addPageFlags(cmd *cobra.Command, opts *pageOptions) {
+	cmd.Flags().StringVar(&opts.token, "page-token", "", "Page token from a previous next_page_token to resume from")
+	cmd.Flags().BoolVar(&opts.all, "all", false, "Fetch every page of results")
+	cmd.Flags().Int64Var(&opts.limit, "limit", 0, "Fetch pages until this many results are collected (implies --all)")
+}
+
+// validate checks the pagination flags for consistency.
+func (o *pageOptions) validate() error {
+	if o.limit < 0 {
+		return fmt.Errorf("--limit must be positive")
+	}
+	return nil
+}
+
+// multiPage reports whether more than one page should be fetched.
+func (o *pageOptions) multiPage() bool {
+	return o.all || o.limit > 0
+}
+
+// pageSize returns the page size for the next request. A single-page listing
+// uses maxResults; multi-page listings use the largest page the API allows,
+// shrunk so that --limit is never overshot.
+func (o *pageOptions) pageSize(maxResults int64, collected int) int64 {
+	size := min(maxResults, gmailMaxPageSize)
+	if o.multiPage() {
+		size = gmailMaxPageSize
+	}
+	if o.limit > 0 {
+		size = min(size, o.limit-int64(collected))
+	}
+	return size
+}
+
+// done reports whether paging should stop after collecting this many results.
+func (o *pageOptions) done(collected int) bool {
+	if !o.multiPage() {
+		return true
+	}
+	return o.limit > 0 && int64(collected) >= o.limit
+}
+
+// pagingRequested reports whether any pagination flag was given to cmd.
+func pagingRequested(cmd *cobra.Command) bool {
+	for _, name := range []string{"page-token", "all", "limit"} {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// outputListJSON prints the items of a list command. By default they are a
+// bare JSON array, as the list commands printed before they could page, and a
+// next page token is reported on stderr. When paging was requested with a
+// pagination flag, the output is an object holding the items under key and
+// next_page_token.
+func outputListJSON(cmd *cobra.Command, key string, items interface{}, next string) error {
+	if pagingRequested(cmd) {
+		return outputJSON(map[string]interface{}{
+			key:               items,
+			"next_page_token": next,
+		})
+	}
+	if next != "" {
+		fmt.Fprintln(os.Stderr, nextPageHint(next))
+	}
+	return outputJSON(items)
+}
+
+// nextPageHint returns the text-mode message shown when more results exist.
+func nextPageHint(token string) string {
+	return fmt.Sprintf("More results available. Next page token: %s (use --page-token to continue, or --all)", token)
+}
+
+// listMessagePages runs a messages list call, following page tokens as
+// requested by opts. It returns the collected messages, the token for the
+// next unread page ("" if none), and the first page's result size estimate.
+func listMessagePages(ctx context.Context, call *gmail.UsersMessagesListCall, opts *pageOptions, maxResults int64) ([]*gmail.Message, string, int64, error) {
+	var messages []*gmail.Message
+	var next string
+	var estimate int64
+	first := true
+
+	if opts.token != "" {
+		call.PageToken(opts.token)
+	}
+	call.MaxResults(opts.pageSize(maxResults, 0))
+
+	err := call.Pages(ctx, func(page *gmail.ListMessagesResponse) error {
+		if first {
+			estimate = page.ResultSizeEstimate
+			first = false
+		}
+		messages = append(messages, page.Messages...)
+		next = page.NextPageToken
+		if opts.done(len(messages)) {
+			return errDone
+		}
+		// Pages reuses call for the next request, so shrink it to the remaining limit
+		call.MaxResults(opts.pageSize(maxResults, len(messages)))
+		return nil
+	})
+	if err != nil && err != errDone {
+		return nil, "", 0, err
+	}
+	return messages, next, estimate, nil
+}
+
+// listThreadPages runs a threads list call, following page tokens as
+// requested by opts. It returns the collected threads and the next page token.
+func listThreadPages(ctx context.Context, call *gmail.UsersThreadsListCall, opts *pageOptions, maxResults int64) ([]*gmail.Thread, string, error) {
+	var threads []*gmail.Thread
+	var next string
+
+	if opts.token != "" {
+		call.PageToken(opts.token)
+	}
+	call.MaxResults(opts.pageSize(maxResults, 0))
+
+	err := call.Pages(ctx, func(page *gmail.ListThreadsResponse) error {
+		threads = append(threads, page.Threads...)
+		next = page.NextPageToken
+		if opts.done(len(threads)) {
+			return errDone
+		}
+		call.MaxResults(opts.pageSize(maxResults, len(threads)))
+		return nil
+	})
+	if err != nil && err != errDone {
+		return nil, "", err
+	}
+	return threads, next, nil
+}
+
+// listDraftPages runs a drafts list call, following page tokens as
+// requested by opts. It returns the collected drafts and the next page token.
+func listDraftPages(ctx context.Context, call *gmail.UsersDraftsListCall, opts *pageOptions, maxResults int64) ([]*gmail.Draft, string, error) {
+	var drafts []*gmail.Draft
+	var next string
+
+	if opts.token != "" {
+		call.PageToken(opts.token)
+	}
+	call.MaxResults(opts.pageSize(maxResults, 0))
+
+	err := call.Pages(ctx, func(page *gmail.ListDraftsResponse) error {
+		drafts = append(drafts, page.Drafts...)
+		next = page.NextPageToken
+		if opts.done(len(drafts)) {
+			return errDone
+		}
+		call.MaxResults(opts.pageSize(maxResults, len(drafts)))
+		return nil
+	})
+	if err != nil && err != errDone {
+		return nil, "", err
+	}
+	return drafts, next, nil
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+func TestPageOptionsPageSize(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		opts       pageOptions
+		maxResults int64
+		collected  int
+		want       int64
+	}{
+		{name: "should use max results for a single page", opts: pageOptions{}, maxResults: 10, want: 10},
+		{name: "should cap single page at API maximum", opts: pageOptions{}, maxResults: 1000, want: 500},
+		{name: "should use API maximum with --all", opts: pageOptions{all: true}, maxResults: 10, want: 500},
+		{name: "should shrink last page to the remaining limit", opts: pageOptions{limit: 1200}, maxResults: 10, collected: 1000, want: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.opts.pageSize(tt.maxResults, tt.collected); got != tt.want {
+				t.Errorf("pageSize(%d, %d) = %d, want %d", tt.maxResults, tt.collected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageOptionsDone(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		opts      pageOptions
+		collected int
+		want      bool
+	}{
+		{name: "should stop after one page by default", opts: pageOptions{}, collected: 10, want: true},
+		{name: "should continue with --all", opts: pageOptions{all: true}, collected: 5000, want: false},
+		{name: "should continue below limit", opts: pageOptions{limit: 100}, collected: 99, want: false},
+		{name: "should stop at limit", opts: pageOptions{limit: 100}, collected: 100, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.opts.done(tt.collected); got != tt.want {
+				t.Errorf("done(%d) = %v, want %v", tt.collected, got, tt.want)
+			}
+		})
+	}
+}
+
+// newPagedMessagesServer serves total messages in pages sized by the
+// request's maxResults, using the message offset as the page token.
+func newPagedMessagesServer(t *testing.T, total int) *gmail.Service {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("maxResults"))
+		resp := gmail.ListMessagesResponse{ResultSizeEstimate: int64(total)}
+		end := min(start+size, total)
+		for i := start; i < end; i++ {
+			resp.Messages = append(resp.Messages, &gmail.Message{Id: fmt.Sprintf("m%d", i)})
+		}
+		if end < total {
+			resp.NextPageToken = strconv.Itoa(end)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp) //nolint:errcheck
+	}))
+	t.Cleanup(srv.Close)
+
+	service, err := gmail.NewService(context.Background(), option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("failed to create Gmail service: %v", err)
+	}
+	return service
+}
+
+func TestListMessagePages(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		opts       pageOptions
+		maxResults int64
+		wantCount  int
+		wantFirst  string
+		wantNext   string
+	}{
+		{name: "should fetch a single page", opts: pageOptions{}, maxResults: 10, wantCount: 10, wantFirst: "m0", wantNext: "10"},
+		{name: "should resume from page token", opts: pageOptions{token: "1195"}, maxResults: 10, wantCount: 5, wantFirst: "m1195", wantNext: ""},
+		{name: "should fetch every page with --all", opts: pageOptions{all: true}, maxResults: 10, wantCount: 1200, wantFirst: "m0", wantNext: ""},
+		{name: "should stop exactly at --limit", opts: pageOptions{limit: 700}, maxResults: 10, wantCount: 700, wantFirst: "m0", wantNext: "700"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			service := newPagedMessagesServer(t, 1200)
+
+			msgs, next, estimate, err := listMessagePages(context.Background(), service.Users.Messages.List("me"), &tt.opts, tt.maxResults)
+			if err != nil {
+				t.Fatalf("listMessagePages() error = %v", err)
+			}
+			if len(msgs) != tt.wantCount {
+				t.Errorf("got %d messages, want %d", len(msgs), tt.wantCount)
+			}
+			if len(msgs) > 0 && msgs[0].Id != tt.wantFirst {
+				t.Errorf("first message = %q, want %q", msgs[0].Id, tt.wantFirst)
+			}
+			if next != tt.wantNext {
+				t.Errorf("next page token = %q, want %q", next, tt.wantNext)
+			}
+			if estimate != 1200 {
+				t.Errorf("estimate = %d, want 1200", estimate)
+			}
+		})
+	}
+}
+
+func TestE2EListJSONPaging(t *testing.T) {
+	srv := newE2EServer(t)
+	for range 3 {
+		srv.AddMessage(e2eInboxMessage, "INBOX")
+	}
+
+	// Without a pagination flag the output stays a bare array.
+	var list []struct {
+		ID string `json:"id"`
+	}
+	out := mustRunCLI(t, "messages", "list", "-n", "2", "-f", "json")
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatalf("messages list output is not a JSON array: %v\n%s", err, out)
+	}
+	if len(list) != 2 {
+		t.Errorf("messages list -n 2 returned %d messages, want 2", len(list))
+	}
+
+	var page struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+		NextPageToken string `json:"next_page_token"`
+	}
+	out = mustRunCLI(t, "messages", "list", "--limit", "2", "-f", "json")
+	if err := json.Unmarshal([]byte(out), &page); err != nil {
+		t.Fatalf("messages list --limit output is not a JSON object: %v\n%s", err, out)
+	}
+	if len(page.Messages) != 2 || page.NextPageToken == "" {
+		t.Fatalf("messages list --limit 2 = %+v, want 2 messages and a next page token", page)
+	}
+
+	// An empty --page-token asks for the first page in the paged shape.
+	out = mustRunCLI(t, "messages", "list", "-n", "2", "--page-token", "", "-f", "json")
+	if err := json.Unmarshal([]byte(out), &page); err != nil {
+		t.Fatalf("messages list --page-token \"\" output is not a JSON object: %v\n%s", err, out)
+	}
+	if len(page.Messages) != 2 || page.NextPageToken == "" {
+		t.Fatalf("messages list --page-token \"\" = %+v, want 2 messages and a next page token", page)
+	}
+
+	out = mustRunCLI(t, "search", "in:inbox", "--page-token", page.NextPageToken, "-f", "json")
+	page.Messages, page.NextPageToken = nil, ""
+	if err := json.Unmarshal([]byte(out), &page); err != nil {
+		t.Fatalf("search --page-token output is not a JSON object: %v\n%s", err, out)
+	}
+	if len(page.Messages) != 1 || page.NextPageToken != "" {
+		t.Errorf("search --page-token = %+v, want the last message and no next page", page)
+	}
+}
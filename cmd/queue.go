@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// queueClaimTimeout is how long a draft claimed by a 'queue run' is left
+// alone by other runs. A run that crashed mid-send leaves its claim behind;
+// once it expires the next run tries the draft again, and Gmail's refusal to
+// send a draft twice tells it whether the first attempt went through.
+const queueClaimTimeout = 15 * time.Minute
+
+// queueTimeLayout is how queued send times are shown.
+const queueTimeLayout = "Mon Jan 02 2006 15:04 MST"
+
+// queueCmd represents the queue parent command
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Manage messages scheduled with 'send --at'",
+	Long: `Manage the local queue of messages scheduled with 'gsuite send --at'.
+
+Gmail's API has no scheduled send, so 'send --at' saves the message as a
+draft and records it in a queue in the gsuite config directory. 'queue run'
+sends the drafts that are due; run it regularly, for example every five
+minutes from cron:
+
+  */5 * * * * gsuite queue run
+
+Runs lock the queue while they claim drafts, so overlapping runs never send
+the same draft twice.`,
+}
+
+// queueRunCmd represents the queue run subcommand
+var queueRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Send the queued messages that are due",
+	Long: `Send every queued draft whose time has come.
+
+Drafts of all accounts are sent, each from its own account; use --account to
+send only one account's drafts. Nothing is printed when no message is due,
+which keeps cron quiet. A draft that fails to send stays queued and is tried
+again on the next run, and the command exits non-zero. A draft that no longer
+exists (it was sent or deleted in Gmail) is dropped from the queue.`,
+	Example: `  # Send what is due
+  gsuite queue run
+
+  # From cron, every five minutes
+  */5 * * * * gsuite queue run`,
+	Args: cobra.NoArgs,
+	RunE: runQueueRun,
+}
+
+// queueListCmd represents the queue list subcommand
+var queueListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List queued messages",
+	Long:  `List the messages waiting in the queue, soonest first.`,
+	Args:  cobra.NoArgs,
+	RunE:  runQueueList,
+}
+
+// queueCancelCmd represents the queue cancel subcommand
+var queueCancelCmd = &cobra.Command{
+	Use:   "cancel <draft-id>",
+	Short: "Remove a message from the queue",
+	Long: `Remove a scheduled message from the queue so it is not sent.
+
+The draft itself stays in Gmail's drafts, where it can be edited and sent by
+hand or deleted with 'gsuite drafts delete'.`,
+	Example: `  gsuite queue cancel r1234567890`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runQueueCancel,
+}
+
+func init() {
+	rootCmd.AddCommand(queueCmd)
+	queueCmd.AddCommand(queueRunCmd)
+	queueCmd.AddCommand(queueListCmd)
+	queueCmd.AddCommand(queueCancelCmd)
+}
+
+// scheduleSend saves the message that write produces as a draft and queues
+// it to be sent at the given time.
+func scheduleSend(service *gmail.Service, at time.Time, write func(io.Writer) error) error {
+	account, err := currentAccount(service)
+	if err != nil {
+		return err
+	}
+	draft, err := createDraftUpload(service, write)
+	if err != nil {
+		return fmt.Errorf("failed to create draft: %w", err)
+	}
+
+	entry := auth.QueuedSend{
+		DraftID:   draft.Id,
+		Account:   account,
+		To:        sendTo,
+		Subject:   sendSubject,
+		SendAt:    at,
+		CreatedAt: time.Now(),
+	}
+	err = auth.UpdateSendQueue(func(entries []auth.QueuedSend) ([]auth.QueuedSend, error) {
+		return append(entries, entry), nil
+	})
+	if err != nil {
+		return fmt.Errorf("draft %s was created but could not be queued: %w", draft.Id, err)
+	}
+
+	if GetOutputFormat() == "json" {
+		type scheduleResult struct {
+			DraftID string `json:"draft_id"`
+			Account string `json:"account"`
+			SendAt  string `json:"send_at"`
+		}
+		return outputJSON(scheduleResult{
+			DraftID: draft.Id,
+			Account: account,
+			SendAt:  at.Format(time.RFC3339),
+		})
+	}
+
+	fmt.Printf("Message scheduled for %s\nDraft ID: %s\n", at.Format(queueTimeLayout), draft.Id)
+	fmt.Println("It is sent by the first 'gsuite queue run' after that time.")
+	return nil
+}
+
+func runQueueRun(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+	account := GetAccountEmail()
+
+	// Claim the due drafts under the queue lock, so a concurrent run skips
+	// them, then send without holding the lock.
+	var claimed []auth.QueuedSend
+	err := auth.UpdateSendQueue(func(entries []auth.QueuedSend) ([]auth.QueuedSend, error) {
+		for i := range entries {
+			e := &entries[i]
+			if account != "" && !strings.EqualFold(e.Account, account) {
+				continue
+			}
+			if e.SendAt.After(now) || now.Sub(e.ClaimedAt) < queueClaimTimeout {
+				continue
+			}
+			e.ClaimedAt = now
+			claimed = append(claimed, *e)
+		}
+		return entries, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(claimed) == 0 {
+		if GetOutputFormat() == "json" {
+			return outputJSON([]interface{}{})
+		}
+		if GetVerbose() {
+			fmt.Fprintln(os.Stderr, "No scheduled messages are due")
+		}
+		return nil
+	}
+
+	type queueRunResult struct {
+		DraftID   string `json:"draft_id"`
+		Account   string `json:"account"`
+		To        string `json:"to"`
+		MessageID string `json:"message_id,omitempty"`
+		Error     string `json:"error,omitempty"`
+	}
+	ctx := context.Background()
+	services := make(map[string]*gmail.Service)
+	var results []queueRunResult
+	var sentCount, failed int
+	for _, e := range claimed {
+		result := queueRunResult{DraftID: e.DraftID, Account: e.Account, To: e.To}
+		sent, done, err := sendQueuedDraft(ctx, services, e)
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+			failed++
+			fmt.Fprintf(os.Stderr, "Warning: failed to send draft %s to %s: %v\n", e.DraftID, e.To, err)
+		case sent == nil:
+			fmt.Fprintf(os.Stderr, "Warning: draft %s no longer exists (sent or deleted in Gmail); removed it from the queue\n", e.DraftID)
+		default:
+			result.MessageID = sent.Id
+			sentCount++
+			if GetOutputFormat() != "json" {
+				fmt.Printf("Sent draft %s to %s as message %s\n", e.DraftID, e.To, sent.Id)
+			}
+		}
+		results = append(results, result)
+
+		if releaseErr := releaseQueuedSend(e, done); releaseErr != nil {
+			return releaseErr
+		}
+	}
+
+	if GetOutputFormat() == "json" {
+		if err := outputJSON(results); err != nil {
+			return err
+		}
+	} else if len(claimed) > 1 || failed > 0 {
+		fmt.Printf("Sent %d scheduled messages (%d failed)\n", sentCount, failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d scheduled messages failed to send; they stay queued for the next run", failed)
+	}
+	return nil
+}
+
+// sendQueuedDraft sends a claimed draft with the account it was queued
+// from, reusing services across drafts of the same account. done reports
+// whether the entry should leave the queue: the draft was sent, or it no
+// longer exists (sent is nil then).
+func sendQueuedDraft(ctx context.Context, services map[string]*gmail.Service, e auth.QueuedSend) (sent *gmail.Message, done bool, err error) {
+	service, ok := services[e.Account]
+	if !ok {
+		service, err = auth.NewGmailService(ctx, e.Account)
+		if err != nil {
+			return nil, false, fmt.Errorf("authentication failed: %w", err)
+		}
+		services[e.Account] = service
+	}
+
+	sent, err = service.Users.Drafts.Send("me", &gmail.Draft{Id: e.DraftID}).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return nil, true, nil
+		}
+		return nil, false, fmt.Errorf("Gmail API error: %w", err)
+	}
+	return sent, true, nil
+}
+
+// releaseQueuedSend ends a run's claim on e: it leaves the queue when done,
+// and otherwise waits for the next run.
+func releaseQueuedSend(e auth.QueuedSend, done bool) error {
+	return auth.UpdateSendQueue(func(entries []auth.QueuedSend) ([]auth.QueuedSend, error) {
+		for i, q := range entries {
+			if q.DraftID != e.DraftID || q.Account != e.Account {
+				continue
+			}
+			if done {
+				return slices.Delete(entries, i, i+1), nil
+			}
+			entries[i].ClaimedAt = time.Time{}
+			return entries, nil
+		}
+		return entries, nil
+	})
+}
+
+func runQueueList(cmd *cobra.Command, args []string) error {
+	entries, err := auth.LoadSendQueue()
+	if err != nil {
+		return err
+	}
+	account := GetAccountEmail()
+	entries = slices.DeleteFunc(entries, func(e auth.QueuedSend) bool {
+		return account != "" && !strings.EqualFold(e.Account, account)
+	})
+	slices.SortStableFunc(entries, func(a, b auth.QueuedSend) int {
+		return a.SendAt.Compare(b.SendAt)
+	})
+
+	now := time.Now()
+	status := func(e auth.QueuedSend) string {
+		switch {
+		case now.Sub(e.ClaimedAt) < queueClaimTimeout:
+			return "sending"
+		case !e.SendAt.After(now):
+			return "due"
+		default:
+			return "scheduled"
+		}
+	}
+
+	if GetOutputFormat() == "json" {
+		type queueEntryResult struct {
+			DraftID string `json:"draft_id"`
+			Account string `json:"account"`
+			To      string `json:"to"`
+			Subject string `json:"subject"`
+			SendAt  string `json:"send_at"`
+			Status  string `json:"status"`
+		}
+		results := make([]queueEntryResult, 0, len(entries))
+		for _, e := range entries {
+			results = append(results, queueEntryResult{
+				DraftID: e.DraftID,
+				Account: e.Account,
+				To:      e.To,
+				Subject: e.Subject,
+				SendAt:  e.SendAt.Format(time.RFC3339),
+				Status:  status(e),
+			})
+		}
+		return outputJSON(results)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No messages are queued.")
+		return nil
+	}
+	fmt.Printf("Queued messages (%d):\n\n", len(entries))
+	for _, e := range entries {
+		fmt.Printf("Draft ID: %s  [%s]\n", e.DraftID, status(e))
+		fmt.Printf("  Send at: %s\n", e.SendAt.Local().Format(queueTimeLayout))
+		fmt.Printf("  Account: %s\n", e.Account)
+		fmt.Printf("  To:      %s\n", e.To)
+		fmt.Printf("  Subject: %s\n\n", e.Subject)
+	}
+	return nil
+}
+
+func runQueueCancel(cmd *cobra.Command, args []string) error {
+	draftID := args[0]
+	account := GetAccountEmail()
+
+	var cancelled auth.QueuedSend
+	err := auth.UpdateSendQueue(func(entries []auth.QueuedSend) ([]auth.QueuedSend, error) {
+		for i, e := range entries {
+			if e.DraftID != draftID || (account != "" && !strings.EqualFold(e.Account, account)) {
+				continue
+			}
+			if time.Since(e.ClaimedAt) < queueClaimTimeout {
+				return nil, fmt.Errorf("draft %s is being sent by a running 'gsuite queue run'", draftID)
+			}
+			cancelled = e
+			return slices.Delete(entries, i, i+1), nil
+		}
+		return nil, fmt.Errorf("draft %s is not in the queue", draftID)
+	})
+	if err != nil {
+		return err
+	}
+
+	if GetOutputFormat() == "json" {
+		type queueCancelResult struct {
+			DraftID   string `json:"draft_id"`
+			Account   string `json:"account"`
+			Cancelled bool   `json:"cancelled"`
+		}
+		return outputJSON(queueCancelResult{
+			DraftID:   cancelled.DraftID,
+			Account:   cancelled.Account,
+			Cancelled: true,
+		})
+	}
+
+	fmt.Printf("Cancelled scheduled send of draft %s; it is still in your drafts.\n", draftID)
+	return nil
+}
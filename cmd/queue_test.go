@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+)
+
+// setQueuedSend changes the queued entry for draftID with fn.
+func setQueuedSend(t *testing.T, draftID string, fn func(e *auth.QueuedSend)) {
+	t.Helper()
+	err := auth.UpdateSendQueue(func(entries []auth.QueuedSend) ([]auth.QueuedSend, error) {
+		for i := range entries {
+			if entries[i].DraftID == draftID {
+				fn(&entries[i])
+			}
+		}
+		return entries, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestE2ESendAtAndQueueRun(t *testing.T) {
+	srv := newE2EServer(t)
+
+	if _, err := runCLI(t, "send", "-t", "bob@example.com", "-s", "Late", "-b", "x", "--at", "2020-01-01 09:00"); err == nil || !strings.Contains(err.Error(), "in the past") {
+		t.Errorf("send --at in the past error = %v", err)
+	}
+
+	out := mustRunCLI(t, "send", "-t", "bob@example.com", "-s", "Standup", "-b", "**Notes**", "--at", "tomorrow 09:00")
+	if !strings.Contains(out, "Message scheduled for") {
+		t.Errorf("send --at output = %q", out)
+	}
+	drafts := srv.DraftIDs()
+	if len(drafts) != 1 {
+		t.Fatalf("drafts = %v, want one", drafts)
+	}
+	if got := srv.MessageIDs("SENT"); len(got) != 0 {
+		t.Fatalf("send --at sent %d messages right away", len(got))
+	}
+	entries, err := auth.LoadSendQueue()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("queue = %v, %v, want one entry", entries, err)
+	}
+	if e := entries[0]; e.DraftID != drafts[0] || e.Account != srv.Email() || e.To != "bob@example.com" || e.SendAt.Hour() != 9 {
+		t.Errorf("queued entry = %+v", e)
+	}
+
+	out = mustRunCLI(t, "queue", "list")
+	if !strings.Contains(out, drafts[0]) || !strings.Contains(out, "[scheduled]") || !strings.Contains(out, "Standup") {
+		t.Errorf("queue list output = %q", out)
+	}
+
+	// Nothing is due yet.
+	if out := mustRunCLI(t, "queue", "run"); out != "" {
+		t.Errorf("queue run with nothing due printed %q", out)
+	}
+	if got := srv.MessageIDs("SENT"); len(got) != 0 {
+		t.Fatalf("queue run sent a draft before it was due")
+	}
+
+	// A draft another run is sending is left alone.
+	setQueuedSend(t, drafts[0], func(e *auth.QueuedSend) {
+		e.SendAt = time.Now().Add(-time.Minute)
+		e.ClaimedAt = time.Now()
+	})
+	mustRunCLI(t, "queue", "run")
+	if got := srv.MessageIDs("SENT"); len(got) != 0 {
+		t.Fatalf("queue run sent a draft claimed by another run")
+	}
+	if _, err := runCLI(t, "queue", "cancel", drafts[0]); err == nil || !strings.Contains(err.Error(), "being sent") {
+		t.Errorf("queue cancel of a claimed draft error = %v", err)
+	}
+
+	// Once the claim expires, the draft is sent.
+	setQueuedSend(t, drafts[0], func(e *auth.QueuedSend) {
+		e.ClaimedAt = time.Now().Add(-queueClaimTimeout - time.Minute)
+	})
+	out = mustRunCLI(t, "queue", "run")
+	if !strings.Contains(out, "Sent draft "+drafts[0]) {
+		t.Errorf("queue run output = %q", out)
+	}
+	sent := srv.MessageIDs("SENT")
+	if len(sent) != 1 {
+		t.Fatalf("SENT has %d messages, want 1", len(sent))
+	}
+	if msg, _ := srv.Message(sent[0]); headerValue(msg.Payload.Headers, "Subject") != "Standup" {
+		t.Errorf("sent subject = %q, want Standup", headerValue(msg.Payload.Headers, "Subject"))
+	}
+	if entries, _ := auth.LoadSendQueue(); len(entries) != 0 {
+		t.Errorf("queue after sending = %v, want empty", entries)
+	}
+	mustRunCLI(t, "queue", "run")
+	if got := srv.MessageIDs("SENT"); len(got) != 1 {
+		t.Errorf("second queue run: SENT has %d messages, want 1", len(got))
+	}
+}
+
+func TestE2EQueueStaleAndCancel(t *testing.T) {
+	srv := newE2EServer(t)
+
+	mustRunCLI(t, "send", "-t", "bob@example.com", "-s", "One", "-b", "x", "--at", "+1d 10:00")
+	mustRunCLI(t, "send", "-t", "carol@example.com", "-s", "Two", "-b", "x", "--at", "+1d 11:00")
+	drafts := srv.DraftIDs()
+	if len(drafts) != 2 {
+		t.Fatalf("drafts = %v, want two", drafts)
+	}
+
+	out := mustRunCLI(t, "queue", "cancel", drafts[0])
+	if !strings.Contains(out, "still in your drafts") {
+		t.Errorf("queue cancel output = %q", out)
+	}
+	if got := srv.DraftIDs(); len(got) != 2 {
+		t.Errorf("queue cancel removed the draft from Gmail")
+	}
+	if _, err := runCLI(t, "queue", "cancel", drafts[0]); err == nil || !strings.Contains(err.Error(), "not in the queue") {
+		t.Errorf("second queue cancel error = %v", err)
+	}
+
+	// A run that sent a draft and crashed before updating the queue leaves
+	// a stale claim; the next run must not send the message again.
+	mustRunCLI(t, "drafts", "send", drafts[1])
+	setQueuedSend(t, drafts[1], func(e *auth.QueuedSend) {
+		e.SendAt = time.Now().Add(-time.Hour)
+		e.ClaimedAt = time.Now().Add(-time.Hour)
+	})
+	mustRunCLI(t, "queue", "run")
+	if got := srv.MessageIDs("SENT"); len(got) != 1 {
+		t.Errorf("SENT has %d messages, want 1", len(got))
+	}
+	if entries, _ := auth.LoadSendQueue(); len(entries) != 0 {
+		t.Errorf("queue = %v, want the missing draft dropped", entries)
+	}
+}
@@ -72,3 +72,14 @@ func outputJSON(v interface{}) error {
 	fmt.Println(string(jsonBytes))
 	return nil
 }
+
+// outputNDJSON marshals v as compact JSON and prints it to stdout as one
+// line of a newline-delimited JSON stream.
+func outputNDJSON(v interface{}) error {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(jsonBytes))
+	return nil
+}
@@ -3,15 +3,16 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/khang/google-suite-cli/internal/auth"
 	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
 )
 
 var (
 	searchMaxResults int64
 	searchLabelIDs   string
+	searchPages      pageOptions
 )
 
 // searchCmd represents the search command
@@ -20,11 +21,18 @@ var searchCmd = &cobra.Command{
 	Short: "Search Gmail messages using Gmail query syntax",
 	Long: `Search Gmail messages using Gmail's powerful query syntax.
 
+One page is fetched unless --page-token, --all or --limit is given. With
+-f json the results are an array, and the next page token is only shown on
+stderr; any of those flags makes the output an object with "messages" and
+"next_page_token" instead. Use --page-token "" to get the first page that way.
+
 Examples:
   gsuite search "from:user@example.com"
   gsuite search "subject:meeting" --max-results 20
   gsuite search "is:unread" --label-ids INBOX
   gsuite search "newer_than:1d"
+  gsuite search "from:billing@example.com" --all
+  gsuite search "label:newsletters" --limit 1000
 
 Query syntax supports operators like:
   from:, to:, subject:, has:attachment, is:unread, is:starred,
@@ -37,8 +45,10 @@ See https://support.google.com/mail/answer/7190 for full query syntax.`,
 
 func init() {
 	rootCmd.AddCommand(searchCmd)
-	searchCmd.Flags().Int64VarP(&searchMaxResults, "max-results", "n", 10, "Maximum number of results (1-500)")
-	searchCmd.Flags().StringVar(&searchLabelIDs, "label-ids", "", "Comma-separated label IDs to filter by")
+	searchCmd.Flags().Int64VarP(&searchMaxResults, "max-results", "n", 10, "Maximum number of results per page (1-500)")
+	searchCmd.Flags().StringVar(&searchLabelIDs, "label-ids", "", "Comma-separated label names or IDs to filter by")
+	addPageFlags(searchCmd, &searchPages)
+	addConcurrencyFlag(searchCmd)
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
@@ -47,6 +57,12 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	if searchMaxResults < 1 || searchMaxResults > 500 {
 		return fmt.Errorf("--max-results must be between 1 and 500")
 	}
+	if err := searchPages.validate(); err != nil {
+		return err
+	}
+	if err := validateConcurrency(detailConcurrency); err != nil {
+		return err
+	}
 
 	ctx := context.Background()
 
@@ -56,28 +72,27 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build the list request
-	listReq := service.Users.Messages.List("me").Q(query).MaxResults(searchMaxResults)
+	listReq := service.Users.Messages.List("me").Q(query)
 
 	// Add label filter if provided
 	if searchLabelIDs != "" {
-		labelList := strings.Split(searchLabelIDs, ",")
+		labelList, err := newLabelResolver(service).Resolve(splitCommaList(searchLabelIDs))
+		if err != nil {
+			return err
+		}
 		listReq = listReq.LabelIds(labelList...)
 	}
 
-	// Execute the search
-	resp, err := listReq.Do()
+	// Execute the search, following pages if requested
+	messages, nextPageToken, estimate, err := listMessagePages(ctx, listReq, &searchPages, searchMaxResults)
 	if err != nil {
 		return fmt.Errorf("Gmail API error: %w", err)
 	}
 
-	// Handle empty results
-	if len(resp.Messages) == 0 {
-		if GetOutputFormat() == "json" {
-			return outputJSON([]struct{}{})
-		}
-		fmt.Println("No messages found matching query")
-		return nil
-	}
+	// Fetch message headers in parallel, preserving result order
+	details, detailErrs := fetchConcurrently(len(messages), detailConcurrency, func(i int) (*gmail.Message, error) {
+		return service.Users.Messages.Get("me", messages[i].Id).Format("metadata").MetadataHeaders("From", "Subject", "Date").Do()
+	})
 
 	// JSON output mode
 	if GetOutputFormat() == "json" {
@@ -89,20 +104,20 @@ func runSearch(cmd *cobra.Command, args []string) error {
 			Subject  string `json:"subject"`
 			Snippet  string `json:"snippet"`
 		}
-		var results []searchResult
-		for _, msg := range resp.Messages {
-			fullMsg, err := service.Users.Messages.Get("me", msg.Id).Format("metadata").MetadataHeaders("From", "Subject", "Date").Do()
-			if err != nil {
+		results := []searchResult{}
+		for i, msg := range messages {
+			if detailErrs[i] != nil {
 				results = append(results, searchResult{ID: msg.Id, ThreadID: msg.ThreadId})
 				continue
 			}
+			fullMsg := details[i]
 			var from, subject, date string
 			for _, header := range fullMsg.Payload.Headers {
 				switch header.Name {
 				case "From":
-					from = header.Value
+					from = decodeHeader(header.Value)
 				case "Subject":
-					subject = header.Value
+					subject = decodeHeader(header.Value)
 				case "Date":
 					date = header.Value
 				}
@@ -116,25 +131,30 @@ func runSearch(cmd *cobra.Command, args []string) error {
 				Snippet:  fullMsg.Snippet,
 			})
 		}
-		return outputJSON(results)
+		return outputListJSON(cmd, "messages", results, nextPageToken)
+	}
+
+	// Handle empty results
+	if len(messages) == 0 {
+		fmt.Println("No messages found matching query")
+		return nil
 	}
 
 	// Fetch and display each message (text mode)
-	for i, msg := range resp.Messages {
-		// Fetch full message details
-		fullMsg, err := service.Users.Messages.Get("me", msg.Id).Format("metadata").MetadataHeaders("From", "Subject", "Date").Do()
-		if err != nil {
-			return fmt.Errorf("failed to fetch message %s: %w", msg.Id, err)
+	for i, msg := range messages {
+		if detailErrs[i] != nil {
+			return fmt.Errorf("failed to fetch message %s: %w", msg.Id, detailErrs[i])
 		}
+		fullMsg := details[i]
 
 		// Extract headers
 		var from, subject, date string
 		for _, header := range fullMsg.Payload.Headers {
 			switch header.Name {
 			case "From":
-				from = header.Value
+				from = decodeHeader(header.Value)
 			case "Subject":
-				subject = header.Value
+				subject = decodeHeader(header.Value)
 			case "Date":
 				date = header.Value
 			}
@@ -157,7 +177,10 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Snippet: %s\n", snippet)
 	}
 
-	fmt.Printf("\n[Showing %d of %d estimated results]\n", len(resp.Messages), resp.ResultSizeEstimate)
+	fmt.Printf("\n[Showing %d of %d estimated results]\n", len(messages), estimate)
+	if nextPageToken != "" {
+		fmt.Println(nextPageHint(nextPageToken))
+	}
 
 	return nil
 }
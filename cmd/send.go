@@ -3,22 +3,24 @@ package cmd
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
 	"fmt"
 	"html"
+	"io"
 	"mime/multipart"
-	"net/http"
 	"net/textproto"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/khang/google-suite-cli/internal/auth"
 	"github.com/spf13/cobra"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
 	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
 	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
 )
 
 var (
@@ -29,6 +31,9 @@ var (
 	sendCc      string
 	sendBcc     string
 	sendAttach  []string
+	sendFrom    string
+	sendSig     bool
+	sendAt      string
 )
 
 // sendCmd represents the send command
@@ -41,7 +46,22 @@ Composes and sends an email with the specified recipient, subject, and body.
 The body is sent as both plain text and HTML for best rendering across clients.
 The body supports markdown formatting (bold, italic, links, lists, code, etc.)
 which is rendered as HTML for recipients. Use \n in the body for line breaks.
-Optionally include CC and BCC recipients. Supports file attachments via --attach.`,
+Images that refer to local files, like ![logo](./logo.png), are embedded in
+the message and shown inline.
+Optionally include CC and BCC recipients. Supports file attachments via --attach,
+up to Gmail's limit of 25 MB in total. Attachments are streamed from disk while
+the message uploads, and large messages use a resumable upload that retries
+interrupted chunks.
+
+Use --from to send from a verified send-as alias (see 'gsuite settings sendas
+list') and --signature to append that alias's signature. Set GSUITE_SIGNATURE=1
+to append the signature by default.
+
+Use --at to send the message later. Gmail has no scheduled send in its API,
+so the message is saved as a draft and added to a local queue; run 'gsuite
+queue run' regularly (for example from cron) to send queued drafts once they
+are due. Times are in local time, like "tomorrow 09:00", "friday 14:30" or
+"2026-03-15 08:00".`,
 	Example: `  # Send a simple email
   gsuite send --to "recipient@example.com" --subject "Hello" --body "Message content"
 
@@ -54,8 +74,17 @@ Optionally include CC and BCC recipients. Supports file attachments via --attach
   # Send with CC and BCC
   gsuite send -t "recipient@example.com" -s "Meeting" -b "See you there" --cc "cc@example.com" --bcc "bcc@example.com"
 
+  # Send a report with an inline chart
+  gsuite send -t "team@domain.com" -s "Weekly report" -b "Signups are up:\n\n![signups](./signups.png)"
+
   # Send with file attachments
-  gsuite send -t "user@domain.com" -s "Report" -b "See attached.\n\nThanks" --attach report.pdf --attach data.csv`,
+  gsuite send -t "user@domain.com" -s "Report" -b "See attached.\n\nThanks" --attach report.pdf --attach data.csv
+
+  # Send from an alias with its signature
+  gsuite send -t "user@domain.com" -s "Ticket update" -b "Fixed." --from "support@example.com" --signature
+
+  # Send tomorrow morning (needs 'gsuite queue run' to be scheduled)
+  gsuite send -t "team@domain.com" -s "Standup notes" -b "See below." --at "tomorrow 09:00"`,
 	RunE: runSend,
 }
 
@@ -76,9 +105,24 @@ func init() {
 	sendCmd.Flags().StringVar(&sendCc, "cc", "", "CC recipients (comma-separated)")
 	sendCmd.Flags().StringVar(&sendBcc, "bcc", "", "BCC recipients (comma-separated)")
 	sendCmd.Flags().StringArrayVarP(&sendAttach, "attach", "a", nil, "File path to attach (can be specified multiple times)")
+	addSenderFlags(sendCmd, &sendFrom, &sendSig)
+	sendCmd.Flags().StringVar(&sendAt, "at", "", "Queue the message to send at this time instead of now (e.g. \"tomorrow 09:00\")")
 }
 
 func runSend(cmd *cobra.Command, args []string) error {
+	var at time.Time
+	if sendAt != "" {
+		now := time.Now()
+		t, err := parseDateTime(sendAt, time.Local, now)
+		if err != nil {
+			return fmt.Errorf("invalid --at: %w", err)
+		}
+		if !t.After(now) {
+			return fmt.Errorf("--at %s is in the past", t.Format("2006-01-02 15:04"))
+		}
+		at = t
+	}
+
 	ctx := context.Background()
 
 	service, err := auth.NewGmailService(ctx, GetAccountEmail())
@@ -93,27 +137,28 @@ func runSend(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	body := interpretEscapes(sendBody)
+	extra, signature, err := resolveSender(service, sendFrom, useSignature(cmd, sendSig))
+	if err != nil {
+		return err
+	}
 
-	var rawMessage []byte
-	var buildErr error
-	if len(sendAttach) > 0 {
-		rawMessage, buildErr = buildMultipartMessage(sendTo, sendSubject, body, sendCc, sendBcc, sendAttach)
-	} else {
-		rawMessage, buildErr = buildSendRFC2822Message(sendTo, sendSubject, body, sendCc, sendBcc)
+	plainBody, htmlBody, images, err := composeBodies(interpretEscapes(sendBody), signature, "")
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
 	}
-	if buildErr != nil {
-		return fmt.Errorf("failed to build message: %w", buildErr)
+	if err := checkAttachmentSize(sendAttach, images); err != nil {
+		return err
 	}
-	encodedMessage := base64.URLEncoding.EncodeToString(rawMessage)
 
-	// Create the Gmail message object
-	gmailMessage := &gmail.Message{
-		Raw: encodedMessage,
+	write := func(w io.Writer) error {
+		return writeOutgoingMessage(w, sendTo, sendSubject, sendCc, sendBcc, plainBody, htmlBody, images, fileAttachments(sendAttach), extra...)
+	}
+	if !at.IsZero() {
+		return scheduleSend(service, at, write)
 	}
 
-	// Send the message
-	sent, err := service.Users.Messages.Send("me", gmailMessage).Do()
+	// Send the message, streaming it to the upload as it is built
+	sent, err := sendMessageUpload(service, "", write)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
@@ -132,13 +177,88 @@ func runSend(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// buildSendRFC2822Message constructs an RFC 2822 message with multipart/alternative body.
-func buildSendRFC2822Message(to, subject, body, cc, bcc string) ([]byte, error) {
-	altBody, boundary, err := buildAlternativeBody(body)
-	if err != nil {
-		return nil, err
+// maxAttachmentSize is Gmail's limit on the total size of a message's
+// attachments.
+const maxAttachmentSize = 25 << 20
+
+// sendUploadChunkSize is the size of each request of a resumable upload.
+// Messages up to this size are sent in a single request.
+var sendUploadChunkSize = googleapi.DefaultUploadChunkSize
+
+// checkAttachmentSize returns an error if the files at attachPaths and the
+// inline images together exceed Gmail's attachment size limit.
+func checkAttachmentSize(attachPaths []string, images []inlineImage) error {
+	var total int64
+	for _, path := range attachPaths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("attachment file not found: %s", path)
+		}
+		total += info.Size()
+	}
+	for _, img := range images {
+		total += int64(len(img.Data))
+	}
+	if total > maxAttachmentSize {
+		return fmt.Errorf("attachments total %.1f MB, over Gmail's %d MB limit; share large files from Google Drive instead",
+			float64(total)/(1<<20), maxAttachmentSize>>20)
+	}
+	return nil
+}
+
+// sendMessageUpload sends the message that write produces as a media upload,
+// in threadID if it is not empty. The message is streamed to the upload as
+// it is written instead of being built in memory first. Messages larger than
+// sendUploadChunkSize use a resumable upload, which retries a failed chunk
+// rather than starting over.
+func sendMessageUpload(service *gmail.Service, threadID string, write func(io.Writer) error) (*gmail.Message, error) {
+	pr := messagePipe(write)
+	defer pr.Close()
+
+	return service.Users.Messages.Send("me", &gmail.Message{ThreadId: threadID}).
+		Media(pr, googleapi.ContentType("message/rfc822"), googleapi.ChunkSize(sendUploadChunkSize)).
+		Do()
+}
+
+// createDraftUpload creates a draft of the message that write produces, as
+// a media upload like sendMessageUpload.
+func createDraftUpload(service *gmail.Service, write func(io.Writer) error) (*gmail.Draft, error) {
+	pr := messagePipe(write)
+	defer pr.Close()
+
+	return service.Users.Drafts.Create("me", &gmail.Draft{Message: &gmail.Message{}}).
+		Media(pr, googleapi.ContentType("message/rfc822"), googleapi.ChunkSize(sendUploadChunkSize)).
+		Do()
+}
+
+// messagePipe returns a reader of what write produces, written as it is
+// read. Close the reader to unblock the writer if the upload stops before
+// reading everything.
+func messagePipe(write func(io.Writer) error) *io.PipeReader {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(write(pw))
+	}()
+	return pr
+}
+
+// mailHeader is an additional top-level header (e.g. In-Reply-To) written by
+// the message builders after the Subject line.
+type mailHeader struct {
+	Name  string
+	Value string
+}
+
+// writeExtraHeaders appends extra headers to buf in RFC 2822 form.
+func writeExtraHeaders(buf *bytes.Buffer, extra []mailHeader) {
+	for _, h := range extra {
+		buf.WriteString(fmt.Sprintf("%s: %s\r\n", h.Name, h.Value))
 	}
+}
 
+// buildAlternativeMessage constructs an RFC 2822 message whose body is the
+// given multipart/alternative content.
+func buildAlternativeMessage(to, subject, cc, bcc string, altBody []byte, boundary string, extra ...mailHeader) []byte {
 	var header bytes.Buffer
 	header.WriteString(fmt.Sprintf("To: %s\r\n", to))
 	if cc != "" {
@@ -148,6 +268,7 @@ func buildSendRFC2822Message(to, subject, body, cc, bcc string) ([]byte, error)
 		header.WriteString(fmt.Sprintf("Bcc: %s\r\n", bcc))
 	}
 	header.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	writeExtraHeaders(&header, extra)
 	header.WriteString("MIME-Version: 1.0\r\n")
 	header.WriteString(fmt.Sprintf("Content-Type: multipart/alternative; boundary=%s\r\n", boundary))
 	header.WriteString("\r\n")
@@ -156,90 +277,7 @@ func buildSendRFC2822Message(to, subject, body, cc, bcc string) ([]byte, error)
 	result.Write(header.Bytes())
 	result.Write(altBody)
 
-	return result.Bytes(), nil
-}
-
-// buildMultipartMessage constructs a MIME multipart/mixed message with
-// a multipart/alternative body (text + HTML) and file attachments.
-func buildMultipartMessage(to, subject, body, cc, bcc string, attachPaths []string) ([]byte, error) {
-	var buf bytes.Buffer
-	mixedWriter := multipart.NewWriter(&buf)
-
-	// Write top-level headers
-	var headerBuf bytes.Buffer
-	headerBuf.WriteString(fmt.Sprintf("To: %s\r\n", to))
-	if cc != "" {
-		headerBuf.WriteString(fmt.Sprintf("Cc: %s\r\n", cc))
-	}
-	if bcc != "" {
-		headerBuf.WriteString(fmt.Sprintf("Bcc: %s\r\n", bcc))
-	}
-	headerBuf.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
-	headerBuf.WriteString("MIME-Version: 1.0\r\n")
-	headerBuf.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%s\r\n", mixedWriter.Boundary()))
-	headerBuf.WriteString("\r\n")
-
-	// Nest multipart/alternative as the first part of multipart/mixed
-	altBody, altBoundary, err := buildAlternativeBody(body)
-	if err != nil {
-		return nil, err
-	}
-
-	altHeader := make(textproto.MIMEHeader)
-	altHeader.Set("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%s", altBoundary))
-	altPart, err := mixedWriter.CreatePart(altHeader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create alternative part: %w", err)
-	}
-	if _, err := altPart.Write(altBody); err != nil {
-		return nil, fmt.Errorf("failed to write alternative body: %w", err)
-	}
-
-	// Write attachment parts
-	for _, attachPath := range attachPaths {
-		fileData, err := os.ReadFile(attachPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read attachment %s: %w", attachPath, err)
-		}
-
-		sniffLen := 512
-		if len(fileData) < sniffLen {
-			sniffLen = len(fileData)
-		}
-		mimeType := http.DetectContentType(fileData[:sniffLen])
-
-		basename := filepath.Base(attachPath)
-		attachHeader := make(textproto.MIMEHeader)
-		attachHeader.Set("Content-Type", mimeType)
-		attachHeader.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", basename))
-		attachHeader.Set("Content-Transfer-Encoding", "base64")
-
-		attachPart, err := mixedWriter.CreatePart(attachHeader)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create attachment part: %w", err)
-		}
-
-		encoded := base64.StdEncoding.EncodeToString(fileData)
-		for i := 0; i < len(encoded); i += 76 {
-			end := i + 76
-			if end > len(encoded) {
-				end = len(encoded)
-			}
-			if _, err := attachPart.Write([]byte(encoded[i:end] + "\r\n")); err != nil {
-				return nil, fmt.Errorf("failed to write attachment data: %w", err)
-			}
-		}
-	}
-
-	if err := mixedWriter.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
-	}
-
-	var result bytes.Buffer
-	result.Write(headerBuf.Bytes())
-	result.Write(buf.Bytes())
-
-	return result.Bytes(), nil
+	return result.Bytes()
 }
 
 // interpretEscapes converts literal \n, \t, and \\ sequences to their real characters.
@@ -275,21 +313,73 @@ func interpretEscapes(s string) string {
 // plainTextToHTML renders markdown-formatted text into an HTML document.
 // Supports GFM extensions: bold, italic, strikethrough, links, lists, code, tables.
 func plainTextToHTML(text string) string {
-	md := goldmark.New(
+	return "<!DOCTYPE html><html><body>" + markdownToHTMLFragment(text) + "</body></html>"
+}
+
+// markdownToHTMLFragment renders markdown-formatted text into an HTML fragment
+// without the surrounding document. Falls back to escaped text with <br> line
+// breaks if rendering fails.
+func markdownToHTMLFragment(text string) string {
+	return renderMarkdown(text, nil)
+}
+
+// renderMarkdown is markdownToHTMLFragment that, when images is not nil,
+// also embeds the local images the text refers to.
+func renderMarkdown(text string, images *inlineImageCollector) string {
+	options := []goldmark.Option{
 		goldmark.WithExtensions(extension.GFM),
 		goldmark.WithRendererOptions(gmhtml.WithHardWraps()),
-	)
+	}
+	if images != nil {
+		options = append(options, goldmark.WithParserOptions(
+			parser.WithASTTransformers(util.Prioritized(images, 1000)),
+		))
+	}
+	md := goldmark.New(options...)
 	var buf bytes.Buffer
 	if err := md.Convert([]byte(text), &buf); err != nil {
 		escaped := html.EscapeString(text)
-		return "<!DOCTYPE html><html><body>" + strings.ReplaceAll(escaped, "\n", "<br>\n") + "</body></html>"
+		return strings.ReplaceAll(escaped, "\n", "<br>\n")
+	}
+	return buf.String()
+}
+
+// composeBodies returns the text/plain and text/html bodies of an outgoing
+// message: the markdown body, then the HTML signature if there is one, then
+// quoted markdown (e.g. the original message of a reply) if there is any.
+// Local images in body are returned to be embedded; those in quoted text,
+// which someone else wrote, are not.
+func composeBodies(body, signatureHTML, quoted string) (string, string, []inlineImage, error) {
+	return composeBodiesIn("", body, signatureHTML, quoted)
+}
+
+// composeBodiesIn is composeBodies with relative image paths resolved
+// against imageDir instead of the current directory.
+func composeBodiesIn(imageDir, body, signatureHTML, quoted string) (string, string, []inlineImage, error) {
+	images := newInlineImageCollector(imageDir)
+	plainBody := body
+	htmlBody := renderMarkdown(body, images)
+	if images.err != nil {
+		return "", "", nil, images.err
+	}
+	if signatureHTML != "" {
+		plainBody = appendPlainSignature(body, signatureHTML)
+		htmlBody += "<br>\n<div class=\"gmail_signature\">" + signatureHTML + "</div>\n"
+	}
+	if quoted != "" {
+		plainBody += "\n\n" + quoted
+		if signatureHTML != "" {
+			htmlBody += "<br>\n"
+		}
+		htmlBody += markdownToHTMLFragment(quoted)
 	}
-	return "<!DOCTYPE html><html><body>" + buf.String() + "</body></html>"
+	return plainBody, "<!DOCTYPE html><html><body>" + htmlBody + "</body></html>", images.images, nil
 }
 
-// buildAlternativeBody returns the raw bytes and boundary of a multipart/alternative
-// containing text/plain and text/html parts.
-func buildAlternativeBody(body string) ([]byte, string, error) {
+// buildAlternativeParts returns the raw bytes and boundary of a multipart/alternative
+// containing the given text/plain and text/html content. When the HTML embeds
+// images, it is sent as multipart/related with the images after it.
+func buildAlternativeParts(plainBody, htmlBody string, images ...inlineImage) ([]byte, string, error) {
 	var buf bytes.Buffer
 	altWriter := multipart.NewWriter(&buf)
 
@@ -299,18 +389,46 @@ func buildAlternativeBody(body string) ([]byte, string, error) {
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to create text/plain part: %w", err)
 	}
-	if _, err := plainPart.Write([]byte(body)); err != nil {
+	if _, err := plainPart.Write([]byte(plainBody)); err != nil {
 		return nil, "", fmt.Errorf("failed to write text/plain body: %w", err)
 	}
 
-	htmlHeader := make(textproto.MIMEHeader)
-	htmlHeader.Set("Content-Type", "text/html; charset=UTF-8")
-	htmlPart, err := altWriter.CreatePart(htmlHeader)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to create text/html part: %w", err)
-	}
-	if _, err := htmlPart.Write([]byte(plainTextToHTML(body))); err != nil {
-		return nil, "", fmt.Errorf("failed to write text/html body: %w", err)
+	if len(images) == 0 {
+		if err := writeHTMLPart(altWriter, htmlBody); err != nil {
+			return nil, "", err
+		}
+	} else {
+		relatedHeader := make(textproto.MIMEHeader)
+		var related bytes.Buffer
+		relatedWriter := multipart.NewWriter(&related)
+		relatedHeader.Set("Content-Type", fmt.Sprintf("multipart/related; type=\"text/html\"; boundary=%s", relatedWriter.Boundary()))
+		if err := writeHTMLPart(relatedWriter, htmlBody); err != nil {
+			return nil, "", err
+		}
+		for _, img := range images {
+			imgHeader := make(textproto.MIMEHeader)
+			imgHeader.Set("Content-Type", img.MimeType)
+			imgHeader.Set("Content-ID", "<"+img.ContentID+">")
+			imgHeader.Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", img.Filename))
+			imgHeader.Set("Content-Transfer-Encoding", "base64")
+			imgPart, err := relatedWriter.CreatePart(imgHeader)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to create inline image part: %w", err)
+			}
+			if err := copyBase64Lines(imgPart, bytes.NewReader(img.Data)); err != nil {
+				return nil, "", fmt.Errorf("failed to write inline image data: %w", err)
+			}
+		}
+		if err := relatedWriter.Close(); err != nil {
+			return nil, "", fmt.Errorf("failed to close related writer: %w", err)
+		}
+		relatedPart, err := altWriter.CreatePart(relatedHeader)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create related part: %w", err)
+		}
+		if _, err := relatedPart.Write(related.Bytes()); err != nil {
+			return nil, "", fmt.Errorf("failed to write related body: %w", err)
+		}
 	}
 
 	boundary := altWriter.Boundary()
@@ -320,3 +438,17 @@ func buildAlternativeBody(body string) ([]byte, string, error) {
 
 	return buf.Bytes(), boundary, nil
 }
+
+// writeHTMLPart adds a text/html part with htmlBody to w.
+func writeHTMLPart(w *multipart.Writer, htmlBody string) error {
+	htmlHeader := make(textproto.MIMEHeader)
+	htmlHeader.Set("Content-Type", "text/html; charset=UTF-8")
+	htmlPart, err := w.CreatePart(htmlHeader)
+	if err != nil {
+		return fmt.Errorf("failed to create text/html part: %w", err)
+	}
+	if _, err := htmlPart.Write([]byte(htmlBody)); err != nil {
+		return fmt.Errorf("failed to write text/html body: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,582 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+const (
+	// mergeProgressSuffix is appended to the data file path for the default
+	// progress file.
+	mergeProgressSuffix = ".merge.jsonl"
+	// mergeResultsSuffix is appended to the data file path for the default
+	// results file.
+	mergeResultsSuffix = ".results.csv"
+)
+
+// mergeTemplateHeaders are the header lines a merge template may start with.
+var mergeTemplateHeaders = []string{"Subject", "Cc", "Bcc"}
+
+var (
+	// sendMergeCmd flags
+	sendMergeTemplate   string
+	sendMergeData       string
+	sendMergeSubject    string
+	sendMergeDryRun     bool
+	sendMergePreviewDir string
+	sendMergeRate       int
+	sendMergeProgress   string
+	sendMergeResults    string
+	sendMergeFrom       string
+	sendMergeSig        bool
+)
+
+// sendMergeCmd represents the send merge command
+var sendMergeCmd = &cobra.Command{
+	Use:   "merge",
+	Short: "Send a personalized message to every row of a CSV or JSON file",
+	Long: `Send one message per row of a data file, rendering the subject and body of
+a markdown template with Go text/template.
+
+The template file starts with a Subject line, optionally followed by Cc and
+Bcc lines, then a blank line and the markdown body:
+
+  Subject: Welcome to the team, {{.first_name}}!
+  Cc: onboarding@example.com
+
+  Hi {{.first_name}},
+
+  Your first day is **{{.start_date}}**. {{if .buddy}}Your buddy is {{.buddy}}.{{end}}
+
+Each row's fields are available by column name. Referring to a column the
+data does not have is an error. --subject replaces the template's Subject line.
+
+The data is a CSV file with a header row, or a JSON array of objects (.json).
+These columns have a special meaning:
+  to      the recipient (required)
+  cc      more Cc recipients for this row
+  bcc     more Bcc recipients for this row
+  attach  files to attach, separated by ";" (or a JSON array), relative to
+          the data file
+
+Every row is rendered and checked before anything is sent. Bodies go through
+the same markdown rendering as 'send', including inline local images, whose
+paths are relative to the template file.
+
+Use --dry-run to write each message to an .eml file in --preview-dir
+instead of sending it. Messages are sent at most --rate per minute. Each sent
+message is recorded in a progress file, so re-running the same merge after an
+interruption or failure only sends what has not been sent. At the end, a CSV
+of every row's status and message ID is written to --results.`,
+	Example: `  # Preview the onboarding mails
+  gsuite send merge --template welcome.md --data hires.csv --dry-run
+
+  # Send them, at most 10 per minute
+  gsuite send merge --template welcome.md --data hires.csv --rate 10
+
+  # Use a JSON data file and a different subject
+  gsuite send merge --template welcome.md --data hires.json --subject "Day one: {{.start_date}}"`,
+	Args: cobra.NoArgs,
+	RunE: runSendMerge,
+}
+
+func init() {
+	sendCmd.AddCommand(sendMergeCmd)
+
+	// sendMergeCmd flags
+	sendMergeCmd.Flags().StringVar(&sendMergeTemplate, "template", "", "Markdown template file (required)")
+	sendMergeCmd.Flags().StringVar(&sendMergeData, "data", "", "CSV or JSON data file with one row per message (required)")
+	sendMergeCmd.Flags().StringVarP(&sendMergeSubject, "subject", "s", "", "Subject template (default: the template's Subject line)")
+	sendMergeCmd.Flags().BoolVar(&sendMergeDryRun, "dry-run", false, "Write .eml previews instead of sending")
+	sendMergeCmd.Flags().StringVar(&sendMergePreviewDir, "preview-dir", "merge-preview", "Directory for --dry-run previews")
+	sendMergeCmd.Flags().IntVar(&sendMergeRate, "rate", 30, "Maximum messages to send per minute (0 for no limit)")
+	sendMergeCmd.Flags().StringVar(&sendMergeProgress, "progress", "", "Progress file path (default: <data>"+mergeProgressSuffix+")")
+	sendMergeCmd.Flags().StringVar(&sendMergeResults, "results", "", "Results CSV path (default: <data>"+mergeResultsSuffix+")")
+	addSenderFlags(sendMergeCmd, &sendMergeFrom, &sendMergeSig)
+	sendMergeCmd.MarkFlagRequired("template")
+	sendMergeCmd.MarkFlagRequired("data")
+}
+
+// mergeTemplate is a parsed merge template.
+type mergeTemplate struct {
+	subject *template.Template
+	cc      *template.Template
+	bcc     *template.Template
+	body    *template.Template
+	// imageDir is what relative inline image paths in the body are resolved
+	// against: the template file's directory.
+	imageDir string
+}
+
+// mergeMessage is the message rendered for one data row.
+type mergeMessage struct {
+	// Row is the 1-based row number in the data file.
+	Row       int
+	To        string
+	Cc        string
+	Bcc       string
+	Subject   string
+	PlainBody string
+	HTMLBody  string
+	Images    []inlineImage
+	Attach    []string
+	// Key identifies the message's content in the progress file.
+	Key string
+}
+
+// mergeRecord is one line of the merge progress file.
+type mergeRecord struct {
+	Row      int    `json:"row"`
+	To       string `json:"to"`
+	SHA256   string `json:"sha256"`
+	ID       string `json:"id,omitempty"`
+	ThreadID string `json:"thread_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func runSendMerge(cmd *cobra.Command, args []string) error {
+	if sendMergeRate < 0 {
+		return fmt.Errorf("--rate must not be negative")
+	}
+	src, err := os.ReadFile(sendMergeTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+	tmpl, err := parseMergeTemplate(string(src), sendMergeSubject)
+	if err != nil {
+		return err
+	}
+	tmpl.imageDir = filepath.Dir(sendMergeTemplate)
+	rows, err := readMergeData(sendMergeData)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("no rows in %s", sendMergeData)
+	}
+
+	ctx := context.Background()
+
+	// A dry run only needs the API to look up the sender.
+	signature := useSignature(cmd, sendMergeSig)
+	var service *gmail.Service
+	if !sendMergeDryRun || sendMergeFrom != "" || signature {
+		service, err = auth.NewGmailService(ctx, GetAccountEmail())
+		if err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+	extra, signatureHTML, err := resolveSender(service, sendMergeFrom, signature)
+	if err != nil {
+		return err
+	}
+
+	// Render every row first, so a mistake in row 40 does not stop the
+	// merge after 39 messages have gone out.
+	baseDir := filepath.Dir(sendMergeData)
+	messages := make([]mergeMessage, len(rows))
+	for i, row := range rows {
+		msg, err := tmpl.render(row, baseDir, signatureHTML)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", i+1, err)
+		}
+		msg.Row = i + 1
+		messages[i] = msg
+	}
+
+	if sendMergeDryRun {
+		return writeMergePreviews(messages, extra)
+	}
+	return sendMergeMessages(service, messages, extra)
+}
+
+// parseMergeTemplate parses a template file: optional Subject, Cc and Bcc
+// lines ended by a blank line, then the body. subject, if not empty,
+// replaces the Subject line.
+func parseMergeTemplate(src, subject string) (*mergeTemplate, error) {
+	headers := make(map[string]string)
+	body := strings.ReplaceAll(src, "\r\n", "\n")
+	for {
+		line, rest, _ := strings.Cut(body, "\n")
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || !isMergeTemplateHeader(name) {
+			break
+		}
+		headers[strings.ToLower(name)] = strings.TrimSpace(value)
+		body = rest
+		if strings.HasPrefix(body, "\n") {
+			body = body[1:]
+			break
+		}
+	}
+	if subject != "" {
+		headers["subject"] = subject
+	}
+	if headers["subject"] == "" {
+		return nil, fmt.Errorf("template has no Subject line; start it with \"Subject: ...\" or use --subject")
+	}
+
+	t := &mergeTemplate{}
+	for _, part := range []struct {
+		name string
+		text string
+		dest **template.Template
+	}{
+		{"subject", headers["subject"], &t.subject},
+		{"cc", headers["cc"], &t.cc},
+		{"bcc", headers["bcc"], &t.bcc},
+		{"body", body, &t.body},
+	} {
+		parsed, err := template.New(part.name).Option("missingkey=error").Parse(part.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		*part.dest = parsed
+	}
+	return t, nil
+}
+
+// isMergeTemplateHeader reports whether name is a header a template may
+// start with.
+func isMergeTemplateHeader(name string) bool {
+	for _, h := range mergeTemplateHeaders {
+		if strings.EqualFold(name, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// render renders the message for row. Relative attachment paths are
+// resolved against baseDir.
+func (t *mergeTemplate) render(row map[string]interface{}, baseDir, signatureHTML string) (mergeMessage, error) {
+	var msg mergeMessage
+	execute := func(tmpl *template.Template) (string, error) {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, row); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+
+	msg.To = headerLine(mergeField(row, "to"))
+	if msg.To == "" {
+		return msg, fmt.Errorf("no recipient in the \"to\" column")
+	}
+	subject, err := execute(t.subject)
+	if err != nil {
+		return msg, err
+	}
+	cc, err := execute(t.cc)
+	if err != nil {
+		return msg, err
+	}
+	bcc, err := execute(t.bcc)
+	if err != nil {
+		return msg, err
+	}
+	body, err := execute(t.body)
+	if err != nil {
+		return msg, err
+	}
+	msg.Subject = headerLine(subject)
+	msg.Cc = joinAddressLists(headerLine(cc), headerLine(mergeField(row, "cc")))
+	msg.Bcc = joinAddressLists(headerLine(bcc), headerLine(mergeField(row, "bcc")))
+
+	for _, path := range mergeAttachments(row) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		msg.Attach = append(msg.Attach, path)
+	}
+
+	msg.PlainBody, msg.HTMLBody, msg.Images, err = composeBodiesIn(t.imageDir, body, signatureHTML, "")
+	if err != nil {
+		return msg, err
+	}
+	if err := checkAttachmentSize(msg.Attach, msg.Images); err != nil {
+		return msg, err
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		msg.To, msg.Cc, msg.Bcc, msg.Subject, body, strings.Join(msg.Attach, "\n"),
+	}, "\x00")))
+	msg.Key = hex.EncodeToString(sum[:])
+	return msg, nil
+}
+
+// headerLine makes s safe to use as a header value by joining its lines,
+// so data cannot add headers of its own.
+func headerLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// mergeField returns the value of the column name in row, matched
+// case-insensitively, or "" if there is none.
+func mergeField(row map[string]interface{}, name string) string {
+	for key, value := range row {
+		if strings.EqualFold(strings.TrimSpace(key), name) && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+// mergeAttachments returns the files in row's attach column: a
+// ";"-separated string or, from JSON, an array of strings.
+func mergeAttachments(row map[string]interface{}) []string {
+	var paths []string
+	for key, value := range row {
+		if !strings.EqualFold(strings.TrimSpace(key), "attach") {
+			continue
+		}
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+					paths = append(paths, s)
+				}
+			}
+		case string:
+			for _, s := range strings.Split(v, ";") {
+				if s = strings.TrimSpace(s); s != "" {
+					paths = append(paths, s)
+				}
+			}
+		}
+	}
+	return paths
+}
+
+// readMergeData reads the rows of a .json file (an array of objects) or a
+// CSV file with a header row.
+func readMergeData(path string) ([]map[string]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var rows []map[string]interface{}
+		if err := json.NewDecoder(f).Decode(&rows); err != nil {
+			return nil, fmt.Errorf("invalid data file %s: expected a JSON array of objects: %w", path, err)
+		}
+		return rows, nil
+	}
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid data file %s: %w", path, err)
+	}
+	for i, name := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+	}
+	var rows []map[string]interface{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid data file %s: %w", path, err)
+		}
+		row := make(map[string]interface{}, len(header))
+		for i, name := range header {
+			row[name] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// writeMergePreviews writes each message to an .eml file in the preview
+// directory.
+func writeMergePreviews(messages []mergeMessage, extra []mailHeader) error {
+	if err := os.MkdirAll(sendMergePreviewDir, 0700); err != nil {
+		return fmt.Errorf("failed to create preview directory: %w", err)
+	}
+	var paths []string
+	for _, msg := range messages {
+		path := filepath.Join(sendMergePreviewDir, fmt.Sprintf("%03d-%s.eml", msg.Row, sanitizeFilename(msg.To)))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to write preview: %w", err)
+		}
+		err = writeOutgoingMessage(f, msg.To, msg.Subject, msg.Cc, msg.Bcc, msg.PlainBody, msg.HTMLBody, msg.Images, fileAttachments(msg.Attach), extra...)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write preview %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+
+	if GetOutputFormat() == "json" {
+		type previewResult struct {
+			Previews []string `json:"previews"`
+		}
+		return outputJSON(previewResult{Previews: paths})
+	}
+	fmt.Printf("Wrote %d previews to %s (nothing was sent)\n", len(paths), sendMergePreviewDir)
+	return nil
+}
+
+// sendMergeMessages sends the messages not yet recorded as sent in the
+// progress file, recording each one, and writes the results CSV.
+func sendMergeMessages(service *gmail.Service, messages []mergeMessage, extra []mailHeader) error {
+	progressPath := sendMergeProgress
+	if progressPath == "" {
+		progressPath = filepath.Clean(sendMergeData) + mergeProgressSuffix
+	}
+	resultsPath := sendMergeResults
+	if resultsPath == "" {
+		resultsPath = filepath.Clean(sendMergeData) + mergeResultsSuffix
+	}
+
+	done, err := readMergeProgress(progressPath)
+	if err != nil {
+		return err
+	}
+	progress, err := os.OpenFile(progressPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open progress file: %w", err)
+	}
+	defer progress.Close()
+
+	var interval time.Duration
+	if sendMergeRate > 0 {
+		interval = time.Minute / time.Duration(sendMergeRate)
+	}
+	var next time.Time
+	results := make([]mergeRecord, 0, len(messages))
+	var sentCount, skipped, failed int
+	for _, msg := range messages {
+		if record, ok := done[msg.Key]; ok {
+			record.Row = msg.Row
+			results = append(results, record)
+			skipped++
+			continue
+		}
+
+		time.Sleep(time.Until(next))
+		next = time.Now().Add(interval)
+
+		record := mergeRecord{Row: msg.Row, To: msg.To, SHA256: msg.Key}
+		sent, err := sendMessageUpload(service, "", func(w io.Writer) error {
+			return writeOutgoingMessage(w, msg.To, msg.Subject, msg.Cc, msg.Bcc, msg.PlainBody, msg.HTMLBody, msg.Images, fileAttachments(msg.Attach), extra...)
+		})
+		if err != nil {
+			record.Error = err.Error()
+			failed++
+			fmt.Fprintf(os.Stderr, "Warning: failed to send row %d to %s: %v\n", msg.Row, msg.To, err)
+		} else {
+			record.ID, record.ThreadID = sent.Id, sent.ThreadId
+			done[msg.Key] = record
+			sentCount++
+			if GetOutputFormat() != "json" {
+				fmt.Printf("Sent row %d to %s (%s)\n", msg.Row, msg.To, sent.Id)
+			}
+		}
+		results = append(results, record)
+		if err := appendJSONLine(progress, "progress file", record); err != nil {
+			return err
+		}
+	}
+
+	if err := writeMergeResults(resultsPath, results); err != nil {
+		return err
+	}
+
+	if GetOutputFormat() == "json" {
+		type mergeResult struct {
+			Sent     int    `json:"sent"`
+			Skipped  int    `json:"skipped"`
+			Failed   int    `json:"failed"`
+			Results  string `json:"results"`
+			Progress string `json:"progress"`
+		}
+		if err := outputJSON(mergeResult{Sent: sentCount, Skipped: skipped, Failed: failed, Results: resultsPath, Progress: progressPath}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Sent %d messages (%d already sent, %d failed)\n", sentCount, skipped, failed)
+		fmt.Printf("Results written to %s\n", resultsPath)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d messages failed to send; see %s and re-run to retry them", failed, resultsPath)
+	}
+	return nil
+}
+
+// readMergeProgress returns the records of the messages the progress file
+// at path records as sent, by content key.
+func readMergeProgress(path string) (map[string]mergeRecord, error) {
+	done := make(map[string]mergeRecord)
+	err := readJSONLog(path, "progress file", func(line []byte) error {
+		var record mergeRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		if record.ID != "" {
+			done[record.SHA256] = record
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return done, nil
+}
+
+// writeMergeResults writes one CSV line per row with its status, message
+// ID and error.
+func writeMergeResults(path string, results []mergeRecord) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"row", "to", "status", "message_id", "thread_id", "error"}) //nolint:errcheck
+	for _, r := range results {
+		status := "sent"
+		if r.Error != "" {
+			status = "failed"
+		}
+		w.Write([]string{strconv.Itoa(r.Row), r.To, status, r.ID, r.ThreadID, r.Error}) //nolint:errcheck
+	}
+	w.Flush()
+	err = w.Error()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMergeTemplate(t *testing.T) {
+	t.Parallel()
+
+	row := map[string]interface{}{"to": "ana@example.com", "name": "Ana", "team": "ops"}
+	tests := []struct {
+		name        string
+		src         string
+		subject     string
+		wantSubject string
+		wantCc      string
+		wantBody    string
+		wantErr     string
+	}{
+		{
+			name:        "should read the header lines and the body",
+			src:         "Subject: Hi {{.name}}\nCc: {{.team}}@example.com\n\nWelcome, **{{.name}}**.\n",
+			wantSubject: "Hi Ana",
+			wantCc:      "ops@example.com",
+			wantBody:    "Welcome, **Ana**.",
+		},
+		{
+			name:        "should let --subject replace the Subject line",
+			src:         "Subject: Hi\r\n\r\nBody\r\n",
+			subject:     "Hello {{.name}}",
+			wantSubject: "Hello Ana",
+			wantBody:    "Body",
+		},
+		{
+			name:        "should treat a template without headers as all body",
+			src:         "Note: {{.name}} starts Monday.\n",
+			subject:     "Start date",
+			wantSubject: "Start date",
+			wantBody:    "Note: Ana starts Monday.",
+		},
+		{
+			name:    "should require a subject",
+			src:     "Just a body\n",
+			wantErr: "no Subject line",
+		},
+		{
+			name:    "should report template syntax errors",
+			src:     "Subject: {{.name\n\nBody\n",
+			wantErr: "invalid template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tmpl, err := parseMergeTemplate(tt.src, tt.subject)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseMergeTemplate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMergeTemplate() error = %v", err)
+			}
+			msg, err := tmpl.render(row, ".", "")
+			if err != nil {
+				t.Fatalf("render() error = %v", err)
+			}
+			if msg.Subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", msg.Subject, tt.wantSubject)
+			}
+			if msg.Cc != tt.wantCc {
+				t.Errorf("Cc = %q, want %q", msg.Cc, tt.wantCc)
+			}
+			if strings.TrimSpace(msg.PlainBody) != tt.wantBody {
+				t.Errorf("PlainBody = %q, want %q", msg.PlainBody, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestMergeTemplateRender(t *testing.T) {
+	t.Parallel()
+	tmpl, err := parseMergeTemplate("Subject: {{.subject_line}}\nCc: lead@example.com\n\nHi {{.name}}\n", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should fail on a missing column", func(t *testing.T) {
+		t.Parallel()
+		_, err := tmpl.render(map[string]interface{}{"to": "a@example.com", "subject_line": "x"}, ".", "")
+		if err == nil || !strings.Contains(err.Error(), "name") {
+			t.Errorf("render() error = %v, want the missing key", err)
+		}
+	})
+
+	t.Run("should require a recipient", func(t *testing.T) {
+		t.Parallel()
+		_, err := tmpl.render(map[string]interface{}{"To": " ", "subject_line": "x", "name": "A"}, ".", "")
+		if err == nil || !strings.Contains(err.Error(), "no recipient") {
+			t.Errorf("render() error = %v, want no recipient", err)
+		}
+	})
+
+	t.Run("should keep data from adding headers", func(t *testing.T) {
+		t.Parallel()
+		msg, err := tmpl.render(map[string]interface{}{
+			"TO":           "a@example.com\r\nBcc: evil@example.com",
+			"subject_line": "Hi\nBcc: evil@example.com",
+			"name":         "A",
+			"cc":           "b@example.com",
+		}, ".", "")
+		if err != nil {
+			t.Fatalf("render() error = %v", err)
+		}
+		if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+			t.Errorf("To = %q, Subject = %q, want single lines", msg.To, msg.Subject)
+		}
+		if msg.Cc != "lead@example.com, b@example.com" {
+			t.Errorf("Cc = %q, want the template and row lists joined", msg.Cc)
+		}
+	})
+
+	t.Run("should resolve attachments against the data directory", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		for _, name := range []string{"a.pdf", "b.pdf"} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		row := map[string]interface{}{"to": "a@example.com", "subject_line": "x", "name": "A"}
+		for _, attach := range []interface{}{"a.pdf; b.pdf", []interface{}{"a.pdf", "b.pdf"}} {
+			row["attach"] = attach
+			msg, err := tmpl.render(row, dir, "")
+			if err != nil {
+				t.Fatalf("render(attach %v) error = %v", attach, err)
+			}
+			want := []string{filepath.Join(dir, "a.pdf"), filepath.Join(dir, "b.pdf")}
+			if strings.Join(msg.Attach, ",") != strings.Join(want, ",") {
+				t.Errorf("Attach = %v, want %v", msg.Attach, want)
+			}
+		}
+		row["attach"] = "missing.pdf"
+		if _, err := tmpl.render(row, dir, ""); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("render() with a missing attachment error = %v", err)
+		}
+	})
+
+	t.Run("should resolve images against the template directory", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		_, data := writeTestPNG(t, dir, "logo.png")
+		tmpl, err := parseMergeTemplate("Subject: x\n\n![logo](logo.png)\n", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl.imageDir = dir
+		msg, err := tmpl.render(map[string]interface{}{"to": "a@example.com"}, t.TempDir(), "")
+		if err != nil {
+			t.Fatalf("render() error = %v", err)
+		}
+		if len(msg.Images) != 1 || !bytes.Equal(msg.Images[0].Data, data) {
+			t.Errorf("Images = %v, want logo.png from the template directory", msg.Images)
+		}
+	})
+}
+
+func TestReadMergeData(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "rows.csv")
+	if err := os.WriteFile(csvPath, []byte("\ufeffto, name\na@example.com,\"Ana, Jr.\"\nb@example.com,Ben\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := readMergeData(csvPath)
+	if err != nil {
+		t.Fatalf("readMergeData(csv) error = %v", err)
+	}
+	if len(rows) != 2 || rows[0]["to"] != "a@example.com" || rows[0]["name"] != "Ana, Jr." || rows[1]["name"] != "Ben" {
+		t.Errorf("readMergeData(csv) = %v", rows)
+	}
+
+	jsonPath := filepath.Join(dir, "rows.json")
+	if err := os.WriteFile(jsonPath, []byte(`[{"to": "a@example.com", "tasks": 3}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rows, err = readMergeData(jsonPath)
+	if err != nil {
+		t.Fatalf("readMergeData(json) error = %v", err)
+	}
+	if len(rows) != 1 || mergeField(rows[0], "TO") != "a@example.com" || mergeField(rows[0], "tasks") != "3" {
+		t.Errorf("readMergeData(json) = %v", rows)
+	}
+
+	badPath := filepath.Join(dir, "bad.csv")
+	if err := os.WriteFile(badPath, []byte("to,name\na@example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readMergeData(badPath); err == nil {
+		t.Error("readMergeData() with a short row error = nil, want an error")
+	}
+}
+
+func TestE2ESendMerge(t *testing.T) {
+	srv := newE2EServer(t)
+	dir := t.TempDir()
+	template := filepath.Join(dir, "welcome.md")
+	if err := os.WriteFile(template, []byte("Subject: Welcome, {{.name}}!\n\nHi {{.name}}, you start on **{{.start}}**.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "handbook.pdf"), []byte("%PDF-1.4 handbook"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data := filepath.Join(dir, "hires.csv")
+	if err := os.WriteFile(data, []byte("to,name,start,cc,attach\n"+
+		"ana@example.com,Ana,Monday,,handbook.pdf\n"+
+		"ben@example.com,Ben,Tuesday,boss@example.com,\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	previews := filepath.Join(dir, "preview")
+	out := mustRunCLI(t, "send", "merge", "--template", template, "--data", data, "--dry-run", "--preview-dir", previews)
+	if !strings.Contains(out, "Wrote 2 previews") {
+		t.Errorf("dry run output = %q", out)
+	}
+	eml, err := os.ReadFile(filepath.Join(previews, "002-ben@example.com.eml"))
+	if err != nil {
+		t.Fatalf("preview not written: %v", err)
+	}
+	if !strings.Contains(string(eml), "Subject: Welcome, Ben!") || !strings.Contains(string(eml), "Cc: boss@example.com") {
+		t.Errorf("preview = %q", eml)
+	}
+	if got := srv.MessageIDs("SENT"); len(got) != 0 {
+		t.Fatalf("dry run sent %d messages", len(got))
+	}
+
+	out = mustRunCLI(t, "send", "merge", "--template", template, "--data", data, "--rate", "0")
+	if !strings.Contains(out, "Sent 2 messages (0 already sent, 0 failed)") {
+		t.Errorf("output = %q", out)
+	}
+	sent := srv.MessageIDs("SENT")
+	if len(sent) != 2 {
+		t.Fatalf("SENT has %d messages, want 2", len(sent))
+	}
+	for _, id := range sent {
+		msg, _ := srv.Message(id)
+		if headerValue(msg.Payload.Headers, "To") == "ana@example.com" {
+			if atts := findAttachments(msg.Payload.Parts); len(atts) != 1 || atts[0].Filename != "handbook.pdf" {
+				t.Errorf("Ana's attachments = %+v, want handbook.pdf", atts)
+			}
+		}
+	}
+
+	// Re-running sends nothing already sent.
+	out = mustRunCLI(t, "send", "merge", "--template", template, "--data", data, "--rate", "0")
+	if !strings.Contains(out, "Sent 0 messages (2 already sent, 0 failed)") {
+		t.Errorf("re-run output = %q", out)
+	}
+	if got := srv.MessageIDs("SENT"); len(got) != 2 {
+		t.Errorf("re-run: SENT has %d messages, want 2", len(got))
+	}
+
+	f, err := os.Open(data + mergeResultsSuffix)
+	if err != nil {
+		t.Fatalf("results not written: %v", err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[1][1] != "ana@example.com" || records[1][2] != "sent" || records[1][3] == "" {
+		t.Errorf("results = %v", records)
+	}
+
+	t.Run("should send nothing when a row fails to render", func(t *testing.T) {
+		bad := filepath.Join(dir, "bad.csv")
+		if err := os.WriteFile(bad, []byte("to,name\ncarl@example.com,Carl\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := runCLI(t, "send", "merge", "--template", template, "--data", bad, "--rate", "0")
+		if err == nil || !strings.Contains(err.Error(), "row 1") {
+			t.Errorf("error = %v, want row 1", err)
+		}
+		if got := srv.MessageIDs("SENT"); len(got) != 2 {
+			t.Errorf("SENT has %d messages, want 2", len(got))
+		}
+	})
+}
@@ -8,7 +8,7 @@ import (
 	"testing"
 )
 
-func TestBuildMultipartMessage_SingleTextAttachment(t *testing.T) {
+func TestWriteOutgoingMessage_SingleTextAttachment(t *testing.T) {
 	t.Parallel()
 	tmpDir := t.TempDir()
 	filePath := filepath.Join(tmpDir, "test.txt")
@@ -16,13 +16,11 @@ func TestBuildMultipartMessage_SingleTextAttachment(t *testing.T) {
 		t.Fatalf("failed to create temp file: %v", err)
 	}
 
-	result, err := buildMultipartMessage("to@example.com", "Subject", "Body text", "", "", []string{filePath})
+	output, err := writeTestMessage("to@example.com", "Subject", "Body text", "", "", []string{filePath})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	output := string(result)
-
 	if !strings.Contains(output, "multipart/mixed") {
 		t.Error("expected Content-Type to contain multipart/mixed")
 	}
@@ -49,7 +47,7 @@ func TestBuildMultipartMessage_SingleTextAttachment(t *testing.T) {
 	}
 }
 
-func TestBuildMultipartMessage_MultipleAttachments(t *testing.T) {
+func TestWriteOutgoingMessage_MultipleAttachments(t *testing.T) {
 	t.Parallel()
 	tmpDir := t.TempDir()
 
@@ -63,13 +61,11 @@ func TestBuildMultipartMessage_MultipleAttachments(t *testing.T) {
 		t.Fatalf("failed to create second temp file: %v", err)
 	}
 
-	result, err := buildMultipartMessage("to@example.com", "Subject", "Body text", "", "", []string{file1, file2})
+	output, err := writeTestMessage("to@example.com", "Subject", "Body text", "", "", []string{file1, file2})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	output := string(result)
-
 	if !strings.Contains(output, "first.txt") {
 		t.Error("expected output to contain first attachment filename")
 	}
@@ -79,7 +75,7 @@ func TestBuildMultipartMessage_MultipleAttachments(t *testing.T) {
 	}
 }
 
-func TestBuildMultipartMessage_WithCCAndBCC(t *testing.T) {
+func TestWriteOutgoingMessage_WithCCAndBCC(t *testing.T) {
 	t.Parallel()
 	tmpDir := t.TempDir()
 	filePath := filepath.Join(tmpDir, "dummy.txt")
@@ -87,13 +83,11 @@ func TestBuildMultipartMessage_WithCCAndBCC(t *testing.T) {
 		t.Fatalf("failed to create temp file: %v", err)
 	}
 
-	result, err := buildMultipartMessage("to@example.com", "Subject", "Body text", "cc@example.com", "bcc@example.com", []string{filePath})
+	output, err := writeTestMessage("to@example.com", "Subject", "Body text", "cc@example.com", "bcc@example.com", []string{filePath})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	output := string(result)
-
 	if !strings.Contains(output, "Cc: cc@example.com") {
 		t.Error("expected output to contain Cc header")
 	}
@@ -103,9 +97,9 @@ func TestBuildMultipartMessage_WithCCAndBCC(t *testing.T) {
 	}
 }
 
-func TestBuildMultipartMessage_NonexistentFileReturnsError(t *testing.T) {
+func TestWriteOutgoingMessage_NonexistentFileReturnsError(t *testing.T) {
 	t.Parallel()
-	_, err := buildMultipartMessage("to@example.com", "Subject", "Body text", "", "", []string{"/nonexistent/path/nofile.txt"})
+	_, err := writeTestMessage("to@example.com", "Subject", "Body text", "", "", []string{"/nonexistent/path/nofile.txt"})
 	if err == nil {
 		t.Fatal("expected error for nonexistent attachment file, got nil")
 	}
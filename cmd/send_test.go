@@ -106,7 +106,7 @@ func TestPlainTextToHTML(t *testing.T) {
 	}
 }
 
-func TestBuildSendRFC2822Message(t *testing.T) {
+func TestWriteOutgoingMessageHeaders(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
 		name        string
@@ -154,11 +154,10 @@ func TestBuildSendRFC2822Message(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			raw, err := buildSendRFC2822Message(tt.to, tt.subject, tt.body, tt.cc, tt.bcc)
+			msg, err := writeTestMessage(tt.to, tt.subject, tt.body, tt.cc, tt.bcc, nil)
 			if err != nil {
-				t.Fatalf("buildSendRFC2822Message() error = %v", err)
+				t.Fatalf("writeOutgoingMessage() error = %v", err)
 			}
-			msg := string(raw)
 			for _, want := range tt.contains {
 				if !strings.Contains(msg, want) {
 					t.Errorf("message missing %q", want)
@@ -173,7 +172,7 @@ func TestBuildSendRFC2822Message(t *testing.T) {
 	}
 }
 
-func TestBuildAlternativeBody(t *testing.T) {
+func TestBuildAlternativeParts(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
 		name string
@@ -192,15 +191,19 @@ func TestBuildAlternativeBody(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			raw, boundary, err := buildAlternativeBody(tt.body)
+			plainBody, htmlBody, _, err := composeBodies(tt.body, "", "")
 			if err != nil {
-				t.Fatalf("buildAlternativeBody() error = %v", err)
+				t.Fatalf("composeBodies() error = %v", err)
+			}
+			raw, boundary, err := buildAlternativeParts(plainBody, htmlBody)
+			if err != nil {
+				t.Fatalf("buildAlternativeParts() error = %v", err)
 			}
 			if len(raw) == 0 {
-				t.Error("buildAlternativeBody() returned empty bytes")
+				t.Error("buildAlternativeParts() returned empty bytes")
 			}
 			if boundary == "" {
-				t.Error("buildAlternativeBody() returned empty boundary")
+				t.Error("buildAlternativeParts() returned empty boundary")
 			}
 			content := string(raw)
 			if !strings.Contains(content, "text/plain") {
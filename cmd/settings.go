@@ -0,0 +1,362 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+var (
+	// settingsVacationSetCmd flags
+	vacationSubject      string
+	vacationBody         string
+	vacationStart        string
+	vacationEnd          string
+	vacationTimezone     string
+	vacationContactsOnly bool
+	vacationDomainOnly   bool
+)
+
+// settingsCmd represents the settings parent command
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Manage Gmail settings",
+	Long: `Manage Gmail settings for the authenticated user.
+
+This command group provides operations for account-level settings such as
+the vacation responder.`,
+}
+
+// settingsVacationCmd represents the settings vacation parent command
+var settingsVacationCmd = &cobra.Command{
+	Use:   "vacation",
+	Short: "Manage the vacation responder",
+	Long: `Manage the Gmail vacation responder (out-of-office auto-reply).
+
+The responder can be limited to a time window and to senders in your
+contacts or your domain.`,
+}
+
+// settingsVacationGetCmd represents the settings vacation get command
+var settingsVacationGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Show the vacation responder settings",
+	Long: `Show whether the vacation responder is on, its subject and message,
+its time window and who it replies to.`,
+	Example: `  # Show the vacation responder
+  gsuite settings vacation get
+
+  # As JSON, e.g. to check from a script whether it is on
+  gsuite settings vacation get -f json`,
+	Args: cobra.NoArgs,
+	RunE: runSettingsVacationGet,
+}
+
+// settingsVacationSetCmd represents the settings vacation set command
+var settingsVacationSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Turn on the vacation responder",
+	Long: `Turn on the vacation responder, updating only the settings given as flags.
+
+Settings that are not given keep their current values, so running set again
+with only --end extends an existing out-of-office reply. --body is required
+the first time.
+
+Flags:
+  --subject: Subject of the reply
+  --body: Reply message with markdown support (\n for newlines)
+  --start, --end: Time window for the reply; pass "" to clear
+  --timezone: IANA timezone for --start and --end (default: local)
+  --contacts-only: Only reply to senders in your contacts
+  --domain-only: Only reply to senders in your domain (Workspace accounts)
+
+Date/time formats for --start and --end:
+  RFC3339, 2006-01-02, 2006-01-02 15:04, 15:04, today, tomorrow,
+  monday-sunday, +Nd, or one of those days with a time (tomorrow 09:00)`,
+	Example: `  # Out of office for a week starting tomorrow
+  gsuite settings vacation set --subject "Out of office" \
+    --body "I'm away until **Monday**.\n\nFor urgent issues contact oncall@example.com." \
+    --start tomorrow --end +8d
+
+  # Only auto-reply to colleagues
+  gsuite settings vacation set --domain-only
+
+  # Clear the end date
+  gsuite settings vacation set --end ""`,
+	Args: cobra.NoArgs,
+	RunE: runSettingsVacationSet,
+}
+
+// settingsVacationDisableCmd represents the settings vacation disable command
+var settingsVacationDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Turn off the vacation responder",
+	Long: `Turn off the vacation responder. The subject, message and other settings
+are kept, so a later 'set' without --body turns the same reply back on.`,
+	Example: `  # Turn off the vacation responder
+  gsuite settings vacation disable`,
+	Args: cobra.NoArgs,
+	RunE: runSettingsVacationDisable,
+}
+
+func init() {
+	rootCmd.AddCommand(settingsCmd)
+	settingsCmd.AddCommand(settingsVacationCmd)
+	settingsVacationCmd.AddCommand(settingsVacationGetCmd)
+	settingsVacationCmd.AddCommand(settingsVacationSetCmd)
+	settingsVacationCmd.AddCommand(settingsVacationDisableCmd)
+
+	// settingsVacationGetCmd flags
+	settingsVacationGetCmd.Flags().StringVar(&vacationTimezone, "timezone", "", "IANA timezone for displayed times")
+
+	// settingsVacationSetCmd flags
+	settingsVacationSetCmd.Flags().StringVarP(&vacationSubject, "subject", "s", "", "Reply subject")
+	settingsVacationSetCmd.Flags().StringVarP(&vacationBody, "body", "b", "", "Reply message with markdown support")
+	settingsVacationSetCmd.Flags().StringVar(&vacationStart, "start", "", "Start replying at this time")
+	settingsVacationSetCmd.Flags().StringVar(&vacationEnd, "end", "", "Stop replying at this time")
+	settingsVacationSetCmd.Flags().StringVar(&vacationTimezone, "timezone", "", "IANA timezone for --start and --end")
+	settingsVacationSetCmd.Flags().BoolVar(&vacationContactsOnly, "contacts-only", false, "Only reply to senders in your contacts")
+	settingsVacationSetCmd.Flags().BoolVar(&vacationDomainOnly, "domain-only", false, "Only reply to senders in your domain")
+}
+
+// vacationJSON is the JSON form of the vacation responder settings.
+type vacationJSON struct {
+	Enabled            bool   `json:"enabled"`
+	Subject            string `json:"subject"`
+	BodyPlainText      string `json:"body_plain_text"`
+	BodyHTML           string `json:"body_html"`
+	StartTime          string `json:"start_time,omitempty"`
+	EndTime            string `json:"end_time,omitempty"`
+	RestrictToContacts bool   `json:"restrict_to_contacts"`
+	RestrictToDomain   bool   `json:"restrict_to_domain"`
+}
+
+// newVacationJSON converts API vacation settings to their JSON form, with
+// times in RFC3339 in loc.
+func newVacationJSON(v *gmail.VacationSettings, loc *time.Location) vacationJSON {
+	result := vacationJSON{
+		Enabled:            v.EnableAutoReply,
+		Subject:            v.ResponseSubject,
+		BodyPlainText:      v.ResponseBodyPlainText,
+		BodyHTML:           v.ResponseBodyHtml,
+		RestrictToContacts: v.RestrictToContacts,
+		RestrictToDomain:   v.RestrictToDomain,
+	}
+	if v.StartTime != 0 {
+		result.StartTime = time.UnixMilli(v.StartTime).In(loc).Format(time.RFC3339)
+	}
+	if v.EndTime != 0 {
+		result.EndTime = time.UnixMilli(v.EndTime).In(loc).Format(time.RFC3339)
+	}
+	return result
+}
+
+func runSettingsVacationGet(cmd *cobra.Command, args []string) error {
+	loc, err := loadTimezone(vacationTimezone)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	v, err := service.Users.Settings.GetVacation("me").Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		return outputJSON(newVacationJSON(v, loc))
+	}
+
+	printVacation(v, loc)
+	return nil
+}
+
+func runSettingsVacationSet(cmd *cobra.Command, args []string) error {
+	loc, err := loadTimezone(vacationTimezone)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	v, err := service.Users.Settings.GetVacation("me").Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	if err := applyVacationFlags(cmd, v, loc, time.Now()); err != nil {
+		return err
+	}
+	if v.ResponseBodyPlainText == "" && v.ResponseBodyHtml == "" {
+		return fmt.Errorf("--body is required: the vacation responder has no message yet")
+	}
+	v.EnableAutoReply = true
+
+	updated, err := updateVacation(service, v)
+	if err != nil {
+		return err
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		return outputJSON(newVacationJSON(updated, loc))
+	}
+
+	fmt.Println("Vacation responder enabled.")
+	printVacation(updated, loc)
+	return nil
+}
+
+func runSettingsVacationDisable(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	v, err := service.Users.Settings.GetVacation("me").Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	wasEnabled := v.EnableAutoReply
+	v.EnableAutoReply = false
+	updated, err := updateVacation(service, v)
+	if err != nil {
+		return err
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		return outputJSON(newVacationJSON(updated, time.Now().Location()))
+	}
+
+	if !wasEnabled {
+		fmt.Println("Vacation responder was already off.")
+		return nil
+	}
+	fmt.Println("Vacation responder disabled.")
+	return nil
+}
+
+// applyVacationFlags overlays the set flags the user passed onto v. Times
+// are parsed in loc relative to now; an empty --start or --end clears it.
+func applyVacationFlags(cmd *cobra.Command, v *gmail.VacationSettings, loc *time.Location, now time.Time) error {
+	if cmd.Flags().Changed("subject") {
+		v.ResponseSubject = vacationSubject
+	}
+	if cmd.Flags().Changed("body") {
+		body := interpretEscapes(vacationBody)
+		v.ResponseBodyPlainText = body
+		v.ResponseBodyHtml = ""
+		if strings.TrimSpace(body) != "" {
+			v.ResponseBodyHtml = markdownToHTMLFragment(body)
+		}
+	}
+	if cmd.Flags().Changed("start") {
+		ms, err := parseVacationTime(vacationStart, loc, now)
+		if err != nil {
+			return fmt.Errorf("invalid --start: %w", err)
+		}
+		v.StartTime = ms
+	}
+	if cmd.Flags().Changed("end") {
+		ms, err := parseVacationTime(vacationEnd, loc, now)
+		if err != nil {
+			return fmt.Errorf("invalid --end: %w", err)
+		}
+		v.EndTime = ms
+	}
+	if v.StartTime != 0 && v.EndTime != 0 && v.EndTime <= v.StartTime {
+		return fmt.Errorf("end time must be after start time")
+	}
+	if cmd.Flags().Changed("contacts-only") {
+		v.RestrictToContacts = vacationContactsOnly
+	}
+	if cmd.Flags().Changed("domain-only") {
+		v.RestrictToDomain = vacationDomainOnly
+	}
+	return nil
+}
+
+// parseVacationTime parses a --start or --end value into Unix milliseconds,
+// the form the Gmail API uses. An empty value means no limit and returns 0.
+func parseVacationTime(input string, loc *time.Location, now time.Time) (int64, error) {
+	if strings.TrimSpace(input) == "" {
+		return 0, nil
+	}
+	t, err := parseDateTime(input, loc, now)
+	if err != nil {
+		return 0, err
+	}
+	return t.UnixMilli(), nil
+}
+
+// updateVacation writes v back. Boolean and time fields are always sent so
+// that turning a setting off or clearing a time takes effect.
+func updateVacation(service *gmail.Service, v *gmail.VacationSettings) (*gmail.VacationSettings, error) {
+	v.ForceSendFields = []string{"EnableAutoReply", "RestrictToContacts", "RestrictToDomain", "StartTime", "EndTime"}
+	updated, err := service.Users.Settings.UpdateVacation("me", v).Do()
+	if err != nil {
+		return nil, auth.HandleGmailError(err)
+	}
+	return updated, nil
+}
+
+// printVacation prints the vacation responder settings in text mode.
+func printVacation(v *gmail.VacationSettings, loc *time.Location) {
+	status := "off"
+	if v.EnableAutoReply {
+		status = "on"
+	}
+	fmt.Printf("Status:  %s\n", status)
+	if v.ResponseSubject != "" {
+		fmt.Printf("Subject: %s\n", v.ResponseSubject)
+	}
+	if v.StartTime != 0 {
+		fmt.Printf("Starts:  %s\n", formatVacationTime(v.StartTime, loc))
+	}
+	if v.EndTime != 0 {
+		fmt.Printf("Ends:    %s\n", formatVacationTime(v.EndTime, loc))
+	}
+	var audience []string
+	if v.RestrictToContacts {
+		audience = append(audience, "contacts")
+	}
+	if v.RestrictToDomain {
+		audience = append(audience, "domain")
+	}
+	if len(audience) > 0 {
+		fmt.Printf("Replies: only to %s\n", strings.Join(audience, " and "))
+	} else {
+		fmt.Println("Replies: to everyone")
+	}
+	if v.ResponseBodyPlainText != "" {
+		fmt.Printf("\n%s\n", v.ResponseBodyPlainText)
+	} else if v.ResponseBodyHtml != "" {
+		fmt.Printf("\n%s\n", v.ResponseBodyHtml)
+	}
+}
+
+// formatVacationTime formats Unix milliseconds for display in loc.
+func formatVacationTime(ms int64, loc *time.Location) string {
+	return time.UnixMilli(ms).In(loc).Format("Mon Jan 02, 2006 03:04 PM MST")
+}
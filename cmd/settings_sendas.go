@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+// signatureEnv turns on --signature by default for send, drafts create and
+// messages reply when set to a true value such as "1" or "true".
+const signatureEnv = "GSUITE_SIGNATURE"
+
+var (
+	// settingsSendAsUpdateSignatureCmd flags
+	sendAsSignature     string
+	sendAsSignatureHTML bool
+)
+
+// settingsSendAsCmd represents the settings sendas parent command
+var settingsSendAsCmd = &cobra.Command{
+	Use:   "sendas",
+	Short: "Manage send-as aliases and signatures",
+	Long: `Manage the addresses you can send mail from and their signatures.
+
+Send-as aliases are added and verified in Gmail's settings ("Accounts" →
+"Send mail as"). Verified aliases can be used with --from on 'send',
+'drafts create' and 'messages reply'.`,
+}
+
+// settingsSendAsListCmd represents the settings sendas list command
+var settingsSendAsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List send-as aliases",
+	Long: `List the addresses you can send mail from, with their display name,
+whether they are the default, and their verification status.`,
+	Example: `  # List send-as aliases
+  gsuite settings sendas list`,
+	Args: cobra.NoArgs,
+	RunE: runSettingsSendAsList,
+}
+
+// settingsSendAsGetCmd represents the settings sendas get command
+var settingsSendAsGetCmd = &cobra.Command{
+	Use:   "get <email>",
+	Short: "Get a send-as alias and its signature",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSettingsSendAsGet,
+}
+
+// settingsSendAsUpdateSignatureCmd represents the settings sendas update-signature command
+var settingsSendAsUpdateSignatureCmd = &cobra.Command{
+	Use:   "update-signature <email>",
+	Short: "Set the signature of a send-as alias",
+	Long: `Set the signature of a send-as alias.
+
+The signature supports markdown formatting, which is stored as HTML. Use
+--html to pass HTML as-is, and an empty --signature to remove the signature.
+
+Args:
+  email: The send-as address to update (required)`,
+	Example: `  # Set a markdown signature
+  gsuite settings sendas update-signature me@example.com --signature "**Jane Doe**\nSupport Lead"
+
+  # Remove the signature
+  gsuite settings sendas update-signature me@example.com --signature ""`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSettingsSendAsUpdateSignature,
+}
+
+func init() {
+	settingsCmd.AddCommand(settingsSendAsCmd)
+	settingsSendAsCmd.AddCommand(settingsSendAsListCmd)
+	settingsSendAsCmd.AddCommand(settingsSendAsGetCmd)
+	settingsSendAsCmd.AddCommand(settingsSendAsUpdateSignatureCmd)
+
+	// settingsSendAsUpdateSignatureCmd flags
+	settingsSendAsUpdateSignatureCmd.Flags().StringVar(&sendAsSignature, "signature", "", "Signature with markdown support (required)")
+	settingsSendAsUpdateSignatureCmd.Flags().BoolVar(&sendAsSignatureHTML, "html", false, "Treat --signature as HTML instead of markdown")
+	settingsSendAsUpdateSignatureCmd.MarkFlagRequired("signature")
+}
+
+// sendAsJSON is the JSON form of a send-as alias.
+type sendAsJSON struct {
+	Email              string `json:"email"`
+	DisplayName        string `json:"display_name"`
+	ReplyTo            string `json:"reply_to,omitempty"`
+	IsPrimary          bool   `json:"is_primary"`
+	IsDefault          bool   `json:"is_default"`
+	VerificationStatus string `json:"verification_status"`
+	Signature          string `json:"signature"`
+}
+
+// newSendAsJSON converts an API send-as alias to its JSON form.
+func newSendAsJSON(alias *gmail.SendAs) sendAsJSON {
+	return sendAsJSON{
+		Email:              alias.SendAsEmail,
+		DisplayName:        alias.DisplayName,
+		ReplyTo:            alias.ReplyToAddress,
+		IsPrimary:          alias.IsPrimary,
+		IsDefault:          alias.IsDefault,
+		VerificationStatus: sendAsStatus(alias),
+		Signature:          alias.Signature,
+	}
+}
+
+func runSettingsSendAsList(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	resp, err := service.Users.Settings.SendAs.List("me").Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		results := []sendAsJSON{}
+		for _, alias := range resp.SendAs {
+			results = append(results, newSendAsJSON(alias))
+		}
+		return outputJSON(results)
+	}
+
+	fmt.Printf("%-35s %-25s %-8s %s\n", "EMAIL", "NAME", "DEFAULT", "STATUS")
+	fmt.Printf("%-35s %-25s %-8s %s\n", "-----", "----", "-------", "------")
+	for _, alias := range resp.SendAs {
+		isDefault := ""
+		if alias.IsDefault {
+			isDefault = "yes"
+		}
+		fmt.Printf("%-35s %-25s %-8s %s\n", alias.SendAsEmail, alias.DisplayName, isDefault, sendAsStatus(alias))
+	}
+	fmt.Printf("\n[Total: %d aliases]\n", len(resp.SendAs))
+	return nil
+}
+
+func runSettingsSendAsGet(cmd *cobra.Command, args []string) error {
+	email := args[0]
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	alias, err := service.Users.Settings.SendAs.Get("me", email).Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		return outputJSON(newSendAsJSON(alias))
+	}
+
+	printSendAs(alias)
+	return nil
+}
+
+func runSettingsSendAsUpdateSignature(cmd *cobra.Command, args []string) error {
+	email := args[0]
+
+	signature := interpretEscapes(sendAsSignature)
+	if !sendAsSignatureHTML && strings.TrimSpace(signature) != "" {
+		signature = strings.TrimSpace(markdownToHTMLFragment(signature))
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	updated, err := service.Users.Settings.SendAs.Patch("me", email, &gmail.SendAs{
+		Signature:       signature,
+		ForceSendFields: []string{"Signature"},
+	}).Do()
+	if err != nil {
+		return auth.HandleGmailError(err)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		return outputJSON(newSendAsJSON(updated))
+	}
+
+	if updated.Signature == "" {
+		fmt.Printf("Signature removed: %s\n", updated.SendAsEmail)
+		return nil
+	}
+	fmt.Printf("Signature updated: %s\n", updated.SendAsEmail)
+	return nil
+}
+
+// printSendAs prints a send-as alias and its signature in text mode.
+func printSendAs(alias *gmail.SendAs) {
+	fmt.Printf("Email: %s\n", alias.SendAsEmail)
+	if alias.DisplayName != "" {
+		fmt.Printf("Name: %s\n", alias.DisplayName)
+	}
+	if alias.ReplyToAddress != "" {
+		fmt.Printf("Reply-To: %s\n", alias.ReplyToAddress)
+	}
+	fmt.Printf("Primary: %t\n", alias.IsPrimary)
+	fmt.Printf("Default: %t\n", alias.IsDefault)
+	fmt.Printf("Status: %s\n", sendAsStatus(alias))
+	if alias.Signature != "" {
+		fmt.Println("---")
+		fmt.Println(htmlToText(alias.Signature))
+	}
+}
+
+// sendAsStatus returns the alias's verification status. The primary address
+// has none and is always usable, so it is reported as accepted.
+func sendAsStatus(alias *gmail.SendAs) string {
+	if alias.IsPrimary && alias.VerificationStatus == "" {
+		return "accepted"
+	}
+	return alias.VerificationStatus
+}
+
+// addSenderFlags registers the --from and --signature flags on a command that
+// composes a message.
+func addSenderFlags(cmd *cobra.Command, from *string, signature *bool) {
+	cmd.Flags().StringVar(from, "from", "", "Send from this verified send-as alias")
+	cmd.Flags().BoolVar(signature, "signature", false, "Append the sender's signature (default from $"+signatureEnv+")")
+}
+
+// useSignature reports whether to append a signature: the --signature flag
+// when given, otherwise the GSUITE_SIGNATURE environment variable.
+func useSignature(cmd *cobra.Command, flagValue bool) bool {
+	if cmd.Flags().Changed("signature") {
+		return flagValue
+	}
+	enabled, err := strconv.ParseBool(os.Getenv(signatureEnv))
+	return err == nil && enabled
+}
+
+// resolveSender looks up the send-as alias for --from and, if requested, its
+// signature. It returns the From header to add (none when from is empty, so
+// Gmail uses the default address) and the signature HTML to append.
+func resolveSender(service *gmail.Service, from string, signature bool) ([]mailHeader, string, error) {
+	if from == "" && !signature {
+		return nil, "", nil
+	}
+
+	resp, err := service.Users.Settings.SendAs.List("me").Do()
+	if err != nil {
+		return nil, "", auth.HandleGmailError(err)
+	}
+
+	var headers []mailHeader
+	alias := defaultSendAs(resp.SendAs)
+	if from != "" {
+		if alias, err = findSendAs(resp.SendAs, from); err != nil {
+			return nil, "", err
+		}
+		headers = append(headers, mailHeader{Name: "From", Value: sendAsAddress(alias)})
+	}
+
+	if !signature || alias == nil {
+		return headers, "", nil
+	}
+	return headers, alias.Signature, nil
+}
+
+// findSendAs returns the verified alias for email from aliases.
+func findSendAs(aliases []*gmail.SendAs, email string) (*gmail.SendAs, error) {
+	for _, alias := range aliases {
+		if !strings.EqualFold(alias.SendAsEmail, strings.TrimSpace(email)) {
+			continue
+		}
+		if status := sendAsStatus(alias); status != "accepted" {
+			return nil, fmt.Errorf("send-as alias %s is not verified (status: %s)", alias.SendAsEmail, status)
+		}
+		return alias, nil
+	}
+	return nil, fmt.Errorf("send-as alias not found: %s (see 'gsuite settings sendas list')", email)
+}
+
+// defaultSendAs returns the alias Gmail sends from by default, or nil.
+func defaultSendAs(aliases []*gmail.SendAs) *gmail.SendAs {
+	for _, alias := range aliases {
+		if alias.IsDefault {
+			return alias
+		}
+	}
+	for _, alias := range aliases {
+		if alias.IsPrimary {
+			return alias
+		}
+	}
+	return nil
+}
+
+// sendAsAddress formats the alias as a From header value, including its
+// display name when it has one.
+func sendAsAddress(alias *gmail.SendAs) string {
+	if alias.DisplayName == "" {
+		return alias.SendAsEmail
+	}
+	return (&mail.Address{Name: alias.DisplayName, Address: alias.SendAsEmail}).String()
+}
+
+// appendPlainSignature appends a signature to a plain text body after the
+// conventional "-- " delimiter line.
+func appendPlainSignature(body, signatureHTML string) string {
+	if signatureHTML == "" {
+		return body
+	}
+	return body + "\n\n-- \n" + htmlToText(signatureHTML)
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestFindSendAs(t *testing.T) {
+	t.Parallel()
+
+	aliases := []*gmail.SendAs{
+		{SendAsEmail: "me@example.com", IsPrimary: true, IsDefault: true},
+		{SendAsEmail: "support@example.com", DisplayName: "Support Team", VerificationStatus: "accepted"},
+		{SendAsEmail: "sales@example.com", VerificationStatus: "pending"},
+	}
+	tests := []struct {
+		name     string
+		email    string
+		wantFrom string
+		wantErr  string
+	}{
+		{name: "should accept the primary address", email: "me@example.com", wantFrom: "me@example.com"},
+		{name: "should match case-insensitively and include the display name", email: "Support@Example.com", wantFrom: `"Support Team" <support@example.com>`},
+		{name: "should reject unverified aliases", email: "sales@example.com", wantErr: "not verified (status: pending)"},
+		{name: "should reject unknown aliases", email: "nobody@example.com", wantErr: "send-as alias not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			alias, err := findSendAs(aliases, tt.email)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("findSendAs(%q) error = %v, want %q", tt.email, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("findSendAs(%q) unexpected error: %v", tt.email, err)
+			}
+			if got := sendAsAddress(alias); got != tt.wantFrom {
+				t.Errorf("sendAsAddress() = %q, want %q", got, tt.wantFrom)
+			}
+		})
+	}
+}
+
+func TestComposeBodies(t *testing.T) {
+	t.Parallel()
+
+	plain, htmlBody, _, err := composeBodies("Hi **Bob**", "<p>Jane</p>", "> quoted")
+	if err != nil {
+		t.Fatalf("composeBodies() error = %v", err)
+	}
+	if plain != "Hi **Bob**\n\n-- \nJane\n\n> quoted" {
+		t.Errorf("composeBodies() plain = %q", plain)
+	}
+	sig := strings.Index(htmlBody, `<div class="gmail_signature"><p>Jane</p></div>`)
+	quote := strings.Index(htmlBody, "<blockquote>")
+	if !strings.Contains(htmlBody, "<strong>Bob</strong>") || sig < 0 || quote < sig {
+		t.Errorf("composeBodies() html = %q, want body, then signature, then quote", htmlBody)
+	}
+
+	plain, htmlBody, _, err = composeBodies("Hi", "", "")
+	if err != nil {
+		t.Fatalf("composeBodies() error = %v", err)
+	}
+	if plain != "Hi" || htmlBody != plainTextToHTML("Hi") {
+		t.Errorf("composeBodies() without signature = %q, %q", plain, htmlBody)
+	}
+}
+
+func TestE2ESendAs(t *testing.T) {
+	srv := newE2EServer(t)
+	srv.AddSendAs(&gmail.SendAs{SendAsEmail: "support@example.com", DisplayName: "Support", VerificationStatus: "accepted"})
+	srv.AddSendAs(&gmail.SendAs{SendAsEmail: "sales@example.com", VerificationStatus: "pending"})
+
+	out := mustRunCLI(t, "settings", "sendas", "list")
+	if !strings.Contains(out, "support@example.com") || !strings.Contains(out, "pending") {
+		t.Errorf("sendas list output:\n%s", out)
+	}
+
+	out = mustRunCLI(t, "settings", "sendas", "update-signature", "support@example.com", "--signature", "**Support**\\nACME", "-f", "json")
+	var updated sendAsJSON
+	if err := json.Unmarshal([]byte(out), &updated); err != nil {
+		t.Fatalf("update-signature output is not JSON: %v\n%s", err, out)
+	}
+	if !strings.Contains(updated.Signature, "<strong>Support</strong>") {
+		t.Errorf("updated signature = %q, want rendered markdown", updated.Signature)
+	}
+
+	if _, err := runCLI(t, "send", "--to", "bob@example.com", "-s", "Hi", "-b", "x", "--from", "sales@example.com"); err == nil || !strings.Contains(err.Error(), "not verified") {
+		t.Errorf("send from an unverified alias error = %v, want not verified", err)
+	}
+
+	mustRunCLI(t, "send", "--to", "bob@example.com", "-s", "Hi", "-b", "Fixed.", "--from", "support@example.com", "--signature")
+	sent := srv.MessageIDs("SENT")
+	if len(sent) != 1 {
+		t.Fatalf("after send, SENT has %d messages, want 1", len(sent))
+	}
+	raw, _ := srv.RawMessage(sent[0])
+	if !strings.Contains(string(raw), `From: "Support" <support@example.com>`) || !strings.Contains(string(raw), "-- \nSupport\nACME") {
+		t.Errorf("sent message missing From header or signature:\n%s", raw)
+	}
+
+	// GSUITE_SIGNATURE turns signatures on by default; the default alias has none
+	t.Setenv(signatureEnv, "1")
+	mustRunCLI(t, "drafts", "create", "--to", "bob@example.com", "-s", "Draft", "-b", "Later", "--from", "support@example.com")
+	mustRunCLI(t, "drafts", "update", srv.DraftIDs()[0], "--subject", "Draft v2")
+	mustRunCLI(t, "drafts", "send", srv.DraftIDs()[0])
+	sent = srv.MessageIDs("SENT")
+	raw, _ = srv.RawMessage(sent[len(sent)-1])
+	if !strings.Contains(string(raw), "From: \"Support\" <support@example.com>") || !strings.Contains(string(raw), "Later\n\n-- \nSupport") {
+		t.Errorf("draft sent without the alias or signature:\n%s", raw)
+	}
+}
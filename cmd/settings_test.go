@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseVacationTime(t *testing.T) {
+	t.Parallel()
+
+	loc := time.FixedZone("EST", -5*3600)
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, loc)
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "should treat empty input as no limit", input: "  "},
+		{name: "should parse dates in the given timezone", input: "2026-03-20", want: time.Date(2026, 3, 20, 0, 0, 0, 0, loc)},
+		{name: "should parse relative days", input: "+2d", want: time.Date(2026, 3, 12, 0, 0, 0, 0, loc)},
+		{name: "should parse RFC3339", input: "2026-03-20T17:30:00Z", want: time.Date(2026, 3, 20, 17, 30, 0, 0, time.UTC)},
+		{name: "should reject unknown formats", input: "next week", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseVacationTime(tt.input, loc, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseVacationTime(%q) expected error, got %d", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseVacationTime(%q) unexpected error: %v", tt.input, err)
+			}
+			var want int64
+			if !tt.want.IsZero() {
+				want = tt.want.UnixMilli()
+			}
+			if got != want {
+				t.Errorf("parseVacationTime(%q) = %d, want %d", tt.input, got, want)
+			}
+		})
+	}
+}
+
+func TestE2ESettingsVacation(t *testing.T) {
+	srv := newE2EServer(t)
+
+	if _, err := runCLI(t, "settings", "vacation", "set", "--subject", "Away"); err == nil || !strings.Contains(err.Error(), "--body is required") {
+		t.Errorf("vacation set without a body error = %v, want --body is required", err)
+	}
+	if _, err := runCLI(t, "settings", "vacation", "set", "--body", "x", "--start", "2026-03-20", "--end", "2026-03-10"); err == nil {
+		t.Error("vacation set with end before start succeeded, want an error")
+	}
+
+	out := mustRunCLI(t, "settings", "vacation", "set", "--subject", "Out of office",
+		"--body", "Back **Monday**.\\nPing oncall.", "--start", "2026-03-20", "--end", "2026-03-27",
+		"--timezone", "UTC", "--domain-only", "-f", "json")
+	var got vacationJSON
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("vacation set output is not JSON: %v\n%s", err, out)
+	}
+	if !got.Enabled || got.Subject != "Out of office" || !got.RestrictToDomain || got.RestrictToContacts {
+		t.Errorf("vacation set = %+v, want enabled, domain-only, subject set", got)
+	}
+	if got.StartTime != "2026-03-20T00:00:00Z" || got.EndTime != "2026-03-27T00:00:00Z" {
+		t.Errorf("vacation window = %s to %s, want 2026-03-20 to 2026-03-27", got.StartTime, got.EndTime)
+	}
+	if got.BodyPlainText != "Back **Monday**.\nPing oncall." || !strings.Contains(got.BodyHTML, "<strong>Monday</strong>") {
+		t.Errorf("vacation body = %q / %q, want plain markdown and rendered HTML", got.BodyPlainText, got.BodyHTML)
+	}
+
+	// Updating one setting keeps the rest
+	mustRunCLI(t, "settings", "vacation", "set", "--end", "")
+	v := srv.Vacation()
+	if v.EndTime != 0 || v.StartTime == 0 || v.ResponseSubject != "Out of office" || !v.RestrictToDomain {
+		t.Errorf("after clearing --end, settings = %+v, want only the end time cleared", v)
+	}
+
+	out = mustRunCLI(t, "settings", "vacation", "disable")
+	if !strings.Contains(out, "Vacation responder disabled.") {
+		t.Errorf("vacation disable output:\n%s", out)
+	}
+	v = srv.Vacation()
+	if v.EnableAutoReply || v.ResponseSubject != "Out of office" {
+		t.Errorf("after disable, settings = %+v, want off with the message kept", v)
+	}
+
+	out = mustRunCLI(t, "settings", "vacation", "get", "--timezone", "UTC")
+	if !strings.Contains(out, "Status:  off") || !strings.Contains(out, "Replies: only to domain") {
+		t.Errorf("vacation get output:\n%s", out)
+	}
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"strings"
 
@@ -16,6 +15,7 @@ var (
 	threadsMaxResults int64
 	threadsLabelIDs   string
 	threadsQuery      string
+	threadsListPages  pageOptions
 )
 
 // threadsCmd represents the threads parent command
@@ -25,7 +25,8 @@ var threadsCmd = &cobra.Command{
 	Long: `Manage Gmail conversation threads.
 
 Threads are collections of messages grouped by Gmail into conversations.
-Use subcommands to list threads or view individual thread contents.`,
+Use subcommands to list threads, view individual thread contents, or
+change the labels of a whole conversation at once.`,
 }
 
 // threadsListCmd represents the threads list subcommand
@@ -36,12 +37,17 @@ var threadsListCmd = &cobra.Command{
 
 Displays thread ID, snippet preview, and message count for each thread.
 Use --query for Gmail search syntax (same as web interface).
+Use --page-token, --all or --limit to page through more results. JSON output
+is an array of threads unless one of those flags is given; then it is an
+object with "threads" and "next_page_token", so scripts should pass
+--page-token "" for the first page.
 
 Examples:
   gsuite threads list
   gsuite threads list -n 20
   gsuite threads list -q "from:alice@example.com"
-  gsuite threads list --label-ids "INBOX,UNREAD"`,
+  gsuite threads list --label-ids "INBOX,UNREAD"
+  gsuite threads list -q "is:unread" --all`,
 	RunE: runThreadsList,
 }
 
@@ -52,6 +58,8 @@ var threadsGetCmd = &cobra.Command{
 	Long: `Get a Gmail thread and display all messages in the conversation.
 
 Shows messages in chronological order (oldest first) with headers and body content.
+Messages without a plain text body show their HTML body converted to text,
+with link targets listed as numbered footnotes.
 
 Example:
   gsuite threads get 18d1234567890abc`,
@@ -65,12 +73,21 @@ func init() {
 	threadsCmd.AddCommand(threadsGetCmd)
 
 	// threads list flags
-	threadsListCmd.Flags().Int64VarP(&threadsMaxResults, "max-results", "n", 10, "Maximum number of threads to return (max 500)")
-	threadsListCmd.Flags().StringVar(&threadsLabelIDs, "label-ids", "", "Comma-separated list of label IDs to filter by")
+	threadsListCmd.Flags().Int64VarP(&threadsMaxResults, "max-results", "n", 10, "Maximum number of threads per page (max 500)")
+	threadsListCmd.Flags().StringVar(&threadsLabelIDs, "label-ids", "", "Comma-separated list of label names or IDs to filter by")
 	threadsListCmd.Flags().StringVarP(&threadsQuery, "query", "q", "", "Gmail search query (same syntax as web interface)")
+	addPageFlags(threadsListCmd, &threadsListPages)
+	addConcurrencyFlag(threadsListCmd)
 }
 
 func runThreadsList(cmd *cobra.Command, args []string) error {
+	if err := threadsListPages.validate(); err != nil {
+		return err
+	}
+	if err := validateConcurrency(detailConcurrency); err != nil {
+		return err
+	}
+
 	ctx := context.Background()
 
 	service, err := auth.NewGmailService(ctx, GetAccountEmail())
@@ -81,17 +98,11 @@ func runThreadsList(cmd *cobra.Command, args []string) error {
 	// Build threads list request
 	listCall := service.Users.Threads.List("me")
 
-	// Apply max results (cap at 500)
-	if threadsMaxResults > 500 {
-		threadsMaxResults = 500
-	}
-	listCall = listCall.MaxResults(threadsMaxResults)
-
-	// Apply label IDs filter
+	// Apply label filter
 	if threadsLabelIDs != "" {
-		labels := strings.Split(threadsLabelIDs, ",")
-		for i := range labels {
-			labels[i] = strings.TrimSpace(labels[i])
+		labels, err := newLabelResolver(service).Resolve(splitCommaList(threadsLabelIDs))
+		if err != nil {
+			return err
 		}
 		listCall = listCall.LabelIds(labels...)
 	}
@@ -101,20 +112,16 @@ func runThreadsList(cmd *cobra.Command, args []string) error {
 		listCall = listCall.Q(threadsQuery)
 	}
 
-	// Execute request
-	result, err := listCall.Do()
+	// Execute request, following pages if requested
+	threads, nextPageToken, err := listThreadPages(ctx, listCall, &threadsListPages, threadsMaxResults)
 	if err != nil {
 		return fmt.Errorf("Gmail API error: %w", err)
 	}
 
-	// Handle empty results
-	if len(result.Threads) == 0 {
-		if GetOutputFormat() == "json" {
-			return outputJSON([]struct{}{})
-		}
-		fmt.Println("No threads found.")
-		return nil
-	}
+	// Fetch threads in parallel to get message counts, preserving list order
+	fullThreads, threadErrs := fetchConcurrently(len(threads), detailConcurrency, func(i int) (*gmail.Thread, error) {
+		return service.Users.Threads.Get("me", threads[i].Id).Format("minimal").Do()
+	})
 
 	// JSON output mode
 	if GetOutputFormat() == "json" {
@@ -123,12 +130,11 @@ func runThreadsList(cmd *cobra.Command, args []string) error {
 			Snippet      string `json:"snippet"`
 			MessageCount int    `json:"message_count"`
 		}
-		var results []threadListItem
-		for _, thread := range result.Threads {
+		results := []threadListItem{}
+		for i, thread := range threads {
 			msgCount := 0
-			fullThread, err := service.Users.Threads.Get("me", thread.Id).Format("minimal").Do()
-			if err == nil {
-				msgCount = len(fullThread.Messages)
+			if threadErrs[i] == nil {
+				msgCount = len(fullThreads[i].Messages)
 			}
 			results = append(results, threadListItem{
 				ThreadID:     thread.Id,
@@ -136,14 +142,18 @@ func runThreadsList(cmd *cobra.Command, args []string) error {
 				MessageCount: msgCount,
 			})
 		}
-		return outputJSON(results)
+		return outputListJSON(cmd, "threads", results, nextPageToken)
+	}
+
+	// Handle empty results
+	if len(threads) == 0 {
+		fmt.Println("No threads found.")
+		return nil
 	}
 
 	// Text output mode
-	for _, thread := range result.Threads {
-		// Get full thread to access message count
-		fullThread, err := service.Users.Threads.Get("me", thread.Id).Format("minimal").Do()
-		if err != nil {
+	for i, thread := range threads {
+		if threadErrs[i] != nil {
 			// If we can't get full thread, just print what we have
 			fmt.Printf("Thread: %s\n", thread.Id)
 			fmt.Printf("  Snippet: %s\n", truncateSnippet(thread.Snippet, 80))
@@ -152,14 +162,14 @@ func runThreadsList(cmd *cobra.Command, args []string) error {
 		}
 
 		fmt.Printf("Thread: %s\n", thread.Id)
-		fmt.Printf("  Messages: %d\n", len(fullThread.Messages))
+		fmt.Printf("  Messages: %d\n", len(fullThreads[i].Messages))
 		fmt.Printf("  Snippet: %s\n", truncateSnippet(thread.Snippet, 80))
 		fmt.Println()
 	}
 
 	// Indicate if more results available
-	if result.NextPageToken != "" {
-		fmt.Println("More results available. Use pagination to see more.")
+	if nextPageToken != "" {
+		fmt.Println(nextPageHint(nextPageToken))
 	}
 
 	return nil
@@ -201,7 +211,7 @@ func runThreadsGet(cmd *cobra.Command, args []string) error {
 			headers := make(map[string]string)
 			if msg.Payload != nil {
 				for _, h := range msg.Payload.Headers {
-					headers[strings.ToLower(h.Name)] = h.Value
+					headers[strings.ToLower(h.Name)] = decodeHeader(h.Value)
 				}
 			}
 			body := extractMessageBody(msg.Payload)
@@ -233,7 +243,7 @@ func runThreadsGet(cmd *cobra.Command, args []string) error {
 		headers := make(map[string]string)
 		if msg.Payload != nil {
 			for _, h := range msg.Payload.Headers {
-				headers[strings.ToLower(h.Name)] = h.Value
+				headers[strings.ToLower(h.Name)] = decodeHeader(h.Value)
 			}
 		}
 
@@ -265,19 +275,24 @@ func runThreadsGet(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// extractMessageBody extracts plain text body from message payload
+// extractMessageBody extracts the plain text body from a message payload,
+// falling back to the HTML body converted to text.
 func extractMessageBody(payload *gmail.MessagePart) string {
+	if body := extractPlainMessageBody(payload); body != "" {
+		return body
+	}
+	return htmlBodyText(payload)
+}
+
+// extractPlainMessageBody extracts the text/plain body from a message payload.
+func extractPlainMessageBody(payload *gmail.MessagePart) string {
 	if payload == nil {
 		return ""
 	}
 
 	// If this part has text/plain body, decode and return it
 	if payload.MimeType == "text/plain" && payload.Body != nil && payload.Body.Data != "" {
-		decoded, err := base64.URLEncoding.DecodeString(payload.Body.Data)
-		if err != nil {
-			return ""
-		}
-		return string(decoded)
+		return partText(payload)
 	}
 
 	// Check for multipart messages
@@ -285,7 +300,7 @@ func extractMessageBody(payload *gmail.MessagePart) string {
 		// First try to find text/plain
 		for _, part := range payload.Parts {
 			if part.MimeType == "text/plain" {
-				body := extractMessageBody(part)
+				body := extractPlainMessageBody(part)
 				if body != "" {
 					return body
 				}
@@ -293,7 +308,7 @@ func extractMessageBody(payload *gmail.MessagePart) string {
 		}
 		// Recurse into nested multipart
 		for _, part := range payload.Parts {
-			body := extractMessageBody(part)
+			body := extractPlainMessageBody(part)
 			if body != "" {
 				return body
 			}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+var (
+	// threadsModifyCmd flags
+	threadsAddLabels    string
+	threadsRemoveLabels string
+
+	// threadsDeleteCmd flags
+	threadsDeleteYes bool
+)
+
+// threadsModifyCmd represents the threads modify subcommand
+var threadsModifyCmd = &cobra.Command{
+	Use:   "modify <thread-id>",
+	Short: "Add or remove labels on every message in a thread",
+	Long: `Add or remove labels on every message in a conversation thread.
+
+At least one of --add-labels or --remove-labels is required.
+Labels can be system labels (INBOX, UNREAD, STARRED, etc.), user label IDs,
+or label names matched case-insensitively, including nested "Parent/Child"
+names.`,
+	Example: `  # Mark a conversation as read
+  gsuite threads modify <id> --remove-labels UNREAD
+
+  # Star a conversation and file it
+  gsuite threads modify <id> --add-labels STARRED,"Work/Clients" --remove-labels INBOX`,
+	Args: cobra.ExactArgs(1),
+	RunE: runThreadsModify,
+}
+
+// threadsArchiveCmd represents the threads archive subcommand
+var threadsArchiveCmd = &cobra.Command{
+	Use:   "archive <thread-id>",
+	Short: "Archive a thread",
+	Long: `Archive a conversation thread by removing the INBOX label from all of
+its messages. The thread stays searchable under All Mail.`,
+	Example: `  gsuite threads archive 18d1234567890abc`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runThreadsArchive,
+}
+
+// threadsTrashCmd represents the threads trash subcommand
+var threadsTrashCmd = &cobra.Command{
+	Use:   "trash <thread-id>",
+	Short: "Move a thread to the trash",
+	Long: `Move every message in a conversation thread to the trash.
+Trashed messages are deleted by Gmail after 30 days.`,
+	Example: `  gsuite threads trash 18d1234567890abc`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runThreadsTrash,
+}
+
+// threadsUntrashCmd represents the threads untrash subcommand
+var threadsUntrashCmd = &cobra.Command{
+	Use:     "untrash <thread-id>",
+	Short:   "Restore a thread from the trash",
+	Long:    `Restore every message in a conversation thread from the trash.`,
+	Example: `  gsuite threads untrash 18d1234567890abc`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runThreadsUntrash,
+}
+
+// threadsDeleteCmd represents the threads delete subcommand
+var threadsDeleteCmd = &cobra.Command{
+	Use:   "delete <thread-id>",
+	Short: "Permanently delete a thread",
+	Long: `Permanently delete a conversation thread and all of its messages.
+Deleted messages cannot be recovered; use 'threads trash' to move the thread
+to the trash instead.
+
+--yes is required to confirm. Permanent deletion needs full mail access, which
+is only granted by 'gsuite login --scopes full'.`,
+	Example: `  gsuite threads delete 18d1234567890abc --yes`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runThreadsDelete,
+}
+
+func init() {
+	threadsCmd.AddCommand(threadsModifyCmd)
+	threadsCmd.AddCommand(threadsArchiveCmd)
+	threadsCmd.AddCommand(threadsTrashCmd)
+	threadsCmd.AddCommand(threadsUntrashCmd)
+	threadsCmd.AddCommand(threadsDeleteCmd)
+
+	// threadsModifyCmd flags
+	threadsModifyCmd.Flags().StringVar(&threadsAddLabels, "add-labels", "", "Comma-separated label names or IDs to add (e.g., STARRED,Receipts)")
+	threadsModifyCmd.Flags().StringVar(&threadsRemoveLabels, "remove-labels", "", "Comma-separated label names or IDs to remove (e.g., UNREAD,INBOX)")
+
+	// threadsDeleteCmd flags
+	threadsDeleteCmd.Flags().BoolVar(&threadsDeleteYes, "yes", false, "Confirm permanent deletion")
+}
+
+func runThreadsModify(cmd *cobra.Command, args []string) error {
+	threadID := args[0]
+
+	addLabelsList := splitCommaList(threadsAddLabels)
+	removeLabelsList := splitCommaList(threadsRemoveLabels)
+	if len(addLabelsList) == 0 && len(removeLabelsList) == 0 {
+		return fmt.Errorf("at least one of --add-labels or --remove-labels required")
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	// Resolve label names to IDs
+	modifyReq := &gmail.ModifyThreadRequest{}
+	resolver := newLabelResolver(service)
+	if modifyReq.AddLabelIds, err = resolver.Resolve(addLabelsList); err != nil {
+		return err
+	}
+	if modifyReq.RemoveLabelIds, err = resolver.Resolve(removeLabelsList); err != nil {
+		return err
+	}
+
+	thread, err := service.Users.Threads.Modify("me", threadID, modifyReq).Do()
+	if err != nil {
+		return threadAPIError(threadID, err, auth.HandleGmailError)
+	}
+
+	return printThreadModified(threadID, len(thread.Messages), addLabelsList, removeLabelsList)
+}
+
+func runThreadsArchive(cmd *cobra.Command, args []string) error {
+	threadID := args[0]
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	thread, err := service.Users.Threads.Modify("me", threadID, &gmail.ModifyThreadRequest{
+		RemoveLabelIds: []string{"INBOX"},
+	}).Do()
+	if err != nil {
+		return threadAPIError(threadID, err, auth.HandleGmailError)
+	}
+
+	return printThreadModified(threadID, len(thread.Messages), nil, []string{"INBOX"})
+}
+
+func runThreadsTrash(cmd *cobra.Command, args []string) error {
+	return trashThread(args[0], true)
+}
+
+func runThreadsUntrash(cmd *cobra.Command, args []string) error {
+	return trashThread(args[0], false)
+}
+
+// trashThread moves a thread to the trash, or restores it from the trash.
+func trashThread(threadID string, trash bool) error {
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	var thread *gmail.Thread
+	if trash {
+		thread, err = service.Users.Threads.Trash("me", threadID).Do()
+	} else {
+		thread, err = service.Users.Threads.Untrash("me", threadID).Do()
+	}
+	if err != nil {
+		return threadAPIError(threadID, err, auth.HandleGmailError)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		type trashResult struct {
+			ThreadID string `json:"thread_id"`
+			Messages int    `json:"messages"`
+			Trashed  bool   `json:"trashed"`
+		}
+		return outputJSON(trashResult{
+			ThreadID: threadID,
+			Messages: len(thread.Messages),
+			Trashed:  trash,
+		})
+	}
+
+	if trash {
+		fmt.Printf("Thread moved to trash: %s\n", threadID)
+	} else {
+		fmt.Printf("Thread restored from trash: %s\n", threadID)
+	}
+	return nil
+}
+
+func runThreadsDelete(cmd *cobra.Command, args []string) error {
+	threadID := args[0]
+
+	if !threadsDeleteYes {
+		return fmt.Errorf("this will permanently delete thread %s and all its messages. Use --yes to confirm, or 'threads trash' to move it to the trash", threadID)
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	if err := service.Users.Threads.Delete("me", threadID).Do(); err != nil {
+		return threadAPIError(threadID, err, auth.HandleDeleteError)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		type deleteResult struct {
+			ThreadID string `json:"thread_id"`
+			Deleted  bool   `json:"deleted"`
+		}
+		return outputJSON(deleteResult{ThreadID: threadID, Deleted: true})
+	}
+
+	fmt.Printf("Thread deleted: %s\n", threadID)
+	return nil
+}
+
+// threadAPIError turns a Threads API error into a user-facing error. Errors
+// other than a missing thread or label are wrapped by handle.
+func threadAPIError(threadID string, err error, handle func(error) error) error {
+	if strings.Contains(err.Error(), "404") || strings.Contains(err.Error(), "Not Found") {
+		return fmt.Errorf("thread not found: %s", threadID)
+	}
+	if strings.Contains(err.Error(), "Invalid label") {
+		return fmt.Errorf("invalid label ID in request: %w", err)
+	}
+	return handle(err)
+}
+
+// printThreadModified reports a label change on a thread in the same shape
+// as 'messages modify'.
+func printThreadModified(threadID string, messages int, added, removed []string) error {
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		type modifyResult struct {
+			ThreadID      string   `json:"thread_id"`
+			Messages      int      `json:"messages"`
+			LabelsAdded   []string `json:"labels_added"`
+			LabelsRemoved []string `json:"labels_removed"`
+		}
+		result := modifyResult{
+			ThreadID:      threadID,
+			Messages:      messages,
+			LabelsAdded:   added,
+			LabelsRemoved: removed,
+		}
+		if result.LabelsAdded == nil {
+			result.LabelsAdded = []string{}
+		}
+		if result.LabelsRemoved == nil {
+			result.LabelsRemoved = []string{}
+		}
+		return outputJSON(result)
+	}
+
+	fmt.Printf("Thread modified: %s (%d messages)\n", threadID, messages)
+	if len(added) > 0 {
+		fmt.Printf("  Labels added: %s\n", strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		fmt.Printf("  Labels removed: %s\n", strings.Join(removed, ", "))
+	}
+	return nil
+}
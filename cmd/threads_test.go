@@ -2,6 +2,9 @@ package cmd
 
 import (
 	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
 	"testing"
 
 	"google.golang.org/api/gmail/v1"
@@ -118,6 +121,19 @@ func TestExtractMessageBody(t *testing.T) {
 			},
 			want: "deep nested",
 		},
+		{
+			name: "should convert the HTML body when no text/plain exists",
+			payload: &gmail.MessagePart{
+				MimeType: "multipart/alternative",
+				Parts: []*gmail.MessagePart{
+					{
+						MimeType: "text/html",
+						Body:     &gmail.MessagePartBody{Data: encode(`<p>See <a href="https://example.com">this</a></p>`)},
+					},
+				},
+			},
+			want: "See this[1]\n\n[1] https://example.com",
+		},
 		{
 			name: "should return empty when no text content exists",
 			payload: &gmail.MessagePart{
@@ -143,3 +159,78 @@ func TestExtractMessageBody(t *testing.T) {
 		})
 	}
 }
+
+func TestE2EThreadsModify(t *testing.T) {
+	srv := newE2EServer(t)
+	first := srv.AddMessage(e2eInboxMessage, "INBOX", "UNREAD")
+	reply := srv.AddMessage("From: me@example.com\r\n"+
+		"To: alice@example.com\r\n"+
+		"Subject: Re: Quarterly report\r\n"+
+		"In-Reply-To: <report@example.com>\r\n"+
+		"\r\n"+
+		"Thanks!\r\n", "INBOX", "UNREAD")
+	msg, _ := srv.Message(first)
+	threadID := msg.ThreadId
+	receipts := srv.AddLabel("Receipts")
+
+	// labelsOf returns the labels of every message in the thread.
+	labelsOf := func() [][]string {
+		var labels [][]string
+		for _, id := range []string{first, reply} {
+			m, _ := srv.Message(id)
+			labels = append(labels, m.LabelIds)
+		}
+		return labels
+	}
+
+	out := mustRunCLI(t, "threads", "modify", threadID, "--add-labels", "receipts", "--remove-labels", "UNREAD", "-f", "json")
+	var result struct {
+		ThreadID      string   `json:"thread_id"`
+		Messages      int      `json:"messages"`
+		LabelsAdded   []string `json:"labels_added"`
+		LabelsRemoved []string `json:"labels_removed"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("threads modify output is not JSON: %v\n%s", err, out)
+	}
+	if result.ThreadID != threadID || result.Messages != 2 || strings.Join(result.LabelsAdded, ",") != "receipts" || strings.Join(result.LabelsRemoved, ",") != "UNREAD" {
+		t.Errorf("threads modify result = %+v", result)
+	}
+	for _, labels := range labelsOf() {
+		if !slices.Contains(labels, receipts) || slices.Contains(labels, "UNREAD") {
+			t.Errorf("after threads modify, message labels = %v, want %s without UNREAD", labels, receipts)
+		}
+	}
+
+	mustRunCLI(t, "threads", "archive", threadID)
+	for _, labels := range labelsOf() {
+		if slices.Contains(labels, "INBOX") {
+			t.Errorf("after threads archive, message labels = %v, want no INBOX", labels)
+		}
+	}
+
+	mustRunCLI(t, "threads", "trash", threadID)
+	for _, labels := range labelsOf() {
+		if !slices.Contains(labels, "TRASH") {
+			t.Errorf("after threads trash, message labels = %v, want TRASH", labels)
+		}
+	}
+	mustRunCLI(t, "threads", "untrash", threadID)
+	for _, labels := range labelsOf() {
+		if slices.Contains(labels, "TRASH") {
+			t.Errorf("after threads untrash, message labels = %v, want no TRASH", labels)
+		}
+	}
+
+	if _, err := runCLI(t, "threads", "delete", threadID); err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("threads delete without --yes error = %v, want a --yes hint", err)
+	}
+	mustRunCLI(t, "threads", "delete", threadID, "--yes")
+	if ids := srv.MessageIDs(""); len(ids) != 0 {
+		t.Errorf("after threads delete, messages %v remain", ids)
+	}
+
+	if _, err := runCLI(t, "threads", "archive", threadID); err == nil || !strings.Contains(err.Error(), "thread not found") {
+		t.Errorf("threads archive of a deleted thread error = %v, want thread not found", err)
+	}
+}
@@ -0,0 +1,427 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/gmail/v1"
+)
+
+var (
+	// watchStartCmd flags
+	watchTopic         string
+	watchLabelIDs      string
+	watchLabelBehavior string
+
+	// watchServeCmd flags
+	watchServeAddr  string
+	watchServePath  string
+	watchServeToken string
+	watchServeReset bool
+)
+
+// watchCmd represents the watch parent command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Manage Gmail push notifications",
+	Long: `Manage Gmail push notifications delivered through Google Cloud Pub/Sub.
+
+'watch start' asks Gmail to publish a notification to a Pub/Sub topic
+whenever the mailbox changes, 'watch stop' ends that, and 'watch serve'
+runs a webhook for a Pub/Sub push subscription that turns each notification
+into a history fetch.
+
+For polling without Pub/Sub, see 'gsuite history' and 'gsuite messages watch'.`,
+}
+
+// watchStartCmd represents the watch start subcommand
+var watchStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start push notifications to a Pub/Sub topic",
+	Long: `Ask Gmail to publish mailbox change notifications to a Pub/Sub topic.
+
+The topic must exist and gmail-api-push@system.gserviceaccount.com must be
+allowed to publish to it. A watch expires after 7 days; run 'watch start'
+again (e.g. daily) to renew it.
+
+If the account has no saved push position yet, the mailbox's current
+history ID is saved, so 'watch serve' reports changes from now on. The push
+position is kept apart from those of 'history' and 'messages watch'.`,
+	Example: `  # Notify about every change
+  gsuite watch start --topic projects/my-project/topics/gmail
+
+  # Only changes to inbox messages
+  gsuite watch start --topic projects/my-project/topics/gmail --label-ids INBOX`,
+	Args: cobra.NoArgs,
+	RunE: runWatchStart,
+}
+
+// watchStopCmd represents the watch stop subcommand
+var watchStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop push notifications",
+	Long:  `Stop all push notifications for the mailbox.`,
+	Args:  cobra.NoArgs,
+	RunE:  runWatchStop,
+}
+
+// watchServeCmd represents the watch serve subcommand
+var watchServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Receive Pub/Sub push notifications and print mailbox changes",
+	Long: `Run an HTTP endpoint for a Pub/Sub push subscription.
+
+Each push request carries a notification with the account's email address
+and new history ID. The changes since the saved history position are
+fetched and printed as NDJSON, exactly like 'gsuite history', and the
+position is saved. Notifications for other accounts are acknowledged and
+ignored. Requests whose history fetch fails get a 500 response, so Pub/Sub
+delivers them again.
+
+Gmail keeps about a week of history. If the saved position has expired, the
+command exits with an error instead of skipping the lost changes; restart it
+with --reset to report changes from now on.
+
+Set --token and add ?token=<value> to the push endpoint URL to reject
+requests that don't come from your subscription. Press Ctrl+C to stop.`,
+	Example: `  # Listen on port 8080
+  gsuite watch serve --addr :8080 --token "$PUSH_TOKEN"
+
+  # Try it with a sample envelope
+  data=$(printf '{"emailAddress":"me@example.com","historyId":"123"}' | base64)
+  curl -X POST "localhost:8080/?token=$PUSH_TOKEN" -d "{\"message\":{\"data\":\"$data\"}}"`,
+	Args: cobra.NoArgs,
+	RunE: runWatchServe,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.AddCommand(watchStartCmd)
+	watchCmd.AddCommand(watchStopCmd)
+	watchCmd.AddCommand(watchServeCmd)
+
+	// watchStartCmd flags
+	watchStartCmd.Flags().StringVar(&watchTopic, "topic", "", "Pub/Sub topic, e.g. projects/my-project/topics/gmail (required)")
+	watchStartCmd.Flags().StringVar(&watchLabelIDs, "label-ids", "", "Comma-separated label names or IDs to restrict notifications to")
+	watchStartCmd.Flags().StringVar(&watchLabelBehavior, "label-filter", "include", "Whether --label-ids are included or excluded (include, exclude)")
+	watchStartCmd.MarkFlagRequired("topic")
+
+	// watchServeCmd flags
+	watchServeCmd.Flags().StringVar(&watchServeAddr, "addr", ":8080", "Address to listen on")
+	watchServeCmd.Flags().StringVar(&watchServePath, "path", "/", "URL path of the push endpoint")
+	watchServeCmd.Flags().StringVar(&watchServeToken, "token", "", "Require this value in the token query parameter")
+	watchServeCmd.Flags().BoolVar(&watchServeReset, "reset", false, "Ignore the saved position and report changes from now on")
+}
+
+func runWatchStart(cmd *cobra.Command, args []string) error {
+	if !strings.HasPrefix(watchTopic, "projects/") || !strings.Contains(watchTopic, "/topics/") {
+		return fmt.Errorf("invalid --topic %q: must look like projects/<project>/topics/<topic>", watchTopic)
+	}
+	if watchLabelBehavior != "include" && watchLabelBehavior != "exclude" {
+		return fmt.Errorf("invalid --label-filter %q: must be include or exclude", watchLabelBehavior)
+	}
+
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	req := &gmail.WatchRequest{TopicName: watchTopic}
+	if req.LabelIds, err = newLabelResolver(service).Resolve(splitCommaList(watchLabelIDs)); err != nil {
+		return err
+	}
+	if len(req.LabelIds) > 0 {
+		req.LabelFilterBehavior = watchLabelBehavior
+	}
+
+	resp, err := service.Users.Watch("me", req).Do()
+	if err != nil {
+		return fmt.Errorf("Gmail API error: %w", err)
+	}
+
+	account, err := currentAccount(service)
+	if err != nil {
+		return err
+	}
+	saved, err := auth.LoadPushHistoryID(account)
+	if err != nil {
+		return err
+	}
+	if saved == 0 {
+		if err := auth.SavePushHistoryID(account, resp.HistoryId, true); err != nil {
+			return err
+		}
+	}
+
+	expiration := time.UnixMilli(resp.Expiration)
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		type watchStartResult struct {
+			Topic      string `json:"topic"`
+			HistoryID  uint64 `json:"history_id"`
+			Expiration string `json:"expiration"`
+		}
+		return outputJSON(watchStartResult{
+			Topic:      watchTopic,
+			HistoryID:  resp.HistoryId,
+			Expiration: expiration.UTC().Format(time.RFC3339),
+		})
+	}
+
+	fmt.Printf("Push notifications started for %s\n", account)
+	fmt.Printf("Topic:      %s\n", watchTopic)
+	fmt.Printf("History ID: %d\n", resp.HistoryId)
+	fmt.Printf("Expires:    %s (run 'watch start' again before then)\n", expiration.Local().Format("Mon Jan 2, 2006 3:04 PM MST"))
+	return nil
+}
+
+func runWatchStop(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	if err := service.Users.Stop("me").Do(); err != nil {
+		return fmt.Errorf("Gmail API error: %w", err)
+	}
+
+	// JSON output mode
+	if GetOutputFormat() == "json" {
+		type watchStopResult struct {
+			Stopped bool `json:"stopped"`
+		}
+		return outputJSON(watchStopResult{Stopped: true})
+	}
+
+	fmt.Println("Push notifications stopped.")
+	return nil
+}
+
+func runWatchServe(cmd *cobra.Command, args []string) error {
+	if !strings.HasPrefix(watchServePath, "/") {
+		return fmt.Errorf("invalid --path %q: must start with /", watchServePath)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	service, err := auth.NewGmailService(ctx, GetAccountEmail())
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	account, err := currentAccount(service)
+	if err != nil {
+		return err
+	}
+	if watchServeReset {
+		profile, err := service.Users.GetProfile("me").Do()
+		if err != nil {
+			return fmt.Errorf("Gmail API error: %w", err)
+		}
+		if err := auth.SavePushHistoryID(account, profile.HistoryId, true); err != nil {
+			return err
+		}
+	}
+
+	expired := make(chan *historyExpiredError, 1)
+	mux := http.NewServeMux()
+	mux.Handle(watchServePath, &pushReceiver{
+		service: service,
+		account: account,
+		token:   watchServeToken,
+		emit: func(r historyRecord) error {
+			return outputNDJSON(r)
+		},
+		expired: expired,
+	})
+	server := &http.Server{Addr: watchServeAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "Listening for push notifications for %s on %s%s\n", account, watchServeAddr, watchServePath)
+
+	var result error
+	select {
+	case err := <-errs:
+		return fmt.Errorf("push receiver failed: %w", err)
+	case e := <-expired:
+		result = fmt.Errorf("history ID %d has expired; restart with --reset to serve changes from now", e.start)
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	return result
+}
+
+// pushEnvelope is the body of a Pub/Sub push request.
+type pushEnvelope struct {
+	Message struct {
+		Data      string `json:"data"`
+		MessageID string `json:"messageId"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+// pushNotification is the Gmail notification carried in a push envelope.
+type pushNotification struct {
+	EmailAddress string `json:"emailAddress"`
+	HistoryID    uint64 `json:"historyId"`
+}
+
+// UnmarshalJSON accepts historyId as a number or, as Gmail sends it, a string.
+func (n *pushNotification) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		EmailAddress string          `json:"emailAddress"`
+		HistoryID    json.RawMessage `json:"historyId"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	n.EmailAddress = raw.EmailAddress
+	id, err := strconv.ParseUint(strings.Trim(string(raw.HistoryID), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid historyId %s", raw.HistoryID)
+	}
+	n.HistoryID = id
+	return nil
+}
+
+// decodePushEnvelope extracts the Gmail notification from a push request body.
+func decodePushEnvelope(body []byte) (pushNotification, error) {
+	var envelope pushEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return pushNotification{}, fmt.Errorf("invalid push envelope: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(envelope.Message.Data)
+	if err != nil {
+		if data, err = base64.URLEncoding.DecodeString(envelope.Message.Data); err != nil {
+			return pushNotification{}, fmt.Errorf("invalid message data: %w", err)
+		}
+	}
+	var n pushNotification
+	if err := json.Unmarshal(data, &n); err != nil {
+		return pushNotification{}, fmt.Errorf("invalid notification: %w", err)
+	}
+	if n.EmailAddress == "" || n.HistoryID == 0 {
+		return pushNotification{}, fmt.Errorf("notification needs emailAddress and historyId")
+	}
+	return n, nil
+}
+
+// pushReceiver handles Pub/Sub push requests for one account. Requests are
+// processed one at a time, so each change is emitted once.
+type pushReceiver struct {
+	service *gmail.Service
+	account string
+	// token, if set, must match the request's token query parameter.
+	token string
+	// emit is called for every change fetched.
+	emit func(historyRecord) error
+	// expired, if set, receives the error when the saved position has
+	// expired, so the server can stop instead of skipping changes.
+	expired chan<- *historyExpiredError
+
+	mu sync.Mutex
+}
+
+func (p *pushReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if p.token != "" && subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(p.token)) != 1 {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	n, err := decodePushEnvelope(body)
+	if err != nil {
+		// Malformed envelopes will never succeed; acknowledge them.
+		fmt.Fprintf(os.Stderr, "Warning: ignoring push request: %v\n", err)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if !strings.EqualFold(n.EmailAddress, p.account) {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring notification for %s (serving %s)\n", n.EmailAddress, p.account)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := p.sync(r.Context(), n); err != nil {
+		var expired *historyExpiredError
+		if errors.As(err, &expired) && p.expired != nil {
+			select {
+			case p.expired <- expired:
+			default:
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		http.Error(w, "history fetch failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sync emits the changes since the saved history position and saves the
+// new position.
+func (p *pushReceiver) sync(ctx context.Context, n pushNotification) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	start, err := auth.LoadPushHistoryID(p.account)
+	if err != nil {
+		return err
+	}
+	if start == 0 {
+		// Nothing to compare with: changes are reported from here on.
+		return auth.SavePushHistoryID(p.account, n.HistoryID, true)
+	}
+	if n.HistoryID <= start {
+		return nil // already processed
+	}
+
+	// An expired position is returned as is: the changes since start are
+	// lost, and skipping ahead would hide that.
+	records, latest, err := listHistory(ctx, p.service, start, historyQuery{})
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := p.emit(record); err != nil {
+			return err
+		}
+	}
+	return auth.SavePushHistoryID(p.account, latest, false)
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khang/google-suite-cli/internal/auth"
+)
+
+// pushBody returns a Pub/Sub push envelope carrying notification.
+func pushBody(notification string) string {
+	data := base64.StdEncoding.EncodeToString([]byte(notification))
+	return fmt.Sprintf(`{"message":{"data":%q,"messageId":"1"},"subscription":"projects/p/subscriptions/s"}`, data)
+}
+
+func TestDecodePushEnvelope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		body      string
+		wantEmail string
+		wantID    uint64
+		wantErr   bool
+	}{
+		{
+			name:      "should decode a string historyId as Gmail sends it",
+			body:      pushBody(`{"emailAddress":"me@example.com","historyId":"9876"}`),
+			wantEmail: "me@example.com",
+			wantID:    9876,
+		},
+		{
+			name:      "should decode a numeric historyId",
+			body:      pushBody(`{"emailAddress":"me@example.com","historyId":42}`),
+			wantEmail: "me@example.com",
+			wantID:    42,
+		},
+		{
+			name:      "should accept URL-safe base64 data",
+			body:      `{"message":{"data":"` + base64.URLEncoding.EncodeToString([]byte(`{"emailAddress":"a@b.c","historyId":"7"}`)) + `"}}`,
+			wantEmail: "a@b.c",
+			wantID:    7,
+		},
+		{name: "should reject invalid JSON", body: `{"message":`, wantErr: true},
+		{name: "should reject invalid base64", body: `{"message":{"data":"%%%"}}`, wantErr: true},
+		{name: "should reject a missing historyId", body: pushBody(`{"emailAddress":"me@example.com"}`), wantErr: true},
+		{name: "should reject a non-numeric historyId", body: pushBody(`{"emailAddress":"me@example.com","historyId":"abc"}`), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := decodePushEnvelope([]byte(tt.body))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("decodePushEnvelope() expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodePushEnvelope() unexpected error: %v", err)
+			}
+			if got.EmailAddress != tt.wantEmail || got.HistoryID != tt.wantID {
+				t.Errorf("decodePushEnvelope() = %+v, want %s %d", got, tt.wantEmail, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestE2EWatchPush(t *testing.T) {
+	srv := newE2EServer(t)
+
+	mustRunCLI(t, "watch", "start", "--topic", "projects/p/topics/gmail", "--label-ids", "inbox")
+	if w := srv.Watch(); w == nil || w.TopicName != "projects/p/topics/gmail" || strings.Join(w.LabelIds, ",") != "INBOX" || w.LabelFilterBehavior != "include" {
+		t.Fatalf("watch request = %+v, want the topic with INBOX included", w)
+	}
+	if saved, _ := auth.LoadPushHistoryID(srv.Email()); saved != srv.HistoryID() {
+		t.Errorf("saved push position after watch start = %d, want %d", saved, srv.HistoryID())
+	}
+	if saved, _ := auth.LoadHistoryID(srv.Email()); saved != 0 {
+		t.Errorf("watch start saved the history command's position: %d", saved)
+	}
+	if _, err := runCLI(t, "watch", "start", "--topic", "gmail"); err == nil {
+		t.Error("watch start with a bare topic name succeeded, want an error")
+	}
+
+	service, err := auth.NewGmailService(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var emitted []historyRecord
+	expired := make(chan *historyExpiredError, 1)
+	receiver := &pushReceiver{
+		service: service,
+		account: srv.Email(),
+		token:   "secret",
+		emit: func(r historyRecord) error {
+			emitted = append(emitted, r)
+			return nil
+		},
+		expired: expired,
+	}
+	post := func(target, body string) int {
+		rec := httptest.NewRecorder()
+		receiver.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	id := srv.AddMessage(e2eInboxMessage, "INBOX")
+	notification := pushBody(fmt.Sprintf(`{"emailAddress":%q,"historyId":"%d"}`, srv.Email(), srv.HistoryID()))
+
+	if code := post("/?token=wrong", notification); code != http.StatusForbidden {
+		t.Errorf("push with a wrong token = %d, want 403", code)
+	}
+	if code := post("/?token=secret", notification); code != http.StatusNoContent {
+		t.Fatalf("push = %d, want 204", code)
+	}
+	if len(emitted) != 1 || emitted[0].Type != "messagesAdded" || emitted[0].MessageID != id {
+		t.Errorf("push emitted %+v, want messagesAdded for %s", emitted, id)
+	}
+	if saved, _ := auth.LoadPushHistoryID(srv.Email()); saved != srv.HistoryID() {
+		t.Errorf("saved push position after push = %d, want %d", saved, srv.HistoryID())
+	}
+
+	// Redelivered and foreign notifications are acknowledged without output.
+	emitted = nil
+	if code := post("/?token=secret", notification); code != http.StatusNoContent || len(emitted) != 0 {
+		t.Errorf("redelivered push = %d emitting %+v, want 204 and nothing", code, emitted)
+	}
+	other := pushBody(`{"emailAddress":"other@example.com","historyId":"99999"}`)
+	if code := post("/?token=secret", other); code != http.StatusNoContent || len(emitted) != 0 {
+		t.Errorf("push for another account = %d emitting %+v, want 204 and nothing", code, emitted)
+	}
+	if code := post("/?token=secret", `not json`); code != http.StatusNoContent {
+		t.Errorf("malformed push = %d, want 204", code)
+	}
+
+	rec := httptest.NewRecorder()
+	receiver.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?token=secret", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET = %d, want 405", rec.Code)
+	}
+
+	// An expired position stops the receiver rather than skipping changes.
+	saved, _ := auth.LoadPushHistoryID(srv.Email())
+	srv.AddMessage(e2eInboxMessage, "INBOX")
+	srv.ExpireHistory()
+	emitted = nil
+	gap := pushBody(fmt.Sprintf(`{"emailAddress":%q,"historyId":"%d"}`, srv.Email(), srv.HistoryID()))
+	if code := post("/?token=secret", gap); code != http.StatusInternalServerError || len(emitted) != 0 {
+		t.Errorf("push after the position expired = %d emitting %+v, want 500 and nothing", code, emitted)
+	}
+	select {
+	case e := <-expired:
+		if e.start != saved {
+			t.Errorf("expired position = %d, want %d", e.start, saved)
+		}
+	default:
+		t.Error("push after the position expired did not report it")
+	}
+	if got, _ := auth.LoadPushHistoryID(srv.Email()); got != saved {
+		t.Errorf("push position after expiry = %d, want it kept at %d", got, saved)
+	}
+
+	// An API failure asks Pub/Sub to redeliver.
+	srv.AddMessage(e2eInboxMessage, "INBOX")
+	srv.Close()
+	later := pushBody(fmt.Sprintf(`{"emailAddress":%q,"historyId":"%d"}`, srv.Email(), srv.HistoryID()))
+	if code := post("/?token=secret", later); code != http.StatusInternalServerError {
+		t.Errorf("push with the API down = %d, want 500", code)
+	}
+}
+
+func TestE2EWatchStop(t *testing.T) {
+	srv := newE2EServer(t)
+
+	out := mustRunCLI(t, "watch", "start", "--topic", "projects/p/topics/gmail", "-f", "json")
+	if !strings.Contains(out, `"history_id"`) || !strings.Contains(out, `"expiration"`) {
+		t.Errorf("watch start JSON = %s, want history_id and expiration", out)
+	}
+	mustRunCLI(t, "watch", "stop")
+	if w := srv.Watch(); w != nil {
+		t.Errorf("watch after stop = %+v, want nil", w)
+	}
+}
@@ -66,13 +66,14 @@ func extractOAuth2ClientCreds(jsonData []byte) (clientID, clientSecret string, e
 
 // Login performs the OAuth2 PKCE browser login flow, saves the per-account
 // token, updates the account store, and returns the authenticated user's email.
-func Login(ctx context.Context, credJSON []byte) (string, error) {
+// extraScopes, such as FullMailScopes, are requested on top of the defaults.
+func Login(ctx context.Context, credJSON []byte, extraScopes ...string) (string, error) {
 	clientID, clientSecret, err := extractOAuth2ClientCreds(credJSON)
 	if err != nil {
 		return "", fmt.Errorf("failed to extract OAuth2 client credentials: %w", err)
 	}
 
-	oauthCfg := NewOAuth2Config(clientID, clientSecret)
+	oauthCfg := NewOAuth2Config(clientID, clientSecret, extraScopes...)
 	token, err := oauthCfg.Authenticate(ctx)
 	if err != nil {
 		return "", fmt.Errorf("authentication failed: %w", err)
@@ -108,65 +109,73 @@ func Login(ctx context.Context, credJSON []byte) (string, error) {
 }
 
 // newAuthenticatedClient loads credentials, resolves the account, and returns
-// a configured OAuth2Config and token ready to create service clients.
-func newAuthenticatedClient(ctx context.Context, account string) (*OAuth2Config, *oauth2.Token, error) {
+// a token source that persists refreshed tokens to the account's token file.
+func newAuthenticatedClient(ctx context.Context, account string) (oauth2.TokenSource, error) {
 	credJSON, err := LoadCredentials()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load credentials: %w", err)
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
 	}
 
 	clientID, clientSecret, err := extractOAuth2ClientCreds(credJSON)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	if err := EnsureMigrated(ctx); err != nil {
-		return nil, nil, fmt.Errorf("failed to run migration: %w", err)
+		return nil, fmt.Errorf("failed to run migration: %w", err)
 	}
 
 	resolvedEmail := account
 	if resolvedEmail == "" {
 		store, err := LoadAccountStore()
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to load account store: %w", err)
+			return nil, fmt.Errorf("failed to load account store: %w", err)
 		}
 		resolvedEmail, err = store.GetActive()
 		if err != nil {
-			return nil, nil, fmt.Errorf("no authenticated accounts. Run 'gsuite login' first")
+			return nil, fmt.Errorf("no authenticated accounts. Run 'gsuite login' first")
 		}
 	}
 
 	token, err := LoadTokenFor(resolvedEmail)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil, fmt.Errorf("no token for account %s. Run 'gsuite login' to authenticate", resolvedEmail)
+			return nil, fmt.Errorf("no token for account %s. Run 'gsuite login' to authenticate", resolvedEmail)
 		}
-		return nil, nil, fmt.Errorf("failed to load token for %s: %w", resolvedEmail, err)
+		return nil, fmt.Errorf("failed to load token for %s: %w", resolvedEmail, err)
 	}
 
 	oauthCfg := NewOAuth2Config(clientID, clientSecret)
-	return oauthCfg, token, nil
+	return oauthCfg.PersistingTokenSource(ctx, resolvedEmail, token), nil
 }
 
 // NewGmailService creates an authenticated Gmail service for the given account.
 // If account is empty, the active account from AccountStore is used.
 // Runs EnsureMigrated to transparently upgrade legacy single-token setups.
+// If GSUITE_API_ENDPOINT is set, the service talks to that server instead.
 func NewGmailService(ctx context.Context, account string) (*gmail.Service, error) {
-	oauthCfg, token, err := newAuthenticatedClient(ctx, account)
+	if endpoint := apiEndpoint(); endpoint != "" {
+		return newEndpointGmailService(ctx, endpoint)
+	}
+	tokenSource, err := newAuthenticatedClient(ctx, account)
 	if err != nil {
 		return nil, err
 	}
-	return oauthCfg.NewGmailService(ctx, token)
+	return newGmailService(ctx, tokenSource)
 }
 
 // NewCalendarService creates an authenticated Calendar service for the given account.
 // If account is empty, the active account from AccountStore is used.
+// If GSUITE_API_ENDPOINT is set, the service talks to that server instead.
 func NewCalendarService(ctx context.Context, account string) (*calendar.Service, error) {
-	oauthCfg, token, err := newAuthenticatedClient(ctx, account)
+	if endpoint := apiEndpoint(); endpoint != "" {
+		return newEndpointCalendarService(ctx, endpoint)
+	}
+	tokenSource, err := newAuthenticatedClient(ctx, account)
 	if err != nil {
 		return nil, err
 	}
-	return oauthCfg.NewCalendarService(ctx, token)
+	return newCalendarService(ctx, tokenSource)
 }
 
 // isInsufficientScopeError checks if an API error is a 403 with insufficientPermissions reason.
@@ -203,3 +212,30 @@ func HandleCalendarError(err error, context string) error {
 	}
 	return fmt.Errorf("%s: %w", context, err)
 }
+
+// HandleGmailError wraps a Gmail API error for display. A 403 for a missing
+// OAuth scope, which tokens from before the scope was requested lack, is
+// turned into a hint to log in again.
+func HandleGmailError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if isInsufficientScopeError(err) {
+		return fmt.Errorf("Gmail API error: permission not granted. Run 'gsuite login' to re-authenticate with the required access: %w", err)
+	}
+	return fmt.Errorf("Gmail API error: %w", err)
+}
+
+// HandleDeleteError wraps an error from a permanent deletion for display.
+// Deletion needs FullMailScopes, which a plain 'gsuite login' does not
+// request, so a missing scope is turned into a hint to opt in or to use the
+// trash instead.
+func HandleDeleteError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if isInsufficientScopeError(err) {
+		return fmt.Errorf("Gmail API error: permanent deletion needs full mail access. Run 'gsuite login --scopes full' to grant it, or move the messages to the trash instead: %w", err)
+	}
+	return fmt.Errorf("Gmail API error: %w", err)
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -209,3 +210,119 @@ func TestHandleCalendarError(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleGmailError(t *testing.T) {
+	t.Parallel()
+
+	notFound := &googleapi.Error{Code: 404}
+	tests := []struct {
+		name           string
+		err            error
+		wantNil        bool
+		wantErrContain string
+		wantWrapped    error
+	}{
+		{
+			name:    "should return nil for nil error",
+			err:     nil,
+			wantNil: true,
+		},
+		{
+			name: "should suggest login for 403 insufficient scope",
+			err: &googleapi.Error{
+				Code:   403,
+				Errors: []googleapi.ErrorItem{{Reason: "insufficientPermissions"}},
+			},
+			wantErrContain: "gsuite login",
+		},
+		{
+			name:           "should wrap other errors",
+			err:            notFound,
+			wantErrContain: "Gmail API error",
+			wantWrapped:    notFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := HandleGmailError(tt.err)
+
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("expected nil, got %v", got)
+				}
+				return
+			}
+
+			if got == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(got.Error(), tt.wantErrContain) {
+				t.Errorf("error %q does not contain %q", got.Error(), tt.wantErrContain)
+			}
+			if tt.wantWrapped != nil && !errors.Is(got, tt.wantWrapped) {
+				t.Errorf("error %v does not wrap %v", got, tt.wantWrapped)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteError(t *testing.T) {
+	t.Parallel()
+
+	notFound := &googleapi.Error{Code: 404}
+	tests := []struct {
+		name           string
+		err            error
+		wantNil        bool
+		wantErrContain string
+		wantWrapped    error
+	}{
+		{
+			name:    "should return nil for nil error",
+			err:     nil,
+			wantNil: true,
+		},
+		{
+			name: "should suggest opting in to full access for 403 insufficient scope",
+			err: &googleapi.Error{
+				Code:   403,
+				Errors: []googleapi.ErrorItem{{Reason: "insufficientPermissions"}},
+			},
+			wantErrContain: "gsuite login --scopes full",
+		},
+		{
+			name:           "should wrap other errors",
+			err:            notFound,
+			wantErrContain: "Gmail API error",
+			wantWrapped:    notFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := HandleDeleteError(tt.err)
+
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("expected nil, got %v", got)
+				}
+				return
+			}
+
+			if got == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(got.Error(), tt.wantErrContain) {
+				t.Errorf("error %q does not contain %q", got.Error(), tt.wantErrContain)
+			}
+			if tt.wantWrapped != nil && !errors.Is(got, tt.wantWrapped) {
+				t.Errorf("error %v does not wrap %v", got, tt.wantWrapped)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	calendar "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+// APIEndpointEnv names the environment variable that points gsuite at an
+// alternative API server, such as the internal/fakegoogle test server. When
+// it is set, no credentials or tokens are loaded and requests are sent
+// unauthenticated.
+const APIEndpointEnv = "GSUITE_API_ENDPOINT"
+
+// apiEndpoint returns the API endpoint override, or "" if none is set.
+func apiEndpoint() string {
+	return strings.TrimRight(os.Getenv(APIEndpointEnv), "/")
+}
+
+// newEndpointGmailService creates an unauthenticated Gmail service for the
+// server at endpoint, which serves the API under /gmail/v1/.
+func newEndpointGmailService(ctx context.Context, endpoint string) (*gmail.Service, error) {
+	service, err := gmail.NewService(ctx, option.WithEndpoint(endpoint+"/"), option.WithoutAuthentication())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gmail service: %w", err)
+	}
+	return service, nil
+}
+
+// newEndpointCalendarService creates an unauthenticated Calendar service for
+// the server at endpoint, which serves the API under /calendar/v3/.
+func newEndpointCalendarService(ctx context.Context, endpoint string) (*calendar.Service, error) {
+	service, err := calendar.NewService(ctx, option.WithEndpoint(endpoint+"/calendar/v3/"), option.WithoutAuthentication())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Calendar service: %w", err)
+	}
+	return service, nil
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/khang/google-suite-cli/internal/fakegoogle"
+)
+
+func TestNewServicesUseEndpointOverride(t *testing.T) {
+	srv := fakegoogle.NewServer("fake@example.com")
+	defer srv.Close()
+
+	// No credentials or accounts exist; the override must not need them.
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GOOGLE_CREDENTIALS", "")
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv(APIEndpointEnv, srv.URL+"/")
+
+	ctx := context.Background()
+
+	gmailService, err := NewGmailService(ctx, "")
+	if err != nil {
+		t.Fatalf("NewGmailService() error: %v", err)
+	}
+	profile, err := gmailService.Users.GetProfile("me").Do()
+	if err != nil {
+		t.Fatalf("GetProfile() error: %v", err)
+	}
+	if profile.EmailAddress != "fake@example.com" {
+		t.Errorf("GetProfile() email = %q, want %q", profile.EmailAddress, "fake@example.com")
+	}
+
+	calendarService, err := NewCalendarService(ctx, "")
+	if err != nil {
+		t.Fatalf("NewCalendarService() error: %v", err)
+	}
+	list, err := calendarService.CalendarList.List().Do()
+	if err != nil {
+		t.Fatalf("CalendarList.List() error: %v", err)
+	}
+	if len(list.Items) != 1 || !list.Items[0].Primary {
+		t.Errorf("CalendarList.List() = %d items, want the primary calendar only", len(list.Items))
+	}
+}
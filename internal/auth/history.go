@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/khang/google-suite-cli/internal/filelock"
+)
+
+const historyFile = "history.json"
+
+// HistoryEntry records how far an account's mailbox history has been read.
+type HistoryEntry struct {
+	HistoryID uint64    `json:"history_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HistoryStore maps account emails to their last synced history ID.
+type HistoryStore struct {
+	Accounts map[string]HistoryEntry `json:"accounts"`
+	// Watch holds the positions of 'messages watch', kept apart from
+	// Accounts so a watcher never consumes changes 'history' has not read.
+	Watch map[string]HistoryEntry `json:"watch,omitempty"`
+	// Push holds the positions of 'watch serve', kept apart for the same
+	// reason.
+	Push map[string]HistoryEntry `json:"push,omitempty"`
+}
+
+// historyReader identifies the command whose position is read or saved.
+type historyReader int
+
+const (
+	readerHistory historyReader = iota // 'history'
+	readerWatch                        // 'messages watch'
+	readerPush                         // 'watch start' and 'watch serve'
+)
+
+// section returns the position map of reader.
+func (s *HistoryStore) section(reader historyReader) map[string]HistoryEntry {
+	switch reader {
+	case readerWatch:
+		return s.Watch
+	case readerPush:
+		return s.Push
+	}
+	return s.Accounts
+}
+
+// HistoryStorePath returns the path to ~/.config/gsuite/history.json, next to
+// accounts.json, creating the parent directory if needed.
+func HistoryStorePath() (string, error) {
+	accountsPath, err := AccountStorePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(accountsPath), historyFile), nil
+}
+
+// loadHistoryStore reads history.json. A missing file is an empty store.
+func loadHistoryStore(path string) (*HistoryStore, error) {
+	store := &HistoryStore{
+		Accounts: make(map[string]HistoryEntry),
+		Watch:    make(map[string]HistoryEntry),
+		Push:     make(map[string]HistoryEntry),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read history file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse history file %s: %w", path, err)
+	}
+	if store.Accounts == nil {
+		store.Accounts = make(map[string]HistoryEntry)
+	}
+	if store.Watch == nil {
+		store.Watch = make(map[string]HistoryEntry)
+	}
+	if store.Push == nil {
+		store.Push = make(map[string]HistoryEntry)
+	}
+	return store, nil
+}
+
+// LoadHistoryID returns the last saved history ID for email, or 0 if none
+// has been saved.
+func LoadHistoryID(email string) (uint64, error) {
+	return loadPosition(email, readerHistory)
+}
+
+// LoadWatchHistoryID returns the last history ID 'messages watch' processed
+// for email, or 0 if none has been saved.
+func LoadWatchHistoryID(email string) (uint64, error) {
+	return loadPosition(email, readerWatch)
+}
+
+// LoadPushHistoryID returns the last history ID 'watch serve' processed for
+// email, or 0 if none has been saved.
+func LoadPushHistoryID(email string) (uint64, error) {
+	return loadPosition(email, readerPush)
+}
+
+// SaveHistoryID records id as the last synced history ID for email. The file
+// is updated under a lock so concurrent gsuite processes don't lose writes.
+// When reset is false, an id lower than the saved one is ignored, so a slower
+// process never moves the saved position backwards.
+func SaveHistoryID(email string, id uint64, reset bool) error {
+	return savePosition(email, id, reset, readerHistory)
+}
+
+// SaveWatchHistoryID is SaveHistoryID for the 'messages watch' position.
+func SaveWatchHistoryID(email string, id uint64, reset bool) error {
+	return savePosition(email, id, reset, readerWatch)
+}
+
+// SavePushHistoryID is SaveHistoryID for the 'watch serve' position.
+func SavePushHistoryID(email string, id uint64, reset bool) error {
+	return savePosition(email, id, reset, readerPush)
+}
+
+func loadPosition(email string, reader historyReader) (uint64, error) {
+	path, err := HistoryStorePath()
+	if err != nil {
+		return 0, err
+	}
+	store, err := loadHistoryStore(path)
+	if err != nil {
+		return 0, err
+	}
+	return store.section(reader)[email].HistoryID, nil
+}
+
+func savePosition(email string, id uint64, reset bool, reader historyReader) error {
+	if email == "" {
+		return fmt.Errorf("email cannot be empty")
+	}
+	path, err := HistoryStorePath()
+	if err != nil {
+		return err
+	}
+
+	lock, err := filelock.Acquire(path+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to lock history file: %w", err)
+	}
+	defer lock.Release() //nolint:errcheck
+
+	store, err := loadHistoryStore(path)
+	if err != nil {
+		return err
+	}
+	positions := store.section(reader)
+	if !reset && positions[email].HistoryID > id {
+		return nil
+	}
+	positions[email] = HistoryEntry{HistoryID: id, UpdatedAt: time.Now().UTC()}
+
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history store: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write history file %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write history file %s: %w", path, err)
+	}
+	return nil
+}
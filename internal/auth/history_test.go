@@ -0,0 +1,65 @@
+package auth
+
+import "testing"
+
+func TestHistoryIDRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if id, err := LoadHistoryID("alice@example.com"); err != nil || id != 0 {
+		t.Fatalf("LoadHistoryID with no file = %d, %v, want 0, nil", id, err)
+	}
+
+	steps := []struct {
+		name  string
+		email string
+		id    uint64
+		reset bool
+		want  uint64
+	}{
+		{name: "should save the first ID", email: "alice@example.com", id: 100, want: 100},
+		{name: "should advance the ID", email: "alice@example.com", id: 150, want: 150},
+		{name: "should not move the ID backwards", email: "alice@example.com", id: 120, want: 150},
+		{name: "should move the ID backwards on reset", email: "alice@example.com", id: 90, reset: true, want: 90},
+		{name: "should keep accounts separate", email: "bob@example.com", id: 7, want: 7},
+	}
+	for _, step := range steps {
+		if err := SaveHistoryID(step.email, step.id, step.reset); err != nil {
+			t.Fatalf("%s: SaveHistoryID error: %v", step.name, err)
+		}
+		got, err := LoadHistoryID(step.email)
+		if err != nil {
+			t.Fatalf("%s: LoadHistoryID error: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: LoadHistoryID = %d, want %d", step.name, got, step.want)
+		}
+	}
+
+	if got, _ := LoadHistoryID("alice@example.com"); got != 90 {
+		t.Errorf("alice history ID after saving bob = %d, want 90", got)
+	}
+	if err := SaveWatchHistoryID("alice@example.com", 500, false); err != nil {
+		t.Fatalf("SaveWatchHistoryID error: %v", err)
+	}
+	if got, _ := LoadWatchHistoryID("alice@example.com"); got != 500 {
+		t.Errorf("LoadWatchHistoryID = %d, want 500", got)
+	}
+	if got, _ := LoadHistoryID("alice@example.com"); got != 90 {
+		t.Errorf("alice history ID after saving the watch position = %d, want 90", got)
+	}
+	if err := SavePushHistoryID("alice@example.com", 700, false); err != nil {
+		t.Fatalf("SavePushHistoryID error: %v", err)
+	}
+	if got, _ := LoadPushHistoryID("alice@example.com"); got != 700 {
+		t.Errorf("LoadPushHistoryID = %d, want 700", got)
+	}
+	if got, _ := LoadWatchHistoryID("alice@example.com"); got != 500 {
+		t.Errorf("alice watch position after saving the push position = %d, want 500", got)
+	}
+	if got, _ := LoadHistoryID("alice@example.com"); got != 90 {
+		t.Errorf("alice history ID after saving the push position = %d, want 90", got)
+	}
+	if err := SaveHistoryID("", 1, false); err == nil {
+		t.Error("SaveHistoryID with empty email succeeded, want an error")
+	}
+}
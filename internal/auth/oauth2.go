@@ -34,21 +34,28 @@ type OAuth2Config struct {
 	config *oauth2.Config
 }
 
+// FullMailScopes are requested in addition to the default scopes by
+// 'gsuite login --scopes full'. They allow permanent deletion, which only
+// 'messages batch-delete' and 'threads delete' need, so they are opt-in.
+var FullMailScopes = []string{gmail.MailGoogleComScope}
+
 // NewOAuth2Config creates a new OAuth2Config with the given client credentials.
 // ClientID and ClientSecret should come from a Google OAuth2 client configuration;
-// they must not be hardcoded.
-func NewOAuth2Config(clientID, clientSecret string) *OAuth2Config {
+// they must not be hardcoded. extraScopes are requested on top of the defaults.
+func NewOAuth2Config(clientID, clientSecret string, extraScopes ...string) *OAuth2Config {
+	scopes := []string{
+		gmail.GmailModifyScope,
+		gmail.GmailSettingsBasicScope,
+		calendar.CalendarEventsScope,
+		calendar.CalendarReadonlyScope,
+	}
 	return &OAuth2Config{
 		config: &oauth2.Config{
 			ClientID:     clientID,
 			ClientSecret: clientSecret,
 			Endpoint:     google.Endpoint,
 			RedirectURL:  redirectURL,
-			Scopes: []string{
-				gmail.GmailModifyScope,
-				calendar.CalendarEventsScope,
-				calendar.CalendarReadonlyScope,
-			},
+			Scopes:       append(scopes, extraScopes...),
 		},
 	}
 }
@@ -158,7 +165,16 @@ func (c *OAuth2Config) Authenticate(ctx context.Context) (*oauth2.Token, error)
 
 // NewGmailService creates an authenticated Gmail service from an existing OAuth2 token.
 func (c *OAuth2Config) NewGmailService(ctx context.Context, token *oauth2.Token) (*gmail.Service, error) {
-	tokenSource := c.config.TokenSource(ctx, token)
+	return newGmailService(ctx, c.config.TokenSource(ctx, token))
+}
+
+// NewCalendarService creates an authenticated Calendar service from an existing OAuth2 token.
+func (c *OAuth2Config) NewCalendarService(ctx context.Context, token *oauth2.Token) (*calendar.Service, error) {
+	return newCalendarService(ctx, c.config.TokenSource(ctx, token))
+}
+
+// newGmailService creates a Gmail service that authenticates with tokenSource.
+func newGmailService(ctx context.Context, tokenSource oauth2.TokenSource) (*gmail.Service, error) {
 	client := oauth2.NewClient(ctx, tokenSource)
 
 	service, err := gmail.NewService(ctx, option.WithHTTPClient(client))
@@ -169,9 +185,8 @@ func (c *OAuth2Config) NewGmailService(ctx context.Context, token *oauth2.Token)
 	return service, nil
 }
 
-// NewCalendarService creates an authenticated Calendar service from an existing OAuth2 token.
-func (c *OAuth2Config) NewCalendarService(ctx context.Context, token *oauth2.Token) (*calendar.Service, error) {
-	tokenSource := c.config.TokenSource(ctx, token)
+// newCalendarService creates a Calendar service that authenticates with tokenSource.
+func newCalendarService(ctx context.Context, tokenSource oauth2.TokenSource) (*calendar.Service, error) {
 	client := oauth2.NewClient(ctx, tokenSource)
 
 	service, err := calendar.NewService(ctx, option.WithHTTPClient(client))
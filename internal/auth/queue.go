@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/khang/google-suite-cli/internal/filelock"
+)
+
+const queueFile = "queue.json"
+
+// QueuedSend is a draft waiting in the local queue to be sent at SendAt.
+type QueuedSend struct {
+	DraftID   string    `json:"draft_id"`
+	Account   string    `json:"account"`
+	To        string    `json:"to"`
+	Subject   string    `json:"subject"`
+	SendAt    time.Time `json:"send_at"`
+	CreatedAt time.Time `json:"created_at"`
+	// ClaimedAt is when a 'queue run' started sending the draft. It is zero
+	// while the draft waits.
+	ClaimedAt time.Time `json:"claimed_at,omitzero"`
+}
+
+// sendQueue is the on-disk form of the queue.
+type sendQueue struct {
+	Entries []QueuedSend `json:"entries"`
+}
+
+// SendQueuePath returns the path to ~/.config/gsuite/queue.json, next to
+// accounts.json, creating the parent directory if needed.
+func SendQueuePath() (string, error) {
+	accountsPath, err := AccountStorePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(accountsPath), queueFile), nil
+}
+
+// loadSendQueue reads queue.json. A missing file is an empty queue.
+func loadSendQueue(path string) ([]QueuedSend, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read queue file %s: %w", path, err)
+	}
+	var queue sendQueue
+	if err := json.Unmarshal(data, &queue); err != nil {
+		return nil, fmt.Errorf("failed to parse queue file %s: %w", path, err)
+	}
+	return queue.Entries, nil
+}
+
+// LoadSendQueue returns the queued sends of every account.
+func LoadSendQueue() ([]QueuedSend, error) {
+	path, err := SendQueuePath()
+	if err != nil {
+		return nil, err
+	}
+	return loadSendQueue(path)
+}
+
+// UpdateSendQueue calls fn with the queued sends and saves the entries it
+// returns. The file is locked from read to write, so concurrent gsuite
+// processes (such as overlapping cron runs) see each other's changes. If fn
+// returns an error, nothing is saved.
+func UpdateSendQueue(fn func(entries []QueuedSend) ([]QueuedSend, error)) error {
+	path, err := SendQueuePath()
+	if err != nil {
+		return err
+	}
+
+	lock, err := filelock.Acquire(path+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to lock queue file: %w", err)
+	}
+	defer lock.Release() //nolint:errcheck
+
+	entries, err := loadSendQueue(path)
+	if err != nil {
+		return err
+	}
+	entries, err = fn(entries)
+	if err != nil {
+		return err
+	}
+	if entries == nil {
+		entries = []QueuedSend{}
+	}
+
+	data, err := json.MarshalIndent(sendQueue{Entries: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal queue: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write queue file %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write queue file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateSendQueue(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if entries, err := LoadSendQueue(); err != nil || len(entries) != 0 {
+		t.Fatalf("LoadSendQueue with no file = %v, %v, want empty", entries, err)
+	}
+
+	sendAt := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	err := UpdateSendQueue(func(entries []QueuedSend) ([]QueuedSend, error) {
+		return append(entries, QueuedSend{DraftID: "r-1", Account: "alice@example.com", SendAt: sendAt}), nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateSendQueue error: %v", err)
+	}
+
+	// Concurrent updates must not lose each other's entries.
+	var wg sync.WaitGroup
+	for _, id := range []string{"r-2", "r-3", "r-4", "r-5"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := UpdateSendQueue(func(entries []QueuedSend) ([]QueuedSend, error) {
+				return append(entries, QueuedSend{DraftID: id, SendAt: sendAt}), nil
+			})
+			if err != nil {
+				t.Errorf("UpdateSendQueue(%s) error: %v", id, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := LoadSendQueue()
+	if err != nil {
+		t.Fatalf("LoadSendQueue error: %v", err)
+	}
+	if len(entries) != 5 {
+		t.Fatalf("LoadSendQueue = %d entries, want 5", len(entries))
+	}
+	if entries[0].DraftID != "r-1" || entries[0].Account != "alice@example.com" || !entries[0].SendAt.Equal(sendAt) || !entries[0].ClaimedAt.IsZero() {
+		t.Errorf("first entry = %+v", entries[0])
+	}
+
+	wantErr := errors.New("stop")
+	err = UpdateSendQueue(func(entries []QueuedSend) ([]QueuedSend, error) {
+		return nil, wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("UpdateSendQueue error = %v, want %v", err, wantErr)
+	}
+	if entries, _ := LoadSendQueue(); len(entries) != 5 {
+		t.Errorf("queue after a failed update has %d entries, want 5", len(entries))
+	}
+}
@@ -13,6 +13,7 @@ const (
 	tokenDir  = "gsuite"
 	tokenFile = "token.json"
 	tokensDir = "tokens"
+	cacheDir  = "cache"
 )
 
 // LegacyTokenPath returns the path to the legacy single-user token file
@@ -96,6 +97,27 @@ func TokensDir() (string, error) {
 	return dir, nil
 }
 
+// CacheDir returns the path to ~/.config/gsuite/cache/, where commands keep
+// data that can be refetched, creating the directory with 0700 permissions
+// if needed.
+func CacheDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		home, homeErr := os.UserHomeDir()
+		if homeErr != nil {
+			return "", fmt.Errorf("failed to determine config directory: %w", err)
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+
+	dir := filepath.Join(configDir, tokenDir, cacheDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+
+	return dir, nil
+}
+
 // TokenPathFor returns the per-account token file path:
 // ~/.config/gsuite/tokens/<email>.json
 func TokenPathFor(email string) (string, error) {
@@ -112,7 +134,8 @@ func TokenPathFor(email string) (string, error) {
 }
 
 // SaveTokenFor writes an OAuth2 token to the per-account token file
-// with 0600 permissions.
+// with 0600 permissions. The file is replaced atomically so concurrent
+// readers never observe a partially written token.
 func SaveTokenFor(email string, token *oauth2.Token) error {
 	path, err := TokenPathFor(email)
 	if err != nil {
@@ -124,7 +147,22 @@ func SaveTokenFor(email string, token *oauth2.Token) error {
 		return fmt.Errorf("failed to marshal token: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0600); err != nil {
+	// CreateTemp creates the file with 0600 permissions.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp token file for %s: %w", path, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) //nolint:errcheck
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token file %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token file %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
 		return fmt.Errorf("failed to write token file %s: %w", path, err)
 	}
 
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/khang/google-suite-cli/internal/filelock"
+	"golang.org/x/oauth2"
+)
+
+// persistingTokenSource refreshes the per-account token under a file lock
+// and writes the result back to tokens/<email>.json, so refreshed access
+// tokens and rotated refresh tokens survive across invocations.
+type persistingTokenSource struct {
+	ctx    context.Context
+	config *oauth2.Config
+	email  string
+}
+
+// Token reloads the token file while holding tokens/<email>.json.lock.
+// If another process already refreshed it, that token is reused; otherwise
+// the token is refreshed and saved before the lock is released.
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	path, err := TokenPathFor(s.email)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := filelock.Acquire(path+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock token for %s: %w", s.email, err)
+	}
+	defer lock.Release() //nolint:errcheck
+
+	current, err := LoadTokenFor(s.email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token for %s: %w", s.email, err)
+	}
+	if current.Valid() {
+		return current, nil
+	}
+
+	// config.TokenSource keeps the old refresh token if the server
+	// doesn't rotate it.
+	refreshed, err := s.config.TokenSource(s.ctx, current).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token for %s: %w", s.email, err)
+	}
+
+	if err := SaveTokenFor(s.email, refreshed); err != nil {
+		return nil, fmt.Errorf("failed to save refreshed token for %s: %w", s.email, err)
+	}
+
+	return refreshed, nil
+}
+
+// PersistingTokenSource returns a token source for email that starts from
+// token and persists every refresh back to the account's token file.
+// Safe to use from several gsuite processes at once.
+func (c *OAuth2Config) PersistingTokenSource(ctx context.Context, email string, token *oauth2.Token) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(token, &persistingTokenSource{
+		ctx:    ctx,
+		config: c.config,
+		email:  email,
+	})
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newTestTokenServer returns a token endpoint that hands out a fresh access
+// token (and a rotated refresh token) on every refresh, counting calls.
+func newTestTokenServer(t *testing.T, calls *int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh-%d","token_type":"Bearer","expires_in":3600}`, n, n)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestOAuth2Config(tokenURL string) *OAuth2Config {
+	cfg := NewOAuth2Config("client-id", "client-secret")
+	cfg.config.Endpoint = oauth2.Endpoint{TokenURL: tokenURL, AuthStyle: oauth2.AuthStyleInParams}
+	return cfg
+}
+
+func TestPersistingTokenSourceSavesRefreshedToken(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var calls int32
+	srv := newTestTokenServer(t, &calls)
+	cfg := newTestOAuth2Config(srv.URL)
+
+	email := "refresh@example.com"
+	expired := &oauth2.Token{
+		AccessToken:  "stale",
+		RefreshToken: "original-refresh",
+		TokenType:    "Bearer",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	if err := SaveTokenFor(email, expired); err != nil {
+		t.Fatalf("SaveTokenFor failed: %v", err)
+	}
+
+	got, err := cfg.PersistingTokenSource(context.Background(), email, expired).Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if got.AccessToken != "access-1" {
+		t.Errorf("AccessToken = %q, want %q", got.AccessToken, "access-1")
+	}
+
+	saved, err := LoadTokenFor(email)
+	if err != nil {
+		t.Fatalf("LoadTokenFor failed: %v", err)
+	}
+	if saved.AccessToken != "access-1" {
+		t.Errorf("saved AccessToken = %q, want %q", saved.AccessToken, "access-1")
+	}
+	if saved.RefreshToken != "refresh-1" {
+		t.Errorf("saved RefreshToken = %q, want rotated %q", saved.RefreshToken, "refresh-1")
+	}
+}
+
+func TestPersistingTokenSourceReusesValidToken(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var calls int32
+	srv := newTestTokenServer(t, &calls)
+	cfg := newTestOAuth2Config(srv.URL)
+
+	email := "valid@example.com"
+	valid := &oauth2.Token{
+		AccessToken:  "still-good",
+		RefreshToken: "refresh",
+		TokenType:    "Bearer",
+		Expiry:       time.Now().Add(time.Hour),
+	}
+	if err := SaveTokenFor(email, valid); err != nil {
+		t.Fatalf("SaveTokenFor failed: %v", err)
+	}
+
+	got, err := cfg.PersistingTokenSource(context.Background(), email, valid).Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if got.AccessToken != "still-good" {
+		t.Errorf("AccessToken = %q, want %q", got.AccessToken, "still-good")
+	}
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Errorf("token endpoint called %d times, want 0", n)
+	}
+}
+
+func TestPersistingTokenSourceConcurrentRefreshOnce(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var calls int32
+	srv := newTestTokenServer(t, &calls)
+	cfg := newTestOAuth2Config(srv.URL)
+
+	email := "concurrent@example.com"
+	expired := &oauth2.Token{
+		AccessToken:  "stale",
+		RefreshToken: "original-refresh",
+		TokenType:    "Bearer",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	if err := SaveTokenFor(email, expired); err != nil {
+		t.Fatalf("SaveTokenFor failed: %v", err)
+	}
+
+	// Each source stands in for a separate gsuite process that loaded the
+	// same stale token file.
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cfg.PersistingTokenSource(context.Background(), email, expired).Token(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("token endpoint called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,318 @@
+package fakegoogle
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	calendar "google.golang.org/api/calendar/v3"
+)
+
+// AddCalendar adds a calendar to the account's calendar list.
+func (s *Server) AddCalendar(entry *calendar.CalendarListEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calendars[entry.Id] = entry
+	if s.events[entry.Id] == nil {
+		s.events[entry.Id] = make(map[string]*calendar.Event)
+	}
+}
+
+// AddEvent stores an event in calendarID ("primary" for the account's own
+// calendar) and returns its ID. It panics if the calendar does not exist.
+func (s *Server) AddEvent(calendarID string, event *calendar.Event) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events, ok := s.events[s.resolveCalendarID(calendarID)]
+	if !ok {
+		panic("fakegoogle: unknown calendar " + calendarID)
+	}
+	return s.insertEvent(events, event).Id
+}
+
+// Event returns a stored event, including cancelled ones.
+func (s *Server) Event(calendarID, eventID string) (*calendar.Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.events[s.resolveCalendarID(calendarID)][eventID]
+	if !ok {
+		return nil, false
+	}
+	copied := *event
+	return &copied, true
+}
+
+// resolveCalendarID maps the "primary" alias to the account's calendar.
+func (s *Server) resolveCalendarID(id string) string {
+	if id == "primary" {
+		return s.email
+	}
+	return id
+}
+
+// insertEvent fills in server-assigned fields and stores event. Callers must hold s.mu.
+func (s *Server) insertEvent(events map[string]*calendar.Event, event *calendar.Event) *calendar.Event {
+	now := s.tick().Format(time.RFC3339)
+	if event.Id == "" {
+		event.Id = s.newID("evt")
+	}
+	if event.Status == "" {
+		event.Status = "confirmed"
+	}
+	event.Kind = "calendar#event"
+	event.HtmlLink = "https://calendar.google.com/calendar/event?eid=" + event.Id
+	event.Created = now
+	event.Updated = now
+	if event.Organizer == nil {
+		event.Organizer = &calendar.EventOrganizer{Email: s.email, Self: true}
+	}
+	events[event.Id] = event
+	return event
+}
+
+// eventTime returns the instant an event boundary refers to. All-day dates
+// are taken as midnight UTC.
+func eventTime(t *calendar.EventDateTime) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	if t.DateTime != "" {
+		parsed, _ := time.Parse(time.RFC3339, t.DateTime)
+		return parsed
+	}
+	parsed, _ := time.Parse("2006-01-02", t.Date)
+	return parsed
+}
+
+func (s *Server) registerCalendar(mux *http.ServeMux) {
+	const base = "/calendar/v3"
+
+	mux.HandleFunc("GET "+base+"/users/me/calendarList", s.handleCalendarListList)
+
+	mux.HandleFunc("GET "+base+"/calendars/{calendarId}/events", s.handleEventsList)
+	mux.HandleFunc("POST "+base+"/calendars/{calendarId}/events", s.handleEventsInsert)
+	mux.HandleFunc("GET "+base+"/calendars/{calendarId}/events/{eventId}", s.handleEventsGet)
+	mux.HandleFunc("PUT "+base+"/calendars/{calendarId}/events/{eventId}", s.handleEventsUpdate)
+	mux.HandleFunc("PATCH "+base+"/calendars/{calendarId}/events/{eventId}", s.handleEventsPatch)
+	mux.HandleFunc("DELETE "+base+"/calendars/{calendarId}/events/{eventId}", s.handleEventsDelete)
+}
+
+// calendarEvents returns the events of the request's calendar, writing a 404
+// if it does not exist. Callers must hold s.mu.
+func (s *Server) calendarEvents(w http.ResponseWriter, r *http.Request) (map[string]*calendar.Event, bool) {
+	events, ok := s.events[s.resolveCalendarID(r.PathValue("calendarId"))]
+	if !ok {
+		writeNotFound(w)
+	}
+	return events, ok
+}
+
+// liveEvent returns the request's event, writing a 404 if it does not exist
+// or a 410 if it was deleted. Callers must hold s.mu.
+func (s *Server) liveEvent(w http.ResponseWriter, r *http.Request) (*calendar.Event, bool) {
+	events, ok := s.calendarEvents(w, r)
+	if !ok {
+		return nil, false
+	}
+	event, ok := events[r.PathValue("eventId")]
+	if !ok {
+		writeNotFound(w)
+		return nil, false
+	}
+	if event.Status == "cancelled" {
+		writeError(w, http.StatusGone, "deleted", "Resource has been deleted")
+		return nil, false
+	}
+	return event, true
+}
+
+func (s *Server) handleCalendarListList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]*calendar.CalendarListEntry, 0, len(s.calendars))
+	for _, entry := range s.calendars {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Primary != entries[j].Primary {
+			return entries[i].Primary
+		}
+		return entries[i].Id < entries[j].Id
+	})
+	page, next := paginate(entries, r, "maxResults", 100)
+	writeJSON(w, &calendar.CalendarList{Items: page, NextPageToken: next})
+}
+
+// handleEventsList filters by timeMin, timeMax, q and showDeleted. Recurring
+// events are returned as their single master event, even with singleEvents.
+func (s *Server) handleEventsList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events, ok := s.calendarEvents(w, r)
+	if !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	timeMin, _ := time.Parse(time.RFC3339, params.Get("timeMin"))
+	timeMax, _ := time.Parse(time.RFC3339, params.Get("timeMax"))
+	text := strings.ToLower(params.Get("q"))
+
+	var matches []*calendar.Event
+	for _, event := range events {
+		if event.Status == "cancelled" && params.Get("showDeleted") != "true" {
+			continue
+		}
+		if !timeMin.IsZero() && !eventTime(event.End).After(timeMin) {
+			continue
+		}
+		if !timeMax.IsZero() && !eventTime(event.Start).Before(timeMax) {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(event.Summary+"\n"+event.Description+"\n"+event.Location), text) {
+			continue
+		}
+		matches = append(matches, event)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if params.Get("orderBy") == "updated" {
+			return matches[i].Updated < matches[j].Updated
+		}
+		ti, tj := eventTime(matches[i].Start), eventTime(matches[j].Start)
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return matches[i].Id < matches[j].Id
+	})
+
+	page, next := paginate(matches, r, "maxResults", 250)
+	writeJSON(w, &calendar.Events{
+		Kind:          "calendar#events",
+		Summary:       s.resolveCalendarID(r.PathValue("calendarId")),
+		TimeZone:      "UTC",
+		Items:         page,
+		NextPageToken: next,
+	})
+}
+
+func (s *Server) handleEventsGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events, ok := s.calendarEvents(w, r)
+	if !ok {
+		return
+	}
+	event, ok := events[r.PathValue("eventId")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, event)
+}
+
+func (s *Server) handleEventsInsert(w http.ResponseWriter, r *http.Request) {
+	var event calendar.Event
+	if !readJSON(w, r, &event) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events, ok := s.calendarEvents(w, r)
+	if !ok {
+		return
+	}
+	if event.Start == nil || event.End == nil {
+		writeError(w, http.StatusBadRequest, "required", "Missing start or end time.")
+		return
+	}
+	writeJSON(w, s.insertEvent(events, &event))
+}
+
+func (s *Server) handleEventsUpdate(w http.ResponseWriter, r *http.Request) {
+	var event calendar.Event
+	if !readJSON(w, r, &event) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.liveEvent(w, r)
+	if !ok {
+		return
+	}
+	s.replaceEvent(existing, &event)
+	writeJSON(w, &event)
+}
+
+// handleEventsPatch merges the top-level fields present in the request body
+// into the stored event, as the real PATCH semantics do.
+func (s *Server) handleEventsPatch(w http.ResponseWriter, r *http.Request) {
+	var patch map[string]json.RawMessage
+	if !readJSON(w, r, &patch) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.liveEvent(w, r)
+	if !ok {
+		return
+	}
+
+	current, err := json.Marshal(existing)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "backendError", err.Error())
+		return
+	}
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(current, &merged); err != nil {
+		writeError(w, http.StatusInternalServerError, "backendError", err.Error())
+		return
+	}
+	for key, value := range patch {
+		merged[key] = value
+	}
+	body, err := json.Marshal(merged)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "backendError", err.Error())
+		return
+	}
+	var event calendar.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	s.replaceEvent(existing, &event)
+	writeJSON(w, &event)
+}
+
+// replaceEvent stores updated in place of existing, keeping server-assigned
+// fields. Callers must hold s.mu.
+func (s *Server) replaceEvent(existing, updated *calendar.Event) {
+	updated.Id = existing.Id
+	updated.Kind = existing.Kind
+	updated.HtmlLink = existing.HtmlLink
+	updated.Created = existing.Created
+	updated.Updated = s.tick().Format(time.RFC3339)
+	if updated.Status == "" {
+		updated.Status = existing.Status
+	}
+	if updated.Organizer == nil {
+		updated.Organizer = existing.Organizer
+	}
+	*existing = *updated
+}
+
+// handleEventsDelete marks the event cancelled, so it is still visible with
+// showDeleted, as with the real API.
+func (s *Server) handleEventsDelete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.liveEvent(w, r)
+	if !ok {
+		return
+	}
+	event.Status = "cancelled"
+	event.Updated = s.tick().Format(time.RFC3339)
+	writeEmpty(w)
+}
@@ -0,0 +1,897 @@
+package fakegoogle
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// systemLabels are the Gmail system labels every mailbox starts with.
+var systemLabels = []string{"INBOX", "SENT", "DRAFT", "SPAM", "TRASH", "UNREAD", "STARRED", "IMPORTANT"}
+
+// storedMessage is a message held by the fake mailbox.
+type storedMessage struct {
+	id           string
+	threadID     string
+	labelIDs     []string
+	raw          []byte
+	payload      *gmail.MessagePart
+	attachments  map[string][]byte
+	text         string
+	internalDate int64
+	historyID    uint64
+}
+
+// snippet returns the first 100 characters of the message text.
+func (m *storedMessage) snippet() string {
+	text := strings.Join(strings.Fields(m.text), " ")
+	if len(text) > 100 {
+		text = text[:100]
+	}
+	return text
+}
+
+func (m *storedMessage) hasLabel(id string) bool {
+	return slices.Contains(m.labelIDs, id)
+}
+
+// toAPI renders the message in the requested format: "minimal", "metadata"
+// (restricted to metadataHeaders when given), "raw", or "full" (the default).
+func (m *storedMessage) toAPI(format string, metadataHeaders []string) *gmail.Message {
+	msg := &gmail.Message{
+		Id:           m.id,
+		ThreadId:     m.threadID,
+		LabelIds:     slices.Clone(m.labelIDs),
+		Snippet:      m.snippet(),
+		InternalDate: m.internalDate,
+		HistoryId:    m.historyID,
+		SizeEstimate: int64(len(m.raw)),
+	}
+	switch format {
+	case "minimal":
+	case "raw":
+		msg.Raw = base64.URLEncoding.EncodeToString(m.raw)
+	case "metadata":
+		part := &gmail.MessagePart{MimeType: m.payload.MimeType}
+		for _, h := range m.payload.Headers {
+			if len(metadataHeaders) == 0 || slices.ContainsFunc(metadataHeaders, func(name string) bool {
+				return strings.EqualFold(name, h.Name)
+			}) {
+				part.Headers = append(part.Headers, h)
+			}
+		}
+		msg.Payload = part
+	default:
+		msg.Payload = m.payload
+	}
+	return msg
+}
+
+// AddMessage stores a raw RFC 2822 message with the given labels and returns
+// its ID. A message whose In-Reply-To matches a stored Message-ID joins that
+// thread. It panics if raw cannot be parsed, since fixtures are fixed strings.
+func (s *Server) AddMessage(raw string, labelIDs ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, err := s.storeMessage([]byte(raw), "", labelIDs)
+	if err != nil {
+		panic(fmt.Sprintf("fakegoogle: %v", err))
+	}
+	return msg.id
+}
+
+// Message returns a stored message in full format.
+func (s *Server) Message(id string) (*gmail.Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.messages[id]
+	if !ok {
+		return nil, false
+	}
+	return m.toAPI("full", nil), true
+}
+
+// RawMessage returns the RFC 2822 source of a stored message.
+func (s *Server) RawMessage(id string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.messages[id]
+	if !ok {
+		return nil, false
+	}
+	return slices.Clone(m.raw), true
+}
+
+// MessageIDs returns the IDs of stored messages carrying labelID, or of every
+// message if labelID is empty, oldest first.
+func (s *Server) MessageIDs(labelID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for _, id := range s.order {
+		if labelID == "" || s.messages[id].hasLabel(labelID) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// AddLabel creates a user label and returns its ID.
+func (s *Server) AddLabel(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createLabel(&gmail.Label{Name: name}).Id
+}
+
+// DraftIDs returns the IDs of the stored drafts, sorted.
+func (s *Server) DraftIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.drafts))
+	for id := range s.drafts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// storeMessage parses raw and stores it. An empty threadID places the message
+// in its parent's thread (via In-Reply-To) or a new one. Callers must hold s.mu.
+func (s *Server) storeMessage(raw []byte, threadID string, labelIDs []string) (*storedMessage, error) {
+	parsed, err := parseRawMessage(raw, func() string { return s.newID("att-") })
+	if err != nil {
+		return nil, err
+	}
+
+	id := s.newID("msg-")
+	if threadID == "" {
+		threadID = s.parentThread(lookupHeader(parsed.payload.Headers, "In-Reply-To"))
+	}
+	if threadID == "" {
+		threadID = "thread-" + strings.TrimPrefix(id, "msg-")
+	}
+
+	m := &storedMessage{
+		id:           id,
+		threadID:     threadID,
+		labelIDs:     uniqueLabels(labelIDs),
+		raw:          raw,
+		payload:      parsed.payload,
+		attachments:  parsed.attachments,
+		text:         parsed.text,
+		internalDate: s.tick().UnixMilli(),
+		historyID:    s.nextHistoryID(),
+	}
+	s.messages[id] = m
+	s.order = append(s.order, id)
+	s.recordHistory(&gmail.History{
+		Id:            m.historyID,
+		MessagesAdded: []*gmail.HistoryMessageAdded{{Message: m.toAPI("minimal", nil)}},
+	})
+	return m, nil
+}
+
+// parentThread returns the thread of the stored message whose Message-ID is
+// messageID, or "" if there is none. Callers must hold s.mu.
+func (s *Server) parentThread(messageID string) string {
+	if messageID == "" {
+		return ""
+	}
+	for _, id := range s.order {
+		m := s.messages[id]
+		if lookupHeader(m.payload.Headers, "Message-ID") == messageID {
+			return m.threadID
+		}
+	}
+	return ""
+}
+
+// modifyLabels applies label changes to m. Callers must hold s.mu.
+func (s *Server) modifyLabels(m *storedMessage, add, remove []string) {
+	before := m.labelIDs
+	labels := slices.DeleteFunc(slices.Clone(m.labelIDs), func(id string) bool {
+		return slices.Contains(remove, id)
+	})
+	m.labelIDs = uniqueLabels(append(labels, add...))
+	m.historyID = s.nextHistoryID()
+
+	h := &gmail.History{Id: m.historyID}
+	if added := labelDiff(m.labelIDs, before); len(added) > 0 {
+		h.LabelsAdded = []*gmail.HistoryLabelAdded{{LabelIds: added, Message: m.toAPI("minimal", nil)}}
+	}
+	if removed := labelDiff(before, m.labelIDs); len(removed) > 0 {
+		h.LabelsRemoved = []*gmail.HistoryLabelRemoved{{LabelIds: removed, Message: m.toAPI("minimal", nil)}}
+	}
+	if h.LabelsAdded != nil || h.LabelsRemoved != nil {
+		s.recordHistory(h)
+	}
+}
+
+// deleteMessage permanently removes a message. Callers must hold s.mu.
+func (s *Server) deleteMessage(id string) {
+	m, ok := s.messages[id]
+	delete(s.messages, id)
+	s.order = slices.DeleteFunc(s.order, func(other string) bool { return other == id })
+	historyID := s.nextHistoryID()
+	if ok {
+		s.recordHistory(&gmail.History{
+			Id:              historyID,
+			MessagesDeleted: []*gmail.HistoryMessageDeleted{{Message: &gmail.Message{Id: m.id, ThreadId: m.threadID}}},
+		})
+	}
+}
+
+// checkLabels reports the first unknown label ID in ids. Callers must hold s.mu.
+func (s *Server) checkLabels(ids ...[]string) (string, bool) {
+	for _, list := range ids {
+		for _, id := range list {
+			if _, ok := s.labels[id]; !ok {
+				return id, false
+			}
+		}
+	}
+	return "", true
+}
+
+// uniqueLabels removes duplicate label IDs, keeping the first occurrence.
+func uniqueLabels(ids []string) []string {
+	result := []string{}
+	for _, id := range ids {
+		if !slices.Contains(result, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// createLabel stores a new user label. Callers must hold s.mu.
+func (s *Server) createLabel(label *gmail.Label) *gmail.Label {
+	label.Id = s.newID("Label_")
+	label.Type = "user"
+	s.labels[label.Id] = label
+	return label
+}
+
+// visibleMessages returns messages matching the list parameters shared by
+// messages.list and threads.list, newest first. Callers must hold s.mu.
+func (s *Server) visibleMessages(r *http.Request) ([]*storedMessage, error) {
+	q := r.URL.Query()
+	query, err := parseQuery(q.Get("q"))
+	if err != nil {
+		return nil, err
+	}
+	includeSpamTrash := q.Get("includeSpamTrash") == "true"
+
+	var result []*storedMessage
+	for i := len(s.order) - 1; i >= 0; i-- {
+		m := s.messages[s.order[i]]
+		if !includeSpamTrash && !query.searchesSpamTrash() && (m.hasLabel("SPAM") || m.hasLabel("TRASH")) {
+			continue
+		}
+		if !slices.ContainsFunc(q["labelIds"], func(id string) bool { return !m.hasLabel(id) }) && query.matches(m, s.labels) {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// threadMessages returns the messages of a thread, oldest first. Callers must hold s.mu.
+func (s *Server) threadMessages(threadID string) []*storedMessage {
+	var result []*storedMessage
+	for _, id := range s.order {
+		if m := s.messages[id]; m.threadID == threadID {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func (s *Server) registerGmail(mux *http.ServeMux) {
+	const users = "/gmail/v1/users/{userId}"
+
+	mux.HandleFunc("GET "+users+"/profile", s.handleProfile)
+
+	mux.HandleFunc("GET "+users+"/messages", s.handleMessagesList)
+	mux.HandleFunc("POST "+users+"/messages", s.handleMessagesInsert)
+	mux.HandleFunc("POST "+users+"/messages/import", s.handleMessagesInsert)
+	mux.HandleFunc("POST "+users+"/messages/send", s.handleMessagesSend)
+	mux.HandleFunc("POST "+users+"/messages/batchModify", s.handleMessagesBatchModify)
+	mux.HandleFunc("POST "+users+"/messages/batchDelete", s.handleMessagesBatchDelete)
+	mux.HandleFunc("GET "+users+"/messages/{id}", s.handleMessagesGet)
+	mux.HandleFunc("DELETE "+users+"/messages/{id}", s.handleMessagesDelete)
+	mux.HandleFunc("POST "+users+"/messages/{id}/modify", s.handleMessagesModify)
+	mux.HandleFunc("POST "+users+"/messages/{id}/trash", s.handleMessagesTrash(true))
+	mux.HandleFunc("POST "+users+"/messages/{id}/untrash", s.handleMessagesTrash(false))
+	mux.HandleFunc("GET "+users+"/messages/{messageId}/attachments/{id}", s.handleAttachmentsGet)
+
+	mux.HandleFunc("GET "+users+"/history", s.handleHistoryList)
+	mux.HandleFunc("POST "+users+"/watch", s.handleWatch)
+	mux.HandleFunc("POST "+users+"/stop", s.handleStop)
+
+	mux.HandleFunc("GET "+users+"/threads", s.handleThreadsList)
+	mux.HandleFunc("GET "+users+"/threads/{id}", s.handleThreadsGet)
+	mux.HandleFunc("DELETE "+users+"/threads/{id}", s.handleThreadsDelete)
+	mux.HandleFunc("POST "+users+"/threads/{id}/modify", s.handleThreadsModify)
+	mux.HandleFunc("POST "+users+"/threads/{id}/trash", s.handleThreadsTrash(true))
+	mux.HandleFunc("POST "+users+"/threads/{id}/untrash", s.handleThreadsTrash(false))
+
+	mux.HandleFunc("GET "+users+"/labels", s.handleLabelsList)
+	mux.HandleFunc("POST "+users+"/labels", s.handleLabelsCreate)
+	mux.HandleFunc("GET "+users+"/labels/{id}", s.handleLabelsGet)
+	mux.HandleFunc("PUT "+users+"/labels/{id}", s.handleLabelsUpdate)
+	mux.HandleFunc("PATCH "+users+"/labels/{id}", s.handleLabelsUpdate)
+	mux.HandleFunc("DELETE "+users+"/labels/{id}", s.handleLabelsDelete)
+
+	mux.HandleFunc("GET "+users+"/drafts", s.handleDraftsList)
+	mux.HandleFunc("POST "+users+"/drafts", s.handleDraftsCreate)
+	mux.HandleFunc("POST "+users+"/drafts/send", s.handleDraftsSend)
+	mux.HandleFunc("GET "+users+"/drafts/{id}", s.handleDraftsGet)
+	mux.HandleFunc("PUT "+users+"/drafts/{id}", s.handleDraftsUpdate)
+	mux.HandleFunc("DELETE "+users+"/drafts/{id}", s.handleDraftsDelete)
+}
+
+func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	threads := make(map[string]bool)
+	for _, m := range s.messages {
+		threads[m.threadID] = true
+	}
+	writeJSON(w, &gmail.Profile{
+		EmailAddress:  s.email,
+		MessagesTotal: int64(len(s.messages)),
+		ThreadsTotal:  int64(len(threads)),
+		HistoryId:     s.historyID,
+	})
+}
+
+func (s *Server) handleMessagesList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matches, err := s.visibleMessages(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidArgument", err.Error())
+		return
+	}
+	page, next := paginate(matches, r, "maxResults", 100)
+	resp := &gmail.ListMessagesResponse{
+		NextPageToken:      next,
+		ResultSizeEstimate: int64(len(matches)),
+	}
+	for _, m := range page {
+		resp.Messages = append(resp.Messages, &gmail.Message{Id: m.id, ThreadId: m.threadID})
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleMessagesGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.messages[r.PathValue("id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, m.toAPI(r.URL.Query().Get("format"), r.URL.Query()["metadataHeaders"]))
+}
+
+// handleMessagesInsert serves both messages.insert and messages.import, which
+// store a raw message with the caller's labels without sending it.
+func (s *Server) handleMessagesInsert(w http.ResponseWriter, r *http.Request) {
+	var req gmail.Message
+	if !readJSON(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id, ok := s.checkLabels(req.LabelIds); !ok {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid label: "+id)
+		return
+	}
+	raw, err := base64.URLEncoding.DecodeString(req.Raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid raw message")
+		return
+	}
+	m, err := s.storeMessage(raw, req.ThreadId, req.LabelIds)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidArgument", err.Error())
+		return
+	}
+	writeJSON(w, m.toAPI("minimal", nil))
+}
+
+func (s *Server) handleMessagesSend(w http.ResponseWriter, r *http.Request) {
+	var req gmail.Message
+	if !readJSON(w, r, &req) {
+		return
+	}
+	raw, err := base64.URLEncoding.DecodeString(req.Raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid raw message")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendMessage(w, req, raw)
+}
+
+func (s *Server) handleMessagesModify(w http.ResponseWriter, r *http.Request) {
+	var req gmail.ModifyMessageRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.messages[r.PathValue("id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	if id, ok := s.checkLabels(req.AddLabelIds, req.RemoveLabelIds); !ok {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid label: "+id)
+		return
+	}
+	s.modifyLabels(m, req.AddLabelIds, req.RemoveLabelIds)
+	writeJSON(w, m.toAPI("minimal", nil))
+}
+
+func (s *Server) handleMessagesTrash(trash bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		m, ok := s.messages[r.PathValue("id")]
+		if !ok {
+			writeNotFound(w)
+			return
+		}
+		if trash {
+			s.modifyLabels(m, []string{"TRASH"}, nil)
+		} else {
+			s.modifyLabels(m, nil, []string{"TRASH"})
+		}
+		writeJSON(w, m.toAPI("minimal", nil))
+	}
+}
+
+func (s *Server) handleMessagesDelete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	if _, ok := s.messages[id]; !ok {
+		writeNotFound(w)
+		return
+	}
+	s.deleteMessage(id)
+	writeEmpty(w)
+}
+
+func (s *Server) handleMessagesBatchModify(w http.ResponseWriter, r *http.Request) {
+	var req gmail.BatchModifyMessagesRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(req.Ids) > 1000 {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Too many ids: at most 1000 are allowed")
+		return
+	}
+	if id, ok := s.checkLabels(req.AddLabelIds, req.RemoveLabelIds); !ok {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid label: "+id)
+		return
+	}
+	for _, id := range req.Ids {
+		if m, ok := s.messages[id]; ok {
+			s.modifyLabels(m, req.AddLabelIds, req.RemoveLabelIds)
+		}
+	}
+	writeEmpty(w)
+}
+
+func (s *Server) handleMessagesBatchDelete(w http.ResponseWriter, r *http.Request) {
+	var req gmail.BatchDeleteMessagesRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(req.Ids) > 1000 {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Too many ids: at most 1000 are allowed")
+		return
+	}
+	for _, id := range req.Ids {
+		if _, ok := s.messages[id]; ok {
+			s.deleteMessage(id)
+		}
+	}
+	writeEmpty(w)
+}
+
+func (s *Server) handleAttachmentsGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.messages[r.PathValue("messageId")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	data, ok := m.attachments[r.PathValue("id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, &gmail.MessagePartBody{
+		AttachmentId: r.PathValue("id"),
+		Size:         int64(len(data)),
+		Data:         base64.URLEncoding.EncodeToString(data),
+	})
+}
+
+func (s *Server) handleThreadsList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matches, err := s.visibleMessages(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidArgument", err.Error())
+		return
+	}
+	var threads []*gmail.Thread
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		if seen[m.threadID] {
+			continue
+		}
+		seen[m.threadID] = true
+		threads = append(threads, &gmail.Thread{Id: m.threadID, Snippet: m.snippet(), HistoryId: m.historyID})
+	}
+	page, next := paginate(threads, r, "maxResults", 100)
+	writeJSON(w, &gmail.ListThreadsResponse{
+		Threads:            page,
+		NextPageToken:      next,
+		ResultSizeEstimate: int64(len(threads)),
+	})
+}
+
+func (s *Server) handleThreadsGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := s.threadMessages(r.PathValue("id"))
+	if len(messages) == 0 {
+		writeNotFound(w)
+		return
+	}
+	thread := &gmail.Thread{Id: r.PathValue("id")}
+	for _, m := range messages {
+		thread.Messages = append(thread.Messages, m.toAPI(r.URL.Query().Get("format"), r.URL.Query()["metadataHeaders"]))
+		thread.HistoryId = max(thread.HistoryId, m.historyID)
+	}
+	writeJSON(w, thread)
+}
+
+func (s *Server) handleThreadsModify(w http.ResponseWriter, r *http.Request) {
+	var req gmail.ModifyThreadRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := s.threadMessages(r.PathValue("id"))
+	if len(messages) == 0 {
+		writeNotFound(w)
+		return
+	}
+	if id, ok := s.checkLabels(req.AddLabelIds, req.RemoveLabelIds); !ok {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid label: "+id)
+		return
+	}
+	thread := &gmail.Thread{Id: r.PathValue("id")}
+	for _, m := range messages {
+		s.modifyLabels(m, req.AddLabelIds, req.RemoveLabelIds)
+		thread.Messages = append(thread.Messages, m.toAPI("minimal", nil))
+	}
+	writeJSON(w, thread)
+}
+
+func (s *Server) handleThreadsTrash(trash bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		messages := s.threadMessages(r.PathValue("id"))
+		if len(messages) == 0 {
+			writeNotFound(w)
+			return
+		}
+		thread := &gmail.Thread{Id: r.PathValue("id")}
+		for _, m := range messages {
+			if trash {
+				s.modifyLabels(m, []string{"TRASH"}, nil)
+			} else {
+				s.modifyLabels(m, nil, []string{"TRASH"})
+			}
+			thread.Messages = append(thread.Messages, m.toAPI("minimal", nil))
+		}
+		writeJSON(w, thread)
+	}
+}
+
+func (s *Server) handleThreadsDelete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := s.threadMessages(r.PathValue("id"))
+	if len(messages) == 0 {
+		writeNotFound(w)
+		return
+	}
+	for _, m := range messages {
+		s.deleteMessage(m.id)
+	}
+	writeEmpty(w)
+}
+
+func (s *Server) handleLabelsList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := &gmail.ListLabelsResponse{}
+	for _, id := range systemLabels {
+		resp.Labels = append(resp.Labels, s.labels[id])
+	}
+	var user []*gmail.Label
+	for _, label := range s.labels {
+		if label.Type == "user" {
+			user = append(user, label)
+		}
+	}
+	sort.Slice(user, func(i, j int) bool { return user[i].Name < user[j].Name })
+	resp.Labels = append(resp.Labels, user...)
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleLabelsGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	label, ok := s.labels[r.PathValue("id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	// Counts are computed on demand, as the real API only returns them from get.
+	counted := *label
+	threads, unreadThreads := make(map[string]bool), make(map[string]bool)
+	for _, m := range s.messages {
+		if !m.hasLabel(label.Id) {
+			continue
+		}
+		counted.MessagesTotal++
+		threads[m.threadID] = true
+		if m.hasLabel("UNREAD") {
+			counted.MessagesUnread++
+			unreadThreads[m.threadID] = true
+		}
+	}
+	counted.ThreadsTotal = int64(len(threads))
+	counted.ThreadsUnread = int64(len(unreadThreads))
+	writeJSON(w, &counted)
+}
+
+func (s *Server) handleLabelsCreate(w http.ResponseWriter, r *http.Request) {
+	var req gmail.Label
+	if !readJSON(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if strings.TrimSpace(req.Name) == "" {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid label name")
+		return
+	}
+	if s.labelNameTaken(req.Name, "") {
+		writeError(w, http.StatusConflict, "duplicate", "Label name exists or conflicts")
+		return
+	}
+	writeJSON(w, s.createLabel(&req))
+}
+
+// handleLabelsUpdate serves both labels.update and labels.patch; fields left
+// empty in the request keep their current value.
+func (s *Server) handleLabelsUpdate(w http.ResponseWriter, r *http.Request) {
+	var req gmail.Label
+	if !readJSON(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	label, ok := s.labels[r.PathValue("id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	if label.Type == "system" {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid label: system labels cannot be modified")
+		return
+	}
+	if req.Name != "" {
+		if s.labelNameTaken(req.Name, label.Id) {
+			writeError(w, http.StatusConflict, "duplicate", "Label name exists or conflicts")
+			return
+		}
+		label.Name = req.Name
+	}
+	if req.MessageListVisibility != "" {
+		label.MessageListVisibility = req.MessageListVisibility
+	}
+	if req.LabelListVisibility != "" {
+		label.LabelListVisibility = req.LabelListVisibility
+	}
+	if req.Color != nil {
+		label.Color = req.Color
+	}
+	writeJSON(w, label)
+}
+
+func (s *Server) handleLabelsDelete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	label, ok := s.labels[r.PathValue("id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	if label.Type == "system" {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid label: system labels cannot be deleted")
+		return
+	}
+	delete(s.labels, label.Id)
+	for _, m := range s.messages {
+		if m.hasLabel(label.Id) {
+			s.modifyLabels(m, nil, []string{label.Id})
+		}
+	}
+	writeEmpty(w)
+}
+
+// labelNameTaken reports whether another label already uses name,
+// case-insensitively. Callers must hold s.mu.
+func (s *Server) labelNameTaken(name, exceptID string) bool {
+	for _, label := range s.labels {
+		if label.Id != exceptID && strings.EqualFold(label.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleDraftsList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var drafts []*gmail.Draft
+	for i := len(s.order) - 1; i >= 0; i-- {
+		for draftID, msgID := range s.drafts {
+			if msgID == s.order[i] {
+				drafts = append(drafts, &gmail.Draft{
+					Id:      draftID,
+					Message: &gmail.Message{Id: msgID, ThreadId: s.messages[msgID].threadID},
+				})
+			}
+		}
+	}
+	page, next := paginate(drafts, r, "maxResults", 100)
+	writeJSON(w, &gmail.ListDraftsResponse{
+		Drafts:             page,
+		NextPageToken:      next,
+		ResultSizeEstimate: int64(len(drafts)),
+	})
+}
+
+func (s *Server) handleDraftsGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msgID, ok := s.drafts[r.PathValue("id")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, &gmail.Draft{
+		Id:      r.PathValue("id"),
+		Message: s.messages[msgID].toAPI(r.URL.Query().Get("format"), nil),
+	})
+}
+
+// draftMessage decodes and stores the message of a drafts.create or
+// drafts.update request. Callers must hold s.mu.
+func (s *Server) draftMessage(w http.ResponseWriter, req *gmail.Draft) (*storedMessage, bool) {
+	if req.Message == nil {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Missing draft message")
+		return nil, false
+	}
+	raw, err := base64.URLEncoding.DecodeString(req.Message.Raw)
+	if err != nil || len(raw) == 0 {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid raw message")
+		return nil, false
+	}
+	m, err := s.storeMessage(raw, req.Message.ThreadId, []string{"DRAFT"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidArgument", err.Error())
+		return nil, false
+	}
+	return m, true
+}
+
+func (s *Server) handleDraftsCreate(w http.ResponseWriter, r *http.Request) {
+	var req gmail.Draft
+	if !readJSON(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.draftMessage(w, &req)
+	if !ok {
+		return
+	}
+	id := s.newID("r-")
+	s.drafts[id] = m.id
+	writeJSON(w, &gmail.Draft{Id: id, Message: m.toAPI("minimal", nil)})
+}
+
+func (s *Server) handleDraftsUpdate(w http.ResponseWriter, r *http.Request) {
+	var req gmail.Draft
+	if !readJSON(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	oldID, ok := s.drafts[id]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	if req.Message != nil && req.Message.ThreadId == "" {
+		req.Message.ThreadId = s.messages[oldID].threadID
+	}
+	m, ok := s.draftMessage(w, &req)
+	if !ok {
+		return
+	}
+	s.deleteMessage(oldID)
+	s.drafts[id] = m.id
+	writeJSON(w, &gmail.Draft{Id: id, Message: m.toAPI("minimal", nil)})
+}
+
+func (s *Server) handleDraftsSend(w http.ResponseWriter, r *http.Request) {
+	var req gmail.Draft
+	if !readJSON(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msgID, ok := s.drafts[req.Id]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	m := s.messages[msgID]
+	delete(s.drafts, req.Id)
+	s.modifyLabels(m, []string{"SENT"}, []string{"DRAFT"})
+	writeJSON(w, &gmail.Message{Id: m.id, ThreadId: m.threadID, LabelIds: m.labelIDs})
+}
+
+func (s *Server) handleDraftsDelete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	msgID, ok := s.drafts[id]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	delete(s.drafts, id)
+	s.deleteMessage(msgID)
+	writeEmpty(w)
+}
@@ -0,0 +1,141 @@
+package fakegoogle
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// HistoryID returns the mailbox's current history ID.
+func (s *Server) HistoryID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.historyID
+}
+
+// ExpireHistory discards the recorded history, so history.list calls starting
+// before the current history ID fail with 404 as they do for IDs older than
+// Gmail's retention window.
+func (s *Server) ExpireHistory() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = nil
+	s.historyStart = s.historyID
+}
+
+// Watch returns the active push notification request, or nil if none.
+func (s *Server) Watch() *gmail.WatchRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.watch
+}
+
+// recordHistory appends a mailbox change. Callers must hold s.mu.
+func (s *Server) recordHistory(h *gmail.History) {
+	s.history = append(s.history, h)
+}
+
+// labelDiff returns the labels in a that are not in b.
+func labelDiff(a, b []string) []string {
+	var diff []string
+	for _, id := range a {
+		if !slices.Contains(b, id) {
+			diff = append(diff, id)
+		}
+	}
+	return diff
+}
+
+// historyMessage returns the message a history record is about.
+func historyMessage(h *gmail.History) *gmail.Message {
+	switch {
+	case len(h.MessagesAdded) > 0:
+		return h.MessagesAdded[0].Message
+	case len(h.MessagesDeleted) > 0:
+		return h.MessagesDeleted[0].Message
+	case len(h.LabelsAdded) > 0:
+		return h.LabelsAdded[0].Message
+	case len(h.LabelsRemoved) > 0:
+		return h.LabelsRemoved[0].Message
+	}
+	return nil
+}
+
+// historyHasType reports whether h contains a change of one of types, named
+// as in the historyTypes parameter. An empty types matches every record.
+func historyHasType(h *gmail.History, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	return slices.Contains(types, "messageAdded") && len(h.MessagesAdded) > 0 ||
+		slices.Contains(types, "messageDeleted") && len(h.MessagesDeleted) > 0 ||
+		slices.Contains(types, "labelAdded") && len(h.LabelsAdded) > 0 ||
+		slices.Contains(types, "labelRemoved") && len(h.LabelsRemoved) > 0
+}
+
+func (s *Server) handleWatch(w http.ResponseWriter, r *http.Request) {
+	var req gmail.WatchRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.TopicName == "" {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid topicName")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id, ok := s.checkLabels(req.LabelIds); !ok {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid label: "+id)
+		return
+	}
+	s.watch = &req
+	writeJSON(w, &gmail.WatchResponse{
+		HistoryId:  s.historyID,
+		Expiration: s.now.Add(7 * 24 * time.Hour).UnixMilli(),
+	})
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watch = nil
+	writeEmpty(w)
+}
+
+func (s *Server) handleHistoryList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	start, err := strconv.ParseUint(q.Get("startHistoryId"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "startHistoryId is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if start < s.historyStart {
+		writeNotFound(w)
+		return
+	}
+
+	var records []*gmail.History
+	for _, h := range s.history {
+		if h.Id <= start || !historyHasType(h, q["historyTypes"]) {
+			continue
+		}
+		if label := q.Get("labelId"); label != "" {
+			if m := historyMessage(h); m == nil || !slices.Contains(m.LabelIds, label) {
+				continue
+			}
+		}
+		records = append(records, h)
+	}
+	page, next := paginate(records, r, "maxResults", 100)
+	writeJSON(w, &gmail.ListHistoryResponse{
+		History:       page,
+		HistoryId:     s.historyID,
+		NextPageToken: next,
+	})
+}
@@ -0,0 +1,210 @@
+package fakegoogle
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// parsedMessage is a raw RFC 2822 message split into the Gmail payload tree.
+type parsedMessage struct {
+	payload     *gmail.MessagePart
+	attachments map[string][]byte // attachment ID -> decoded data
+	text        string            // first text/plain body, for snippets and search
+}
+
+// parseRawMessage parses raw into a Gmail payload. Leaf bodies are decoded from
+// their Content-Transfer-Encoding; parts with a filename become attachments
+// that must be fetched separately, as with the real API.
+func parseRawMessage(raw []byte, newAttachmentID func() string) (*parsedMessage, error) {
+	p := &parsedMessage{attachments: make(map[string][]byte)}
+	part, err := p.parsePart(raw, "", newAttachmentID)
+	if err != nil {
+		return nil, err
+	}
+	p.payload = part
+	return p, nil
+}
+
+func (p *parsedMessage) parsePart(raw []byte, partID string, newAttachmentID func() string) (*gmail.MessagePart, error) {
+	headers, body := splitHeaders(raw)
+	part := &gmail.MessagePart{
+		PartId:   partID,
+		Headers:  headers,
+		MimeType: "text/plain",
+	}
+
+	mediaType, params, err := mime.ParseMediaType(lookupHeader(headers, "Content-Type"))
+	if err == nil {
+		part.MimeType = mediaType
+	}
+
+	if strings.HasPrefix(part.MimeType, "multipart/") {
+		part.Body = &gmail.MessagePartBody{}
+		reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for i := 0; ; i++ {
+			child, err := reader.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid multipart body: %w", err)
+			}
+			childRaw, err := rawPartBytes(child)
+			if err != nil {
+				return nil, err
+			}
+			childID := fmt.Sprint(i)
+			if partID != "" {
+				childID = partID + "." + childID
+			}
+			parsed, err := p.parsePart(childRaw, childID, newAttachmentID)
+			if err != nil {
+				return nil, err
+			}
+			part.Parts = append(part.Parts, parsed)
+		}
+		return part, nil
+	}
+
+	data, err := decodeTransferEncoding(body, lookupHeader(headers, "Content-Transfer-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+
+	part.Filename = partFilename(headers)
+	part.Body = &gmail.MessagePartBody{Size: int64(len(data))}
+	if part.Filename != "" {
+		id := newAttachmentID()
+		p.attachments[id] = data
+		part.Body.AttachmentId = id
+		return part, nil
+	}
+
+	part.Body.Data = base64.URLEncoding.EncodeToString(data)
+	if part.MimeType == "text/plain" && p.text == "" {
+		p.text = string(data)
+	}
+	return part, nil
+}
+
+// splitHeaders separates the header block from the body, unfolding
+// continuation lines and keeping the headers in their original order.
+func splitHeaders(raw []byte) ([]*gmail.MessagePartHeader, []byte) {
+	raw = bytes.ReplaceAll(raw, []byte("\r\n"), []byte("\n"))
+	block, body, found := bytes.Cut(raw, []byte("\n\n"))
+	if !found {
+		if bytes.HasPrefix(raw, []byte("\n")) {
+			return nil, raw[1:]
+		}
+		block, body = raw, nil
+	}
+
+	var headers []*gmail.MessagePartHeader
+	for _, line := range strings.Split(string(block), "\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(headers) > 0 {
+			headers[len(headers)-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		headers = append(headers, &gmail.MessagePartHeader{
+			Name:  strings.TrimSpace(name),
+			Value: strings.TrimSpace(value),
+		})
+	}
+	return headers, body
+}
+
+// rawPartBytes reassembles a multipart part's headers and body. The multipart
+// reader does not keep header order, so headers are written sorted by name.
+func rawPartBytes(part *multipart.Part) ([]byte, error) {
+	var buf bytes.Buffer
+	names := make([]string, 0, len(part.Header))
+	for name := range part.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range part.Header[name] {
+			fmt.Fprintf(&buf, "%s: %s\n", name, v)
+		}
+	}
+	buf.WriteString("\n")
+	if _, err := io.Copy(&buf, part); err != nil {
+		return nil, fmt.Errorf("invalid multipart body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeTransferEncoding decodes a leaf body per its Content-Transfer-Encoding.
+func decodeTransferEncoding(body []byte, encoding string) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		compact := strings.Join(strings.Fields(string(body)), "")
+		data, err := base64.StdEncoding.DecodeString(compact)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 body: %w", err)
+		}
+		return data, nil
+	case "quoted-printable":
+		data, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(body)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted-printable body: %w", err)
+		}
+		return data, nil
+	default:
+		return body, nil
+	}
+}
+
+// partFilename returns the attachment filename from Content-Disposition or
+// the Content-Type name parameter.
+func partFilename(headers []*gmail.MessagePartHeader) string {
+	if _, params, err := mime.ParseMediaType(lookupHeader(headers, "Content-Disposition")); err == nil && params["filename"] != "" {
+		return params["filename"]
+	}
+	if _, params, err := mime.ParseMediaType(lookupHeader(headers, "Content-Type")); err == nil {
+		return params["name"]
+	}
+	return ""
+}
+
+// lookupHeader returns the first value of the named header, case-insensitively.
+func lookupHeader(headers []*gmail.MessagePartHeader, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// hasAttachments reports whether any part of the payload is an attachment.
+func hasAttachments(part *gmail.MessagePart) bool {
+	if part == nil {
+		return false
+	}
+	if part.Filename != "" {
+		return true
+	}
+	for _, child := range part.Parts {
+		if hasAttachments(child) {
+			return true
+		}
+	}
+	return false
+}
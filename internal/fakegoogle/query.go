@@ -0,0 +1,168 @@
+package fakegoogle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// queryTerm is one term of a Gmail search query, e.g. "from:alice" or "-is:unread".
+type queryTerm struct {
+	op     string // operator before the colon, "" for free text
+	value  string
+	negate bool
+}
+
+// query is a parsed Gmail search query. All terms must match.
+type query []queryTerm
+
+// parseQuery parses the subset of Gmail search syntax the fake understands:
+// from:, to:, cc:, subject:, label:, is:, in:, has:attachment, rfc822msgid:,
+// after: and before: (YYYY/MM/DD), quoted phrases, negation with "-", and
+// free text. Parentheses are dropped, since all terms must match anyway.
+func parseQuery(q string) (query, error) {
+	var result query
+	for _, token := range tokenizeQuery(q) {
+		token = strings.TrimRight(strings.TrimLeft(token, "("), ")")
+		if token == "" {
+			continue
+		}
+		term := queryTerm{value: token}
+		if strings.HasPrefix(term.value, "-") && len(term.value) > 1 {
+			term.negate = true
+			term.value = term.value[1:]
+		}
+		if op, value, ok := strings.Cut(term.value, ":"); ok && !strings.HasPrefix(term.value, `"`) {
+			term.op = strings.ToLower(op)
+			term.value = value
+			switch term.op {
+			case "from", "to", "cc", "subject", "label", "is", "in", "has", "rfc822msgid":
+			case "after", "before":
+				if _, err := parseQueryDate(value); err != nil {
+					return nil, fmt.Errorf("Invalid query: %s", token)
+				}
+			default:
+				// Unknown operators are matched as free text, like "foo:bar"
+				term.op, term.value = "", token
+			}
+		}
+		term.value = strings.ToLower(strings.Trim(term.value, `"`))
+		result = append(result, term)
+	}
+	return result, nil
+}
+
+// tokenizeQuery splits q on whitespace, keeping quoted phrases together.
+func tokenizeQuery(q string) []string {
+	var tokens []string
+	var current strings.Builder
+	inQuotes := false
+	for _, r := range q {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+func parseQueryDate(value string) (time.Time, error) {
+	return time.Parse("2006/01/02", strings.ReplaceAll(value, "-", "/"))
+}
+
+// searchesSpamTrash reports whether the query explicitly targets spam or
+// trash, which messages.list otherwise excludes.
+func (q query) searchesSpamTrash() bool {
+	for _, term := range q {
+		if term.op == "in" && !term.negate && (term.value == "spam" || term.value == "trash" || term.value == "anywhere") {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether m satisfies every term of the query.
+func (q query) matches(m *storedMessage, labels map[string]*gmail.Label) bool {
+	for _, term := range q {
+		if term.matches(m, labels) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t queryTerm) matches(m *storedMessage, labels map[string]*gmail.Label) bool {
+	header := func(name string) string {
+		return strings.ToLower(lookupHeader(m.payload.Headers, name))
+	}
+	switch t.op {
+	case "from", "to", "cc", "subject":
+		return strings.Contains(header(t.op), t.value)
+	case "label":
+		return hasLabelNamed(m, labels, t.value)
+	case "is":
+		switch t.value {
+		case "read":
+			return !m.hasLabel("UNREAD")
+		default:
+			return m.hasLabel(strings.ToUpper(t.value))
+		}
+	case "in":
+		switch t.value {
+		case "anywhere":
+			return true
+		case "drafts":
+			return m.hasLabel("DRAFT")
+		default:
+			return m.hasLabel(strings.ToUpper(t.value))
+		}
+	case "has":
+		return t.value == "attachment" && hasAttachments(m.payload)
+	case "rfc822msgid":
+		return strings.Trim(header("Message-ID"), "<>") == strings.Trim(t.value, "<>")
+	case "after", "before":
+		day, _ := parseQueryDate(t.value)
+		received := time.UnixMilli(m.internalDate)
+		if t.op == "after" {
+			return !received.Before(day)
+		}
+		return received.Before(day)
+	default:
+		haystack := strings.ToLower(strings.Join([]string{
+			lookupHeader(m.payload.Headers, "Subject"),
+			lookupHeader(m.payload.Headers, "From"),
+			lookupHeader(m.payload.Headers, "To"),
+			m.text,
+		}, "\n"))
+		return strings.Contains(haystack, t.value)
+	}
+}
+
+// hasLabelNamed reports whether m carries the label called name. As in Gmail,
+// label names match case-insensitively with spaces and slashes written as "-".
+func hasLabelNamed(m *storedMessage, labels map[string]*gmail.Label, name string) bool {
+	normalize := strings.NewReplacer(" ", "-", "/", "-").Replace
+	for _, id := range m.labelIDs {
+		label, ok := labels[id]
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(label.Id, name) || strings.EqualFold(normalize(label.Name), normalize(name)) {
+			return true
+		}
+	}
+	return false
+}
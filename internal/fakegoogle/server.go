@@ -0,0 +1,233 @@
+// Package fakegoogle provides an in-memory stand-in for the Gmail and Calendar
+// REST APIs used by gsuite. It runs on an httptest server so commands can be
+// exercised end-to-end without network access or real credentials.
+//
+// Point the CLI at a running fake by setting GSUITE_API_ENDPOINT to its URL:
+//
+//	srv := fakegoogle.NewServer("me@example.com")
+//	defer srv.Close()
+//	os.Setenv("GSUITE_API_ENDPOINT", srv.URL)
+//
+// The fake implements the subset of each API that gsuite calls, with simple
+// but faithful semantics: list pagination, label bookkeeping, threading, draft
+// sending, multipart and resumable media uploads, and a small subset of Gmail
+// search operators.
+package fakegoogle
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	calendar "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
+)
+
+// Server is a running fake Gmail and Calendar API server.
+type Server struct {
+	// URL is the base URL of the server, suitable for GSUITE_API_ENDPOINT.
+	URL string
+
+	srv   *httptest.Server
+	email string
+
+	mu        sync.Mutex
+	nextID    int
+	historyID uint64
+	now       time.Time
+
+	// history holds one record per mailbox change, oldest first. History
+	// IDs below historyStart are reported as expired.
+	history      []*gmail.History
+	historyStart uint64
+	watch        *gmail.WatchRequest
+
+	messages map[string]*storedMessage
+	order    []string // message IDs in insertion order
+	labels   map[string]*gmail.Label
+	drafts   map[string]string // draft ID -> message ID
+	filters  []*gmail.Filter
+	vacation gmail.VacationSettings
+	sendAs   []*gmail.SendAs
+
+	// uploads holds the resumable upload sessions in progress by ID.
+	uploads          map[string]*uploadSession
+	uploadTypes      []string
+	failUploadChunks int
+
+	calendars map[string]*calendar.CalendarListEntry
+	events    map[string]map[string]*calendar.Event // calendar ID -> event ID -> event
+}
+
+// NewServer starts a fake server for the account email. The primary calendar,
+// the primary send-as address and the Gmail system labels exist from the
+// start. Call Close when done.
+func NewServer(email string) *Server {
+	s := &Server{
+		email:     email,
+		historyID: 1000,
+		now:       time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC),
+		messages:  make(map[string]*storedMessage),
+		labels:    make(map[string]*gmail.Label),
+		drafts:    make(map[string]string),
+		uploads:   make(map[string]*uploadSession),
+		calendars: make(map[string]*calendar.CalendarListEntry),
+		events:    make(map[string]map[string]*calendar.Event),
+	}
+	for _, id := range systemLabels {
+		s.labels[id] = &gmail.Label{
+			Id:                    id,
+			Name:                  id,
+			Type:                  "system",
+			MessageListVisibility: "show",
+			LabelListVisibility:   "labelShow",
+		}
+	}
+	s.sendAs = []*gmail.SendAs{{
+		SendAsEmail: email,
+		IsPrimary:   true,
+		IsDefault:   true,
+	}}
+	s.calendars[email] = &calendar.CalendarListEntry{
+		Id:         email,
+		Summary:    email,
+		Primary:    true,
+		AccessRole: "owner",
+		TimeZone:   "UTC",
+	}
+	s.events[email] = make(map[string]*calendar.Event)
+
+	mux := http.NewServeMux()
+	s.registerGmail(mux)
+	s.registerUploads(mux)
+	s.registerSettings(mux)
+	s.registerCalendar(mux)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "notFound", fmt.Sprintf("fakegoogle: no handler for %s %s", r.Method, r.URL.Path))
+	})
+
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Email returns the account the server impersonates.
+func (s *Server) Email() string {
+	return s.email
+}
+
+// newID returns a unique identifier with the given prefix. Callers must hold s.mu.
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%d", prefix, s.nextID)
+}
+
+// nextHistoryID advances and returns the mailbox history ID. Callers must hold s.mu.
+func (s *Server) nextHistoryID() uint64 {
+	s.historyID++
+	return s.historyID
+}
+
+// tick advances the server clock by one minute and returns it, so stored
+// items get distinct, increasing timestamps. Callers must hold s.mu.
+func (s *Server) tick() time.Time {
+	s.now = s.now.Add(time.Minute)
+	return s.now
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v) //nolint:errcheck
+}
+
+// writeEmpty writes the empty response used by delete endpoints.
+func writeEmpty(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError writes an error in the Google API JSON error format, which
+// googleapi.CheckResponse decodes into a *googleapi.Error.
+func writeError(w http.ResponseWriter, code int, reason, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+			"errors": []map[string]string{
+				{"reason": reason, "message": message},
+			},
+		},
+	})
+}
+
+// writeNotFound writes the 404 error Google returns for unknown IDs.
+func writeNotFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, "notFound", "Requested entity was not found.")
+}
+
+// readJSON decodes the request body into v, writing a 400 error on failure.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", fmt.Sprintf("invalid JSON body: %v", err))
+		return false
+	}
+	return true
+}
+
+// mergeJSON overlays the top-level fields of patch onto the JSON form of
+// current and decodes the result into target, as PATCH requests do. It
+// writes an error response and returns false on failure.
+func mergeJSON(w http.ResponseWriter, current interface{}, patch map[string]json.RawMessage, target interface{}) bool {
+	data, err := json.Marshal(current)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "backendError", err.Error())
+		return false
+	}
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(data, &merged); err != nil {
+		writeError(w, http.StatusInternalServerError, "backendError", err.Error())
+		return false
+	}
+	for key, value := range patch {
+		merged[key] = value
+	}
+	if data, err = json.Marshal(merged); err != nil {
+		writeError(w, http.StatusInternalServerError, "backendError", err.Error())
+		return false
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid", err.Error())
+		return false
+	}
+	return true
+}
+
+// paginate returns the page of items selected by the request's pageToken and
+// size, along with the token for the following page. Page tokens are offsets.
+func paginate[T any](items []T, r *http.Request, sizeParam string, defaultSize int) ([]T, string) {
+	start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	size, err := strconv.Atoi(r.URL.Query().Get(sizeParam))
+	if err != nil || size <= 0 {
+		size = defaultSize
+	}
+	if start < 0 || start > len(items) {
+		start = len(items)
+	}
+	end := min(start+size, len(items))
+	next := ""
+	if end < len(items) {
+		next = strconv.Itoa(end)
+	}
+	return items[start:end], next
+}
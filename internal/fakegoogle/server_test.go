@@ -0,0 +1,435 @@
+package fakegoogle
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	calendar "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+const (
+	plainMessage = "From: Alice <alice@example.com>\r\n" +
+		"To: me@example.com\r\n" +
+		"Subject: Quarterly report\r\n" +
+		"Message-ID: <report@example.com>\r\n" +
+		"\r\n" +
+		"The numbers are in.\r\n"
+
+	replyMessage = "From: me@example.com\r\n" +
+		"To: alice@example.com\r\n" +
+		"Subject: Re: Quarterly report\r\n" +
+		"In-Reply-To: <report@example.com>\r\n" +
+		"\r\n" +
+		"Thanks!\r\n"
+
+	attachmentMessage = "From: Bob <bob@example.com>\r\n" +
+		"To: me@example.com\r\n" +
+		"Subject: Invoice\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"b1\"\r\n" +
+		"\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/plain; charset=\"UTF-8\"\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"Invoice attached =E2=9C=93\r\n" +
+		"--b1\r\n" +
+		"Content-Type: application/pdf; name=\"invoice.pdf\"\r\n" +
+		"Content-Disposition: attachment; filename=\"invoice.pdf\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"JVBERi0xLjQ=\r\n" +
+		"--b1--\r\n"
+)
+
+func newTestServices(t *testing.T) (*Server, *gmail.Service, *calendar.Service) {
+	t.Helper()
+	srv := NewServer("me@example.com")
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+	gmailService, err := gmail.NewService(ctx, option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("gmail.NewService() error: %v", err)
+	}
+	calendarService, err := calendar.NewService(ctx, option.WithEndpoint(srv.URL+"/calendar/v3/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("calendar.NewService() error: %v", err)
+	}
+	return srv, gmailService, calendarService
+}
+
+func TestMessagesListQuery(t *testing.T) {
+	t.Parallel()
+
+	srv, service, _ := newTestServices(t)
+	projects := srv.AddLabel("Work/Projects")
+	report := srv.AddMessage(plainMessage, "INBOX", "UNREAD", projects)
+	reply := srv.AddMessage(replyMessage, "SENT")
+	invoice := srv.AddMessage(attachmentMessage, "INBOX")
+	srv.AddMessage(plainMessage, "TRASH")
+
+	tests := []struct {
+		name     string
+		query    string
+		labelIDs []string
+		want     []string
+	}{
+		{name: "should list everything but spam and trash newest first", want: []string{invoice, reply, report}},
+		{name: "should filter by sender", query: "from:alice", want: []string{report}},
+		{name: "should filter by unread", query: "is:unread", want: []string{report}},
+		{name: "should negate terms", query: "-is:unread in:inbox", want: []string{invoice}},
+		{name: "should match nested label names", query: "label:work-projects", want: []string{report}},
+		{name: "should match attachments", query: "has:attachment", want: []string{invoice}},
+		{name: "should match quoted free text", query: `"numbers are"`, want: []string{report}},
+		{name: "should match a Message-ID within a group", query: "rfc822msgid:report@example.com (from:alice)", want: []string{report}},
+		{name: "should filter by label ID", labelIDs: []string{"SENT"}, want: []string{reply}},
+		{name: "should include trash when asked", query: "in:trash quarterly", want: []string{srv.MessageIDs("TRASH")[0]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := service.Users.Messages.List("me").Q(tt.query).LabelIds(tt.labelIDs...).Do()
+			if err != nil {
+				t.Fatalf("List() error: %v", err)
+			}
+			var got []string
+			for _, m := range resp.Messages {
+				got = append(got, m.Id)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("List() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("List() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestMessagesListPagination(t *testing.T) {
+	t.Parallel()
+
+	srv, service, _ := newTestServices(t)
+	for i := 0; i < 5; i++ {
+		srv.AddMessage(plainMessage, "INBOX")
+	}
+
+	var pages int
+	var total int
+	err := service.Users.Messages.List("me").MaxResults(2).Pages(context.Background(), func(resp *gmail.ListMessagesResponse) error {
+		pages++
+		total += len(resp.Messages)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Pages() error: %v", err)
+	}
+	if pages != 3 || total != 5 {
+		t.Errorf("Pages() = %d pages with %d messages, want 3 pages with 5 messages", pages, total)
+	}
+}
+
+func TestMessagesGetParsesMIME(t *testing.T) {
+	t.Parallel()
+
+	srv, service, _ := newTestServices(t)
+	id := srv.AddMessage(attachmentMessage, "INBOX")
+
+	msg, err := service.Users.Messages.Get("me", id).Format("full").Do()
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if msg.Payload.MimeType != "multipart/mixed" || len(msg.Payload.Parts) != 2 {
+		t.Fatalf("Get() payload = %s with %d parts, want multipart/mixed with 2", msg.Payload.MimeType, len(msg.Payload.Parts))
+	}
+	if msg.Snippet != "Invoice attached ✓" {
+		t.Errorf("Get() snippet = %q, want quoted-printable decoded text", msg.Snippet)
+	}
+
+	attachment := msg.Payload.Parts[1]
+	if attachment.Filename != "invoice.pdf" || attachment.Body.AttachmentId == "" {
+		t.Fatalf("Get() attachment part = %+v, want invoice.pdf with an attachment ID", attachment)
+	}
+	body, err := service.Users.Messages.Attachments.Get("me", id, attachment.Body.AttachmentId).Do()
+	if err != nil {
+		t.Fatalf("Attachments.Get() error: %v", err)
+	}
+	data, _ := base64.URLEncoding.DecodeString(body.Data)
+	if string(data) != "%PDF-1.4" {
+		t.Errorf("Attachments.Get() data = %q, want %q", data, "%PDF-1.4")
+	}
+
+	metadata, err := service.Users.Messages.Get("me", id).Format("metadata").MetadataHeaders("Subject").Do()
+	if err != nil {
+		t.Fatalf("Get(metadata) error: %v", err)
+	}
+	if len(metadata.Payload.Headers) != 1 || metadata.Payload.Headers[0].Value != "Invoice" {
+		t.Errorf("Get(metadata) headers = %+v, want only Subject", metadata.Payload.Headers)
+	}
+}
+
+func TestThreadsGroupReplies(t *testing.T) {
+	t.Parallel()
+
+	srv, service, _ := newTestServices(t)
+	report := srv.AddMessage(plainMessage, "INBOX")
+	srv.AddMessage(replyMessage, "SENT")
+
+	original, _ := srv.Message(report)
+	thread, err := service.Users.Threads.Get("me", original.ThreadId).Do()
+	if err != nil {
+		t.Fatalf("Threads.Get() error: %v", err)
+	}
+	if len(thread.Messages) != 2 {
+		t.Errorf("Threads.Get() = %d messages, want 2", len(thread.Messages))
+	}
+
+	if _, err := service.Users.Threads.Trash("me", original.ThreadId).Do(); err != nil {
+		t.Fatalf("Threads.Trash() error: %v", err)
+	}
+	if ids := srv.MessageIDs("TRASH"); len(ids) != 2 {
+		t.Errorf("after Threads.Trash() trash has %d messages, want 2", len(ids))
+	}
+}
+
+func TestHistoryList(t *testing.T) {
+	t.Parallel()
+
+	srv, service, _ := newTestServices(t)
+	start := srv.HistoryID()
+	id := srv.AddMessage(plainMessage, "INBOX", "UNREAD")
+	if _, err := service.Users.Messages.Modify("me", id, &gmail.ModifyMessageRequest{
+		AddLabelIds:    []string{"STARRED", "INBOX"},
+		RemoveLabelIds: []string{"UNREAD"},
+	}).Do(); err != nil {
+		t.Fatalf("Messages.Modify() error: %v", err)
+	}
+	if err := service.Users.Messages.Delete("me", id).Do(); err != nil {
+		t.Fatalf("Messages.Delete() error: %v", err)
+	}
+
+	resp, err := service.Users.History.List("me").StartHistoryId(start).Do()
+	if err != nil {
+		t.Fatalf("History.List() error: %v", err)
+	}
+	if len(resp.History) != 3 || resp.HistoryId != srv.HistoryID() {
+		t.Fatalf("History.List() = %d records at %d, want 3 at %d", len(resp.History), resp.HistoryId, srv.HistoryID())
+	}
+	if added := resp.History[0].MessagesAdded; len(added) != 1 || added[0].Message.Id != id {
+		t.Errorf("first record messagesAdded = %+v, want %s", added, id)
+	}
+	changed := resp.History[1]
+	if len(changed.LabelsAdded) != 1 || strings.Join(changed.LabelsAdded[0].LabelIds, ",") != "STARRED" {
+		t.Errorf("second record labelsAdded = %+v, want only STARRED", changed.LabelsAdded)
+	}
+	if len(changed.LabelsRemoved) != 1 || strings.Join(changed.LabelsRemoved[0].LabelIds, ",") != "UNREAD" {
+		t.Errorf("second record labelsRemoved = %+v, want UNREAD", changed.LabelsRemoved)
+	}
+	if deleted := resp.History[2].MessagesDeleted; len(deleted) != 1 || deleted[0].Message.Id != id {
+		t.Errorf("third record messagesDeleted = %+v, want %s", deleted, id)
+	}
+
+	resp, err = service.Users.History.List("me").StartHistoryId(start).HistoryTypes("messageDeleted").Do()
+	if err != nil || len(resp.History) != 1 {
+		t.Errorf("History.List(messageDeleted) = %v, %v, want 1 record", resp, err)
+	}
+
+	srv.ExpireHistory()
+	_, err = service.Users.History.List("me").StartHistoryId(start).Do()
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound {
+		t.Errorf("History.List() after ExpireHistory error = %v, want 404", err)
+	}
+}
+
+func TestDraftsSend(t *testing.T) {
+	t.Parallel()
+
+	srv, service, _ := newTestServices(t)
+	draft, err := service.Users.Drafts.Create("me", &gmail.Draft{
+		Message: &gmail.Message{Raw: base64.URLEncoding.EncodeToString([]byte(plainMessage))},
+	}).Do()
+	if err != nil {
+		t.Fatalf("Drafts.Create() error: %v", err)
+	}
+
+	sent, err := service.Users.Drafts.Send("me", &gmail.Draft{Id: draft.Id}).Do()
+	if err != nil {
+		t.Fatalf("Drafts.Send() error: %v", err)
+	}
+	if len(srv.DraftIDs()) != 0 {
+		t.Errorf("Drafts.Send() left drafts %v", srv.DraftIDs())
+	}
+	if ids := srv.MessageIDs("SENT"); len(ids) != 1 || ids[0] != sent.Id {
+		t.Errorf("SENT messages = %v, want [%s]", ids, sent.Id)
+	}
+}
+
+func TestLabelsErrors(t *testing.T) {
+	t.Parallel()
+
+	srv, service, _ := newTestServices(t)
+	srv.AddLabel("Receipts")
+	id := srv.AddMessage(plainMessage, "INBOX")
+
+	tests := []struct {
+		name     string
+		call     func() error
+		wantCode int
+	}{
+		{
+			name: "should reject duplicate label names",
+			call: func() error {
+				_, err := service.Users.Labels.Create("me", &gmail.Label{Name: "receipts"}).Do()
+				return err
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "should reject unknown labels in modify",
+			call: func() error {
+				_, err := service.Users.Messages.Modify("me", id, &gmail.ModifyMessageRequest{AddLabelIds: []string{"Label_404"}}).Do()
+				return err
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "should return not found for unknown messages",
+			call: func() error {
+				_, err := service.Users.Messages.Get("me", "missing").Do()
+				return err
+			},
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gErr *googleapi.Error
+			if err := tt.call(); !errors.As(err, &gErr) || gErr.Code != tt.wantCode {
+				t.Errorf("error = %v, want googleapi error %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestEventsLifecycle(t *testing.T) {
+	t.Parallel()
+
+	srv, _, service := newTestServices(t)
+	srv.AddEvent("primary", &calendar.Event{
+		Summary: "Later",
+		Start:   &calendar.EventDateTime{DateTime: "2026-03-02T10:00:00Z"},
+		End:     &calendar.EventDateTime{DateTime: "2026-03-02T11:00:00Z"},
+	})
+
+	created, err := service.Events.Insert("primary", &calendar.Event{
+		Summary: "Standup",
+		Start:   &calendar.EventDateTime{DateTime: "2026-03-01T09:00:00Z"},
+		End:     &calendar.EventDateTime{DateTime: "2026-03-01T09:15:00Z"},
+	}).Do()
+	if err != nil {
+		t.Fatalf("Events.Insert() error: %v", err)
+	}
+
+	if _, err := service.Events.Patch("primary", created.Id, &calendar.Event{Location: "Room 1"}).Do(); err != nil {
+		t.Fatalf("Events.Patch() error: %v", err)
+	}
+	patched, _ := srv.Event("primary", created.Id)
+	if patched.Summary != "Standup" || patched.Location != "Room 1" {
+		t.Errorf("after Patch() event = %q at %q, want Standup at Room 1", patched.Summary, patched.Location)
+	}
+
+	list, err := service.Events.List("primary").TimeMin("2026-03-01T00:00:00Z").TimeMax("2026-03-03T00:00:00Z").OrderBy("startTime").Do()
+	if err != nil {
+		t.Fatalf("Events.List() error: %v", err)
+	}
+	if len(list.Items) != 2 || list.Items[0].Summary != "Standup" {
+		t.Fatalf("Events.List() = %d items, want Standup first of 2", len(list.Items))
+	}
+
+	if err := service.Events.Delete("primary", created.Id).Do(); err != nil {
+		t.Fatalf("Events.Delete() error: %v", err)
+	}
+	list, err = service.Events.List("primary").TimeMin("2026-03-01T00:00:00Z").Do()
+	if err != nil {
+		t.Fatalf("Events.List() error: %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Errorf("Events.List() after delete = %d items, want 1", len(list.Items))
+	}
+	var gErr *googleapi.Error
+	if err := service.Events.Delete("primary", created.Id).Do(); !errors.As(err, &gErr) || gErr.Code != http.StatusGone {
+		t.Errorf("second Events.Delete() error = %v, want 410", err)
+	}
+}
+
+func TestMessagesSendUpload(t *testing.T) {
+	t.Parallel()
+
+	srv, service, _ := newTestServices(t)
+	sent, err := service.Users.Messages.Send("me", &gmail.Message{}).
+		Media(strings.NewReader(plainMessage), googleapi.ContentType("message/rfc822")).Do()
+	if err != nil {
+		t.Fatalf("Messages.Send() multipart upload error: %v", err)
+	}
+	if raw, _ := srv.RawMessage(sent.Id); string(raw) != plainMessage {
+		t.Errorf("stored message = %q, want %q", raw, plainMessage)
+	}
+
+	large := plainMessage + strings.Repeat("x", googleapi.MinUploadChunkSize*2)
+	srv.FailUploadChunks(1)
+	sent, err = service.Users.Messages.Send("me", &gmail.Message{}).
+		Media(strings.NewReader(large), googleapi.ContentType("message/rfc822"), googleapi.ChunkSize(googleapi.MinUploadChunkSize)).Do()
+	if err != nil {
+		t.Fatalf("Messages.Send() resumable upload error: %v", err)
+	}
+	if raw, _ := srv.RawMessage(sent.Id); string(raw) != large {
+		t.Errorf("stored message has %d bytes, want %d", len(raw), len(large))
+	}
+	if got := srv.UploadTypes(); len(got) != 2 || got[0] != "multipart" || got[1] != "resumable" {
+		t.Errorf("UploadTypes() = %v, want [multipart resumable]", got)
+	}
+
+	_, err = service.Users.Messages.Send("me", &gmail.Message{}).
+		Media(strings.NewReader(plainMessage), googleapi.ContentType("text/plain")).Do()
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusBadRequest {
+		t.Errorf("Messages.Send() with text/plain media error = %v, want 400", err)
+	}
+}
+
+func TestDraftsCreateUpload(t *testing.T) {
+	t.Parallel()
+
+	srv, service, _ := newTestServices(t)
+	draft, err := service.Users.Drafts.Create("me", &gmail.Draft{}).
+		Media(strings.NewReader(plainMessage), googleapi.ContentType("message/rfc822")).Do()
+	if err != nil {
+		t.Fatalf("Drafts.Create() upload error: %v", err)
+	}
+	if got := srv.DraftIDs(); len(got) != 1 || got[0] != draft.Id {
+		t.Fatalf("DraftIDs() = %v, want [%s]", got, draft.Id)
+	}
+	if raw, _ := srv.RawMessage(draft.Message.Id); string(raw) != plainMessage {
+		t.Errorf("stored draft = %q, want %q", raw, plainMessage)
+	}
+
+	sent, err := service.Users.Drafts.Send("me", &gmail.Draft{Id: draft.Id}).Do()
+	if err != nil {
+		t.Fatalf("Drafts.Send() error: %v", err)
+	}
+	if sent.Id != draft.Message.Id {
+		t.Errorf("sent message ID = %q, want %q", sent.Id, draft.Message.Id)
+	}
+}
@@ -0,0 +1,209 @@
+package fakegoogle
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// Filters returns the stored filters in creation order.
+func (s *Server) Filters() []*gmail.Filter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]*gmail.Filter, len(s.filters))
+	copy(result, s.filters)
+	return result
+}
+
+// Vacation returns the current vacation responder settings.
+func (s *Server) Vacation() gmail.VacationSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.vacation
+}
+
+// AddSendAs adds a send-as alias, e.g. with a VerificationStatus of
+// "accepted" or "pending".
+func (s *Server) AddSendAs(alias *gmail.SendAs) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendAs = append(s.sendAs, alias)
+}
+
+// SendAs returns the send-as alias for email, or nil.
+func (s *Server) SendAs(email string) *gmail.SendAs {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.sendAsIndex(email); i >= 0 {
+		alias := *s.sendAs[i]
+		return &alias
+	}
+	return nil
+}
+
+func (s *Server) registerSettings(mux *http.ServeMux) {
+	const settings = "/gmail/v1/users/{userId}/settings"
+
+	mux.HandleFunc("GET "+settings+"/filters", s.handleFiltersList)
+	mux.HandleFunc("POST "+settings+"/filters", s.handleFiltersCreate)
+	mux.HandleFunc("GET "+settings+"/filters/{id}", s.handleFiltersGet)
+	mux.HandleFunc("DELETE "+settings+"/filters/{id}", s.handleFiltersDelete)
+	mux.HandleFunc("GET "+settings+"/vacation", s.handleVacationGet)
+	mux.HandleFunc("PUT "+settings+"/vacation", s.handleVacationUpdate)
+	mux.HandleFunc("GET "+settings+"/sendAs", s.handleSendAsList)
+	mux.HandleFunc("GET "+settings+"/sendAs/{email}", s.handleSendAsGet)
+	mux.HandleFunc("PATCH "+settings+"/sendAs/{email}", s.handleSendAsPatch)
+}
+
+// filterIndex returns the position of the filter with id, or -1. Callers must hold s.mu.
+func (s *Server) filterIndex(id string) int {
+	for i, f := range s.filters {
+		if f.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Server) handleFiltersList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, &gmail.ListFiltersResponse{Filter: s.filters})
+}
+
+func (s *Server) handleFiltersGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.filterIndex(r.PathValue("id"))
+	if i < 0 {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, s.filters[i])
+}
+
+// handleFiltersCreate validates a new filter the way Gmail does: it needs
+// criteria and an action, and every label it adds or removes must exist.
+func (s *Server) handleFiltersCreate(w http.ResponseWriter, r *http.Request) {
+	var f gmail.Filter
+	if !readJSON(w, r, &f) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.Criteria == nil || f.Action == nil {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Filter must have criteria and an action")
+		return
+	}
+	if id, ok := s.checkLabels(f.Action.AddLabelIds, f.Action.RemoveLabelIds); !ok {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid label: "+id)
+		return
+	}
+	for _, existing := range s.filters {
+		if sameFilter(existing, &f) {
+			writeError(w, http.StatusBadRequest, "failedPrecondition", "Filter already exists")
+			return
+		}
+	}
+	f.Id = s.newID("filter-")
+	s.filters = append(s.filters, &f)
+	writeJSON(w, &f)
+}
+
+func (s *Server) handleFiltersDelete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.filterIndex(r.PathValue("id"))
+	if i < 0 {
+		writeNotFound(w)
+		return
+	}
+	s.filters = append(s.filters[:i], s.filters[i+1:]...)
+	writeEmpty(w)
+}
+
+// sameFilter reports whether two filters have identical criteria and actions.
+func sameFilter(a, b *gmail.Filter) bool {
+	aJSON, errA := a.Criteria.MarshalJSON()
+	bJSON, errB := b.Criteria.MarshalJSON()
+	if errA != nil || errB != nil || string(aJSON) != string(bJSON) {
+		return false
+	}
+	aJSON, errA = a.Action.MarshalJSON()
+	bJSON, errB = b.Action.MarshalJSON()
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+func (s *Server) handleVacationGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, &s.vacation)
+}
+
+// handleVacationUpdate replaces the vacation settings. Like Gmail, it rejects
+// an end time that is not after the start time.
+func (s *Server) handleVacationUpdate(w http.ResponseWriter, r *http.Request) {
+	var v gmail.VacationSettings
+	if !readJSON(w, r, &v) {
+		return
+	}
+	if v.StartTime != 0 && v.EndTime != 0 && v.EndTime <= v.StartTime {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "End time must be after start time")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vacation = v
+	writeJSON(w, &s.vacation)
+}
+
+// sendAsIndex returns the position of the alias for email, or -1. Callers must hold s.mu.
+func (s *Server) sendAsIndex(email string) int {
+	for i, alias := range s.sendAs {
+		if strings.EqualFold(alias.SendAsEmail, email) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Server) handleSendAsList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, &gmail.ListSendAsResponse{SendAs: s.sendAs})
+}
+
+func (s *Server) handleSendAsGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.sendAsIndex(r.PathValue("email"))
+	if i < 0 {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, s.sendAs[i])
+}
+
+// handleSendAsPatch merges the top-level fields of the request into the alias.
+func (s *Server) handleSendAsPatch(w http.ResponseWriter, r *http.Request) {
+	var patch map[string]json.RawMessage
+	if !readJSON(w, r, &patch) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.sendAsIndex(r.PathValue("email"))
+	if i < 0 {
+		writeNotFound(w)
+		return
+	}
+	var alias gmail.SendAs
+	if !mergeJSON(w, s.sendAs[i], patch, &alias) {
+		return
+	}
+	alias.SendAsEmail = s.sendAs[i].SendAsEmail
+	s.sendAs[i] = &alias
+	writeJSON(w, &alias)
+}
@@ -0,0 +1,253 @@
+package fakegoogle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// uploadComplete stores an uploaded message given the request's JSON
+// metadata. Callers must hold s.mu.
+type uploadComplete func(w http.ResponseWriter, meta json.RawMessage, raw []byte)
+
+// uploadSession is a resumable upload in progress.
+type uploadSession struct {
+	meta     json.RawMessage
+	data     bytes.Buffer
+	complete uploadComplete
+}
+
+// UploadTypes returns the uploadType of every media upload request that
+// started an upload, in order: "multipart" or "resumable".
+func (s *Server) UploadTypes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.uploadTypes...)
+}
+
+// FailUploadChunks makes the next n resumable upload chunk requests fail
+// with 503 Service Unavailable, as a flaky connection would.
+func (s *Server) FailUploadChunks(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failUploadChunks = n
+}
+
+func (s *Server) registerUploads(mux *http.ServeMux) {
+	const users = "/upload/gmail/v1/users/{userId}"
+
+	mux.HandleFunc("POST "+users+"/messages/send", s.handleUpload(func(w http.ResponseWriter, meta json.RawMessage, raw []byte) {
+		var m gmail.Message
+		if decodeUploadMeta(w, meta, &m) {
+			s.sendMessage(w, m, raw)
+		}
+	}))
+	mux.HandleFunc("POST "+users+"/drafts", s.handleUpload(func(w http.ResponseWriter, meta json.RawMessage, raw []byte) {
+		var d gmail.Draft
+		if decodeUploadMeta(w, meta, &d) {
+			s.createDraft(w, d, raw)
+		}
+	}))
+}
+
+// decodeUploadMeta decodes upload metadata into v, writing a 400 error on
+// failure. Empty metadata leaves v as it is.
+func decodeUploadMeta(w http.ResponseWriter, meta json.RawMessage, v interface{}) bool {
+	if len(bytes.TrimSpace(meta)) == 0 {
+		return true
+	}
+	if err := json.Unmarshal(meta, v); err != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", fmt.Sprintf("invalid JSON body: %v", err))
+		return false
+	}
+	return true
+}
+
+// handleUpload serves a media upload endpoint: a single multipart/related
+// request with the metadata and the message, or a resumable upload session
+// that receives the message in chunks. complete stores the message.
+func (s *Server) handleUpload(complete uploadComplete) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if id := r.URL.Query().Get("upload_id"); id != "" {
+			s.handleUploadChunk(w, r, id)
+			return
+		}
+		switch uploadType := r.URL.Query().Get("uploadType"); uploadType {
+		case "multipart":
+			meta, raw, ok := readMultipartUpload(w, r)
+			if !ok {
+				return
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.uploadTypes = append(s.uploadTypes, uploadType)
+			complete(w, meta, raw)
+		case "resumable":
+			if ct := r.Header.Get("X-Upload-Content-Type"); ct != "message/rfc822" {
+				writeError(w, http.StatusBadRequest, "badContent", fmt.Sprintf("Media type '%s' is not supported. Valid media types: [message/*]", ct))
+				return
+			}
+			var meta json.RawMessage
+			if !readJSON(w, r, &meta) {
+				return
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.uploadTypes = append(s.uploadTypes, uploadType)
+			id := s.newID("upload-")
+			s.uploads[id] = &uploadSession{meta: meta, complete: complete}
+			w.Header().Set("Location", s.URL+r.URL.Path+"?uploadType=resumable&upload_id="+id)
+			w.WriteHeader(http.StatusOK)
+		default:
+			writeError(w, http.StatusBadRequest, "invalidArgument", fmt.Sprintf("Invalid upload type: %q", uploadType))
+		}
+	}
+}
+
+// readMultipartUpload reads the metadata and media of a multipart upload,
+// writing a 400 error on failure.
+func readMultipartUpload(w http.ResponseWriter, r *http.Request) (json.RawMessage, []byte, bool) {
+	var meta json.RawMessage
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/related" {
+		writeError(w, http.StatusBadRequest, "badContent", "Multipart upload must be multipart/related")
+		return meta, nil, false
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	part, err := mr.NextPart()
+	if err != nil || json.NewDecoder(part).Decode(&meta) != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", "Multipart upload is missing its metadata")
+		return meta, nil, false
+	}
+	part, err = mr.NextPart()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", "Multipart upload is missing its media")
+		return meta, nil, false
+	}
+	if ct := part.Header.Get("Content-Type"); ct != "message/rfc822" {
+		writeError(w, http.StatusBadRequest, "badContent", fmt.Sprintf("Media type '%s' is not supported. Valid media types: [message/*]", ct))
+		return meta, nil, false
+	}
+	raw, err := io.ReadAll(part)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", err.Error())
+		return meta, nil, false
+	}
+	return meta, raw, true
+}
+
+// handleUploadChunk appends a chunk to a resumable upload. Until the last
+// chunk it answers "resume incomplete" the way Google does for clients that
+// send X-GUploader-No-308; the last chunk completes the upload.
+func (s *Server) handleUploadChunk(w http.ResponseWriter, r *http.Request, id string) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failUploadChunks > 0 {
+		s.failUploadChunks--
+		writeError(w, http.StatusServiceUnavailable, "backendError", "Service unavailable")
+		return
+	}
+	session, ok := s.uploads[id]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	start, total, ok := parseContentRange(r.Header.Get("Content-Range"))
+	if !ok || start != int64(session.data.Len()) {
+		writeError(w, http.StatusBadRequest, "badRequest", fmt.Sprintf("Invalid Content-Range %q at offset %d", r.Header.Get("Content-Range"), session.data.Len()))
+		return
+	}
+	session.data.Write(data)
+
+	if total < 0 || int64(session.data.Len()) < total {
+		w.Header().Set("X-Http-Status-Code-Override", "308")
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", session.data.Len()-1))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	delete(s.uploads, id)
+	session.complete(w, session.meta, session.data.Bytes())
+}
+
+// parseContentRange parses the Content-Range of an upload chunk: "bytes
+// a-b/total", "bytes a-b/*" while the total is unknown, or "bytes */total"
+// for an empty last chunk. total is -1 when unknown.
+func parseContentRange(value string) (start, total int64, ok bool) {
+	spec, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, size, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	total = -1
+	if size != "*" {
+		n, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		total = n
+	}
+	if rng == "*" {
+		return total, total, total >= 0
+	}
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
+
+// createDraft stores raw as a new draft. Callers must hold s.mu.
+func (s *Server) createDraft(w http.ResponseWriter, meta gmail.Draft, raw []byte) {
+	if len(raw) == 0 {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid raw message")
+		return
+	}
+	var threadID string
+	if meta.Message != nil {
+		threadID = meta.Message.ThreadId
+	}
+	m, err := s.storeMessage(raw, threadID, []string{"DRAFT"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidArgument", err.Error())
+		return
+	}
+	id := s.newID("r-")
+	s.drafts[id] = m.id
+	writeJSON(w, &gmail.Draft{Id: id, Message: m.toAPI("minimal", nil)})
+}
+
+// sendMessage stores raw as a sent message. Callers must hold s.mu.
+func (s *Server) sendMessage(w http.ResponseWriter, meta gmail.Message, raw []byte) {
+	if len(raw) == 0 {
+		writeError(w, http.StatusBadRequest, "invalidArgument", "Invalid raw message")
+		return
+	}
+	m, err := s.storeMessage(raw, meta.ThreadId, []string{"SENT"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidArgument", err.Error())
+		return
+	}
+	writeJSON(w, &gmail.Message{Id: m.id, ThreadId: m.threadID, LabelIds: m.labelIDs})
+}
@@ -0,0 +1,67 @@
+// Package filelock provides a cross-process advisory lock on a lock file,
+// using flock on Unix and LockFileEx on Windows. The operating system drops
+// the lock when its holder exits, so a crashed process never leaves a lock
+// behind that others have to guess is stale.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultTimeout is how long Acquire waits for a held lock.
+	DefaultTimeout = 10 * time.Second
+	// retryInterval is the delay between acquisition attempts.
+	retryInterval = 25 * time.Millisecond
+)
+
+// errLocked is returned by tryLock when another holder has the lock.
+var errLocked = errors.New("lock is held")
+
+// Lock is a held lock. Call Release when done.
+type Lock struct {
+	f *os.File
+}
+
+// Acquire locks path, creating the file if needed and retrying until timeout
+// elapses. The file itself is left in place after Release: removing it would
+// let a waiter lock the old file while a newcomer locks a new one.
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", path, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock(f)
+		if err == nil {
+			return &Lock{f: f}, nil
+		}
+		if !errors.Is(err, errLocked) {
+			f.Close() //nolint:errcheck
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if time.Now().After(deadline) {
+			f.Close() //nolint:errcheck
+			return nil, fmt.Errorf("timed out waiting for lock %s (timeout: %s)", path, timeout)
+		}
+		time.Sleep(retryInterval)
+	}
+}
+
+// Release unlocks the lock file. Safe to call on a nil Lock.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	unlockErr := unlock(l.f)
+	closeErr := l.f.Close()
+	if err := errors.Join(unlockErr, closeErr); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.f.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireAndRelease(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "test.lock")
+
+	lock, err := Acquire(path, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("lock file not created: %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	again, err := Acquire(path, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire() after Release error = %v", err)
+	}
+	again.Release() //nolint:errcheck
+}
+
+func TestAcquireTimesOutWhenHeld(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "held.lock")
+
+	lock, err := Acquire(path, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer lock.Release() //nolint:errcheck
+
+	if _, err := Acquire(path, 50*time.Millisecond); err == nil {
+		t.Fatal("expected timeout error for held lock, got nil")
+	}
+}
+
+func TestAcquireIgnoresLeftoverLockFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "leftover.lock")
+
+	// A holder that exited, or crashed, leaves the file but not the lock.
+	if err := os.WriteFile(path, []byte("12345"), 0600); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+
+	lock, err := Acquire(path, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v, want the unheld lock to be taken", err)
+	}
+	lock.Release() //nolint:errcheck
+}
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "contended.lock")
+
+	lock, err := Acquire(path, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		lock.Release() //nolint:errcheck
+	}()
+
+	next, err := Acquire(path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v, want the lock once it is released", err)
+	}
+	next.Release() //nolint:errcheck
+}
+
+func TestReleaseNilLock(t *testing.T) {
+	t.Parallel()
+	var lock *Lock
+	if err := lock.Release(); err != nil {
+		t.Errorf("Release() on nil lock error = %v", err)
+	}
+}